@@ -142,6 +142,7 @@ func (b *BlockParser) ActionUpdateAccountInfo(req *FuncTransactionHandleReq) (re
 		RegisteredAt:       builder.RegisteredAt,
 		ExpiredAt:          builder.ExpiredAt,
 	}
+	accountInfo.InitNameMeta()
 
 	var records []tables.TableRecordsInfo
 	list := builder.Records
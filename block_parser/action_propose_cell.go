@@ -73,6 +73,10 @@ func (b *BlockParser) ActionConfirmProposal(req *FuncTransactionHandleReq) (resp
 		}
 	}
 
+	for i := range accounts {
+		accounts[i].InitNameMeta()
+	}
+
 	if err = b.DbDao.UpdateAccountInfoList(accounts, records, accountIdList); err != nil {
 		resp.Err = fmt.Errorf("UpdateAccountInfo err: %s", err.Error())
 		return
@@ -6,7 +6,6 @@ import (
 	"github.com/dotbitHQ/das-lib/common"
 	"github.com/dotbitHQ/das-lib/core"
 	"github.com/dotbitHQ/das-lib/witness"
-	"gorm.io/gorm"
 	"strconv"
 )
 
@@ -188,6 +187,9 @@ func (b *BlockParser) actionUpdateSubAccountForCreate(req *FuncTransactionHandle
 			})
 		}
 	}
+	for i := range accountInfos {
+		accountInfos[i].InitNameMeta()
+	}
 	if err = b.DbDao.CreateSubAccount(subAccountIds, accountInfos, parentAccountInfo, records); err != nil {
 		return fmt.Errorf("CreateSubAccount err: %s", err.Error())
 	}
@@ -213,6 +215,7 @@ func (b *BlockParser) actionUpdateSubAccountForRenew(req *FuncTransactionHandleR
 
 		accountInfos = append(accountInfos, tables.TableAccountInfo{
 			Id:          subAcc.Id,
+			AccountId:   subAcc.AccountId,
 			BlockNumber: req.BlockNumber,
 			Outpoint:    common.OutPoint2String(req.TxHash, 0),
 			Nonce:       v.CurrentSubAccountData.Nonce,
@@ -220,16 +223,8 @@ func (b *BlockParser) actionUpdateSubAccountForRenew(req *FuncTransactionHandleR
 		})
 	}
 
-	if err := b.DbDao.Transaction(func(tx *gorm.DB) error {
-		for i := range accountInfos {
-			accountInfo := accountInfos[i]
-			if err := tx.Where("id=?", accountInfo.Id).Updates(&accountInfo).Error; err != nil {
-				return err
-			}
-		}
-		return nil
-	}); err != nil {
-		return fmt.Errorf("UpdateSubAccountForRenew err: %s", err.Error())
+	if err := b.DbDao.RenewSubAccountList(accountInfos); err != nil {
+		return fmt.Errorf("RenewSubAccountList err: %s", err.Error())
 	}
 	return nil
 }
@@ -425,6 +420,9 @@ func (b *BlockParser) ActionCreateSubAccount(req *FuncTransactionHandleReq) (res
 		subAccountIds = append(subAccountIds, v.SubAccountData.AccountId)
 	}
 
+	for i := range accountInfos {
+		accountInfos[i].InitNameMeta()
+	}
 	if err = b.DbDao.CreateSubAccount(subAccountIds, accountInfos, parentAccountInfo, nil); err != nil {
 		resp.Err = fmt.Errorf("CreateSubAccount err: %s", err.Error())
 		return
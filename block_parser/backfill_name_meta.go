@@ -0,0 +1,68 @@
+package block_parser
+
+import (
+	"das-account-indexer/tables"
+	"time"
+)
+
+// backfillNameMeta fills account_length and char_set for rows written before the columns existed,
+// from where the last run stopped. Once through the table it is not run again, rows written since have them
+func (b *BlockParser) backfillNameMeta() {
+	b.Wg.Add(1)
+	go func() {
+		defer b.Wg.Done()
+		var lastId, count uint64
+		for {
+			info, err := b.DbDao.GetBackfillInfo(tables.BackfillNameMeta)
+			if err == nil {
+				if info.Done {
+					return
+				}
+				lastId = info.LastId
+				break
+			}
+			log.Error("GetBackfillInfo err:", err.Error())
+			select {
+			case <-b.Ctx.Done():
+				return
+			case <-time.After(time.Second * 5):
+			}
+		}
+		for {
+			select {
+			case <-b.Ctx.Done():
+				return
+			default:
+			}
+			list, err := b.DbDao.FindAccountsWithoutNameMeta(lastId, 1000)
+			if err != nil {
+				log.Error("FindAccountsWithoutNameMeta err:", err.Error(), lastId)
+				time.Sleep(time.Second * 5)
+				continue
+			}
+			if len(list) == 0 {
+				if err = b.DbDao.SaveBackfillInfo(tables.BackfillNameMeta, lastId, true); err != nil {
+					log.Error("SaveBackfillInfo err:", err.Error(), lastId)
+					time.Sleep(time.Second * 5)
+					continue
+				}
+				log.Info("backfillNameMeta done:", count)
+				return
+			}
+			for i := range list {
+				list[i].InitNameMeta()
+			}
+			if err = b.DbDao.UpdateAccountNameMeta(list); err != nil {
+				log.Error("UpdateAccountNameMeta err:", err.Error(), lastId)
+				time.Sleep(time.Second * 5)
+				continue
+			}
+			lastId = list[len(list)-1].Id
+			count += uint64(len(list))
+			if err = b.DbDao.SaveBackfillInfo(tables.BackfillNameMeta, lastId, false); err != nil {
+				log.Error("SaveBackfillInfo err:", err.Error(), lastId)
+			}
+			time.Sleep(time.Millisecond * 100)
+		}
+	}()
+}
@@ -59,6 +59,13 @@ func (b *BlockParser) RunParser() error {
 	if err := b.initCurrentBlockNumber(); err != nil {
 		return fmt.Errorf("initCurrentBlockNumber err: %s", err.Error())
 	}
+	b.backfillNameMeta()
+	if err := b.initSubAccountStats(); err != nil {
+		return fmt.Errorf("initSubAccountStats err: %s", err.Error())
+	}
+	if err := b.initStateHistory(); err != nil {
+		return fmt.Errorf("initStateHistory err: %s", err.Error())
+	}
 
 	atomic.AddUint64(&b.CurrentBlockNumber, 1)
 	b.Wg.Add(1)
@@ -109,6 +116,7 @@ func (b *BlockParser) parsingBlockData(block *types.Block) error {
 	if err := b.checkContractVersion(); err != nil {
 		return err
 	}
+	b.DbDao.SetParsingBlockNumber(block.Header.Number)
 	for _, tx := range block.Transactions {
 		txHash := tx.Hash.Hex()
 		blockNumber := block.Header.Number
@@ -186,6 +194,10 @@ func (b *BlockParser) parserSubMode() error {
 			atomic.AddUint64(&b.CurrentBlockNumber, ^uint64(0))
 		} else if err = b.parsingBlockData(block); err != nil {
 			return fmt.Errorf("parsingBlockData err: %s", err.Error())
+		} else if err = b.saveStateHistory(b.CurrentBlockNumber); err != nil {
+			return fmt.Errorf("saveStateHistory err: %s", err.Error())
+		} else if err = b.pruneDeletedInfo(b.CurrentBlockNumber); err != nil {
+			return fmt.Errorf("pruneDeletedInfo err: %s", err.Error())
 		} else {
 			if err = b.DbDao.CreateBlockInfo(b.CurrentBlockNumber, blockHash, parentHash); err != nil {
 				return fmt.Errorf("CreateBlockInfo err: %s", err.Error())
@@ -228,6 +240,10 @@ func (b *BlockParser) parserConcurrencyMode() error {
 
 		if err = b.parsingBlockData(block); err != nil {
 			return fmt.Errorf("parsingBlockData err: %s", err.Error())
+		} else if err = b.saveStateHistory(b.CurrentBlockNumber); err != nil {
+			return fmt.Errorf("saveStateHistory err: %s", err.Error())
+		} else if err = b.pruneDeletedInfo(b.CurrentBlockNumber); err != nil {
+			return fmt.Errorf("pruneDeletedInfo err: %s", err.Error())
 		} else {
 			if err = b.DbDao.CreateBlockInfo(b.CurrentBlockNumber, blockHash, parentHash); err != nil {
 				return fmt.Errorf("CreateBlockInfo err: %s", err.Error())
@@ -0,0 +1,28 @@
+package block_parser
+
+import (
+	"das-account-indexer/export"
+	"fmt"
+)
+
+const (
+	deletedInfoPruneEvery  = 1000 // blocks
+	deletedInfoPruneMargin = 100  // blocks, a rollback moves the earliest block the api serves back by as much
+)
+
+// pruneDeletedInfo drops the deletes the changefeed no longer serves, it runs after the state history of the block is saved
+func (b *BlockParser) pruneDeletedInfo(blockNumber uint64) error {
+	if blockNumber%deletedInfoPruneEvery != 0 {
+		return nil
+	}
+	before := export.ChangefeedEarliestBlock(blockNumber)
+	if before <= deletedInfoPruneMargin {
+		return nil
+	}
+	count, err := b.DbDao.PruneDeletedInfo(before - deletedInfoPruneMargin)
+	if err != nil {
+		return fmt.Errorf("PruneDeletedInfo err: %s", err.Error())
+	}
+	log.Info("PruneDeletedInfo:", before-deletedInfoPruneMargin, count)
+	return nil
+}
@@ -0,0 +1,59 @@
+package block_parser
+
+import (
+	"das-account-indexer/config"
+	"fmt"
+	"time"
+)
+
+const (
+	stateHistoryRetention  = 259200 // blocks, about 30 days
+	stateHistoryPruneEvery = 1000   // blocks
+)
+
+// initStateHistory builds the state history from the tables when it is enabled the first time, or its last build
+// did not finish. Turned off, the history is dropped, so it is not read with a gap in it once enabled again.
+func (b *BlockParser) initStateHistory() error {
+	start, err := b.DbDao.GetStateHistoryStart()
+	if err != nil {
+		return fmt.Errorf("GetStateHistoryStart err: %s", err.Error())
+	}
+	if !config.Cfg.History.Enable {
+		if start.Id > 0 {
+			if err = b.DbDao.DeleteStateHistoryStart(); err != nil {
+				return fmt.Errorf("DeleteStateHistoryStart err: %s", err.Error())
+			}
+		}
+		return nil
+	} else if start.Id > 0 {
+		return nil
+	}
+	nowTime := time.Now()
+	if err = b.DbDao.InitStateHistory(b.CurrentBlockNumber); err != nil {
+		return fmt.Errorf("InitStateHistory err: %s", err.Error())
+	}
+	log.Info("initStateHistory time:", b.CurrentBlockNumber, time.Since(nowTime).Seconds())
+	return nil
+}
+
+// saveStateHistory runs after each parsed block, before it is marked done
+func (b *BlockParser) saveStateHistory(blockNumber uint64) error {
+	if !config.Cfg.History.Enable {
+		return nil
+	}
+	if err := b.DbDao.SaveStateHistory(blockNumber); err != nil {
+		return fmt.Errorf("SaveStateHistory err: %s", err.Error())
+	}
+	retention := config.Cfg.History.Retention
+	if retention == 0 {
+		retention = stateHistoryRetention
+	}
+	if blockNumber > retention && blockNumber%stateHistoryPruneEvery == 0 {
+		count, err := b.DbDao.PruneStateHistory(blockNumber - retention)
+		if err != nil {
+			return fmt.Errorf("PruneStateHistory err: %s", err.Error())
+		}
+		log.Info("PruneStateHistory:", blockNumber-retention, count)
+	}
+	return nil
+}
@@ -0,0 +1,22 @@
+package block_parser
+
+import (
+	"fmt"
+	"time"
+)
+
+// initSubAccountStats builds the sub-account stats from t_account_info the first time,
+// before the parser starts keeping them up to date
+func (b *BlockParser) initSubAccountStats() error {
+	if empty, err := b.DbDao.SubAccountStatsEmpty(); err != nil {
+		return fmt.Errorf("SubAccountStatsEmpty err: %s", err.Error())
+	} else if !empty {
+		return nil
+	}
+	nowTime := time.Now()
+	if err := b.DbDao.RebuildSubAccountStats(); err != nil {
+		return fmt.Errorf("RebuildSubAccountStats err: %s", err.Error())
+	}
+	log.Info("initSubAccountStats time:", time.Since(nowTime).Seconds())
+	return nil
+}
@@ -0,0 +1,59 @@
+package cache
+
+import (
+	"errors"
+	"github.com/scorpiotzh/mylog"
+	"time"
+)
+
+var (
+	log = mylog.NewLogger("cache", mylog.LevelDebug)
+
+	ErrNil = errors.New("cache: nil")
+)
+
+type Cache interface {
+	Get(key string) (string, error)
+	Set(key, value string, expiration time.Duration) error
+	SetNX(key, value string, expiration time.Duration) (bool, error)
+	Exists(key string) (bool, error)
+	Expire(key string, expiration time.Duration) error
+}
+
+// CacheBy is the tiered counterpart of toolib.CacheByRedis: data is kept for dataExpiration,
+// refreshed by a single caller once updateExpiration has passed, the others keep serving the stale copy.
+// An empty result means cacheHandle has already written the response.
+func CacheBy(c Cache, key string, dataExpiration, lockExpiration, updateExpiration time.Duration, cacheHandle func() (string, error)) (string, error) {
+	updateExpirationKey := "uek:" + key
+	lockExpirationKey := "lek:" + key
+
+	dataStr, err := c.Get(key)
+	if err == ErrNil {
+		if dataStr, err = cacheHandle(); err != nil {
+			return "", err
+		} else if err = c.Set(key, dataStr, dataExpiration); err != nil {
+			return "", err
+		}
+		_ = c.Set(updateExpirationKey, "", updateExpiration)
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+
+	if ok, err := c.Exists(updateExpirationKey); err != nil {
+		return "", err
+	} else if ok {
+		return dataStr, nil
+	}
+	if ok, err := c.SetNX(lockExpirationKey, "", lockExpiration); err != nil || !ok {
+		return dataStr, nil
+	}
+	if dataStr, err = cacheHandle(); err != nil {
+		return "", err
+	} else if err = c.Set(key, dataStr, dataExpiration); err != nil {
+		return "", err
+	}
+	_ = c.Set(updateExpirationKey, "", updateExpiration)
+	_ = c.Expire(lockExpirationKey, time.Second*5)
+	return "", nil
+}
@@ -0,0 +1,128 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+const DefaultLruSize = 10000
+
+type lruEntry struct {
+	key       string
+	value     string
+	expiredAt time.Time
+}
+
+func (e *lruEntry) isExpired(now time.Time) bool {
+	return !e.expiredAt.IsZero() && !now.Before(e.expiredAt)
+}
+
+// LruCache is an in-process cache bounded by the number of entries,
+// the least recently used entry is evicted first.
+type LruCache struct {
+	l     sync.Mutex
+	size  int
+	ll    *list.List
+	items map[string]*list.Element
+}
+
+func NewLruCache(size int) *LruCache {
+	if size <= 0 {
+		size = DefaultLruSize
+	}
+	return &LruCache{
+		size:  size,
+		ll:    list.New(),
+		items: make(map[string]*list.Element),
+	}
+}
+
+func (c *LruCache) Get(key string) (string, error) {
+	c.l.Lock()
+	defer c.l.Unlock()
+
+	e := c.get(key)
+	if e == nil {
+		return "", ErrNil
+	}
+	c.ll.MoveToFront(c.items[key])
+	return e.value, nil
+}
+
+func (c *LruCache) Set(key, value string, expiration time.Duration) error {
+	c.l.Lock()
+	defer c.l.Unlock()
+
+	c.set(key, value, expiration)
+	return nil
+}
+
+func (c *LruCache) SetNX(key, value string, expiration time.Duration) (bool, error) {
+	c.l.Lock()
+	defer c.l.Unlock()
+
+	if c.get(key) != nil {
+		return false, nil
+	}
+	c.set(key, value, expiration)
+	return true, nil
+}
+
+func (c *LruCache) Exists(key string) (bool, error) {
+	c.l.Lock()
+	defer c.l.Unlock()
+
+	return c.get(key) != nil, nil
+}
+
+func (c *LruCache) Expire(key string, expiration time.Duration) error {
+	c.l.Lock()
+	defer c.l.Unlock()
+
+	if e := c.get(key); e != nil {
+		e.expiredAt = time.Now().Add(expiration)
+	}
+	return nil
+}
+
+func (c *LruCache) Len() int {
+	c.l.Lock()
+	defer c.l.Unlock()
+
+	return c.ll.Len()
+}
+
+// get returns the live entry of key and drops it when expired, the caller holds the lock
+func (c *LruCache) get(key string) *lruEntry {
+	ele, ok := c.items[key]
+	if !ok {
+		return nil
+	}
+	e := ele.Value.(*lruEntry)
+	if e.isExpired(time.Now()) {
+		c.ll.Remove(ele)
+		delete(c.items, key)
+		return nil
+	}
+	return e
+}
+
+func (c *LruCache) set(key, value string, expiration time.Duration) {
+	var expiredAt time.Time
+	if expiration > 0 {
+		expiredAt = time.Now().Add(expiration)
+	}
+	if ele, ok := c.items[key]; ok {
+		e := ele.Value.(*lruEntry)
+		e.value, e.expiredAt = value, expiredAt
+		c.ll.MoveToFront(ele)
+		return
+	}
+	c.items[key] = c.ll.PushFront(&lruEntry{key: key, value: value, expiredAt: expiredAt})
+	for c.ll.Len() > c.size {
+		ele := c.ll.Back()
+		c.ll.Remove(ele)
+		delete(c.items, ele.Value.(*lruEntry).key)
+	}
+}
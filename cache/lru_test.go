@@ -0,0 +1,54 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLruCache(t *testing.T) {
+	c := NewLruCache(2)
+	_ = c.Set("a", "1", 0)
+	_ = c.Set("b", "2", 0)
+	if _, err := c.Get("a"); err != nil {
+		t.Fatal(err)
+	}
+	_ = c.Set("c", "3", 0)
+	if _, err := c.Get("b"); err != ErrNil {
+		t.Fatal("b should be evicted")
+	}
+	if c.Len() != 2 {
+		t.Fatal("len:", c.Len())
+	}
+
+	_ = c.Set("d", "4", time.Millisecond)
+	time.Sleep(time.Millisecond * 5)
+	if ok, _ := c.Exists("d"); ok {
+		t.Fatal("d should be expired")
+	}
+	if ok, _ := c.SetNX("d", "", time.Second); !ok {
+		t.Fatal("SetNX on expired key")
+	}
+	if ok, _ := c.SetNX("d", "", time.Second); ok {
+		t.Fatal("SetNX on live key")
+	}
+}
+
+func TestCacheByWithoutRedis(t *testing.T) {
+	c := NewTieredCache(10, nil)
+	if c.Mode() != ModeLru {
+		t.Fatal("mode:", c.Mode())
+	}
+	calls := 0
+	handle := func() (string, error) {
+		calls++
+		return "data", nil
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := CacheBy(c, "k", time.Minute, time.Second, time.Minute, handle); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if calls != 1 {
+		t.Fatal("calls:", calls)
+	}
+}
@@ -0,0 +1,68 @@
+package cache
+
+import (
+	"github.com/go-redis/redis"
+	"time"
+)
+
+type RedisCache struct {
+	red *redis.Client
+}
+
+// NewRedisClient gives a client even when redis is not reachable, with the ping error, so that callers
+// run degraded and go-redis connects again once it is back. It is nil only when no address is set
+func NewRedisClient(addr, password string, dbNum int) (*redis.Client, error) {
+	if addr == "" {
+		return nil, nil
+	}
+	red := redis.NewClient(&redis.Options{
+		Addr:     addr,
+		Password: password,
+		DB:       dbNum,
+	})
+	return red, red.Ping().Err()
+}
+
+func NewRedisCache(red *redis.Client) *RedisCache {
+	return &RedisCache{red: red}
+}
+
+func (c *RedisCache) Ping() error {
+	return c.red.Ping().Err()
+}
+
+func (c *RedisCache) Get(key string) (string, error) {
+	res, err := c.red.Get(key).Result()
+	if err == redis.Nil {
+		return "", ErrNil
+	}
+	return res, err
+}
+
+func (c *RedisCache) Set(key, value string, expiration time.Duration) error {
+	return c.red.Set(key, value, expiration).Err()
+}
+
+func (c *RedisCache) SetNX(key, value string, expiration time.Duration) (bool, error) {
+	return c.red.SetNX(key, value, expiration).Result()
+}
+
+func (c *RedisCache) Exists(key string) (bool, error) {
+	count, err := c.red.Exists(key).Result()
+	return count > 0, err
+}
+
+func (c *RedisCache) Expire(key string, expiration time.Duration) error {
+	return c.red.Expire(key, expiration).Err()
+}
+
+// IncrBy adds n to the counter at key, which expires after expiration
+func (c *RedisCache) IncrBy(key string, n int64, expiration time.Duration) (int64, error) {
+	pipe := c.red.TxPipeline()
+	incr := pipe.IncrBy(key, n)
+	pipe.Expire(key, expiration)
+	if _, err := pipe.Exec(); err != nil {
+		return 0, err
+	}
+	return incr.Val(), nil
+}
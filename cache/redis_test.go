@@ -0,0 +1,39 @@
+package cache
+
+import (
+	"context"
+	"github.com/dotbitHQ/das-lib/core"
+	"sync"
+	"testing"
+	"time"
+)
+
+// redis down at startup: the clients are still there and everything that uses them runs degraded
+func TestRedisDownAtStartup(t *testing.T) {
+	red, err := NewRedisClient("127.0.0.1:1", "", 0)
+	if red == nil || err == nil {
+		t.Fatal("client:", red, "err:", err)
+	}
+
+	c := NewTieredCache(10, red)
+	if c.Mode() != ModeLruRedisDown {
+		t.Fatal("mode:", c.Mode())
+	}
+	if _, err = CacheBy(c, "k", time.Minute, time.Second, time.Minute, func() (string, error) { return "data", nil }); err != nil {
+		t.Fatal(err)
+	}
+	if res, err := c.Get("k"); err != nil || res != "data" {
+		t.Fatal(res, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	dasCore := core.NewDasCore(ctx, &sync.WaitGroup{}, core.WithDasRedis(red))
+	if _, err = dasCore.GetConfigCellByCache(core.CacheConfigCellKeyCharSet); err == nil {
+		t.Fatal("GetConfigCellByCache should fail with redis down")
+	}
+
+	if red, err = NewRedisClient("", "", 0); red != nil || err != nil {
+		t.Fatal("no address:", red, err)
+	}
+}
@@ -0,0 +1,174 @@
+package cache
+
+import (
+	"context"
+	"github.com/go-redis/redis"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type Mode = string
+
+const (
+	ModeLru          Mode = "lru"
+	ModeLruRedis     Mode = "lru+redis"
+	ModeLruRedisDown Mode = "lru(redis down)"
+
+	// entries read through from redis are kept locally no longer than this,
+	// so instances do not drift apart for long
+	defaultLocalTTL = time.Second * 5
+)
+
+// TieredCache serves from the in-process LRU first and uses redis, when configured, as the shared second tier.
+// Any redis error switches it to the LRU alone until the health check sees redis again.
+type TieredCache struct {
+	lru      *LruCache
+	red      *RedisCache
+	redisUp  int32
+	LocalTTL time.Duration
+}
+
+func NewTieredCache(lruSize int, red *redis.Client) *TieredCache {
+	t := TieredCache{
+		lru:      NewLruCache(lruSize),
+		LocalTTL: defaultLocalTTL,
+	}
+	if red != nil {
+		t.red = NewRedisCache(red)
+		if err := t.red.Ping(); err != nil {
+			log.Warn("NewTieredCache redis ping err:", err.Error())
+		} else {
+			t.redisUp = 1
+		}
+	}
+	log.Info("cache mode:", t.Mode())
+	return &t
+}
+
+func (t *TieredCache) Mode() Mode {
+	if t.red == nil {
+		return ModeLru
+	} else if atomic.LoadInt32(&t.redisUp) == 1 {
+		return ModeLruRedis
+	}
+	return ModeLruRedisDown
+}
+
+func (t *TieredCache) redisAvailable() bool {
+	return t.red != nil && atomic.LoadInt32(&t.redisUp) == 1
+}
+
+func (t *TieredCache) setRedisUp(up bool, err error) {
+	var v int32
+	if up {
+		v = 1
+	}
+	if atomic.SwapInt32(&t.redisUp, v) != v {
+		if err != nil {
+			log.Warn("cache mode:", t.Mode(), err.Error())
+		} else {
+			log.Info("cache mode:", t.Mode())
+		}
+	}
+}
+
+// RunHealthCheck pings redis every interval and moves the cache between ModeLruRedis and ModeLruRedisDown
+func (t *TieredCache) RunHealthCheck(ctx context.Context, wg *sync.WaitGroup, interval time.Duration) {
+	if t.red == nil {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				err := t.red.Ping()
+				t.setRedisUp(err == nil, err)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+func (t *TieredCache) Get(key string) (string, error) {
+	if res, err := t.lru.Get(key); err == nil {
+		return res, nil
+	}
+	if !t.redisAvailable() {
+		return "", ErrNil
+	}
+	res, err := t.red.Get(key)
+	if err == ErrNil {
+		return "", ErrNil
+	} else if err != nil {
+		t.setRedisUp(false, err)
+		return "", ErrNil
+	}
+	_ = t.lru.Set(key, res, t.LocalTTL)
+	return res, nil
+}
+
+func (t *TieredCache) Set(key, value string, expiration time.Duration) error {
+	_ = t.lru.Set(key, value, expiration)
+	if t.redisAvailable() {
+		if err := t.red.Set(key, value, expiration); err != nil {
+			t.setRedisUp(false, err)
+		}
+	}
+	return nil
+}
+
+// SetNX takes the lock in redis when it is up so that only one instance refreshes a key
+func (t *TieredCache) SetNX(key, value string, expiration time.Duration) (bool, error) {
+	if t.redisAvailable() {
+		ok, err := t.red.SetNX(key, value, expiration)
+		if err == nil {
+			return ok, nil
+		}
+		t.setRedisUp(false, err)
+	}
+	return t.lru.SetNX(key, value, expiration)
+}
+
+func (t *TieredCache) Exists(key string) (bool, error) {
+	if ok, _ := t.lru.Exists(key); ok {
+		return true, nil
+	}
+	if t.redisAvailable() {
+		ok, err := t.red.Exists(key)
+		if err == nil {
+			return ok, nil
+		}
+		t.setRedisUp(false, err)
+	}
+	return false, nil
+}
+
+func (t *TieredCache) Expire(key string, expiration time.Duration) error {
+	_ = t.lru.Expire(key, expiration)
+	if t.redisAvailable() {
+		if err := t.red.Expire(key, expiration); err != nil {
+			t.setRedisUp(false, err)
+		}
+	}
+	return nil
+}
+
+// IncrBy counts in redis so that all instances share the counter, false when redis is not there
+// and the caller has to count on its own
+func (t *TieredCache) IncrBy(key string, n int64, expiration time.Duration) (int64, bool) {
+	if !t.redisAvailable() {
+		return 0, false
+	}
+	res, err := t.red.IncrBy(key, n, expiration)
+	if err != nil {
+		t.setRedisUp(false, err)
+		return 0, false
+	}
+	return res, true
+}
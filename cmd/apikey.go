@@ -0,0 +1,139 @@
+package main
+
+import (
+	"crypto/rand"
+	"das-account-indexer/config"
+	"das-account-indexer/dao"
+	"das-account-indexer/http_server"
+	"das-account-indexer/tables"
+	"encoding/hex"
+	"fmt"
+	"github.com/urfave/cli/v2"
+)
+
+var apiKeyConfigFlag = &cli.StringFlag{
+	Name:    "config",
+	Aliases: []string{"c"},
+	Usage:   "Load configuration from `FILE`",
+}
+
+var apiKeyNameFlag = &cli.StringFlag{
+	Name:     "name",
+	Usage:    "`NAME` of the key, the label in the metrics",
+	Required: true,
+}
+
+var apiKeyCommand = &cli.Command{
+	Name:  "apikey",
+	Usage: "Manage the api keys kept in the db, the api picks changes up within a minute",
+	Subcommands: []*cli.Command{
+		{
+			Name:  "add",
+			Usage: "Add a key and print it, only its hash is kept",
+			Flags: []cli.Flag{
+				apiKeyConfigFlag,
+				apiKeyNameFlag,
+				&cli.Float64Flag{
+					Name:  "rate",
+					Usage: "Calls per second, 0 is unlimited",
+				},
+				&cli.IntFlag{
+					Name:  "burst",
+					Usage: "Calls at once, the rate rounded up when 0",
+				},
+				&cli.Int64Flag{
+					Name:  "daily-quota",
+					Usage: "Calls per UTC day, 0 is unlimited",
+				},
+			},
+			Action: runApiKeyAdd,
+		},
+		{
+			Name:   "disable",
+			Usage:  "Disable a key",
+			Flags:  []cli.Flag{apiKeyConfigFlag, apiKeyNameFlag},
+			Action: runApiKeyStatus(tables.ApiKeyStatusDisabled),
+		},
+		{
+			Name:   "enable",
+			Usage:  "Enable a key again",
+			Flags:  []cli.Flag{apiKeyConfigFlag, apiKeyNameFlag},
+			Action: runApiKeyStatus(tables.ApiKeyStatusEnabled),
+		},
+		{
+			Name:   "list",
+			Usage:  "List the keys with their limits",
+			Flags:  []cli.Flag{apiKeyConfigFlag},
+			Action: runApiKeyList,
+		},
+	},
+}
+
+func initApiKeyDao(ctx *cli.Context) (*dao.DbDao, error) {
+	if err := config.InitCfg(ctx.String("config")); err != nil {
+		return nil, err
+	}
+	dbDao, err := dao.NewGormDB(config.Cfg.DB.Mysql)
+	if err != nil {
+		return nil, fmt.Errorf("dao.NewGormDB err: %s", err.Error())
+	}
+	return dbDao, nil
+}
+
+func runApiKeyAdd(ctx *cli.Context) error {
+	dbDao, err := initApiKeyDao(ctx)
+	if err != nil {
+		return err
+	}
+	bys := make([]byte, 32)
+	if _, err = rand.Read(bys); err != nil {
+		return fmt.Errorf("rand.Read err: %s", err.Error())
+	}
+	key := hex.EncodeToString(bys)
+	info := tables.TableApiKey{
+		Name:       ctx.String("name"),
+		KeyHash:    http_server.HashApiKey(key),
+		Rate:       ctx.Float64("rate"),
+		Burst:      ctx.Int("burst"),
+		DailyQuota: ctx.Int64("daily-quota"),
+	}
+	if err = dbDao.CreateApiKey(&info); err != nil {
+		return fmt.Errorf("CreateApiKey err: %s", err.Error())
+	}
+	fmt.Println(key)
+	return nil
+}
+
+func runApiKeyStatus(status tables.ApiKeyStatus) cli.ActionFunc {
+	return func(ctx *cli.Context) error {
+		dbDao, err := initApiKeyDao(ctx)
+		if err != nil {
+			return err
+		}
+		if count, err := dbDao.UpdateApiKeyStatus(ctx.String("name"), status); err != nil {
+			return fmt.Errorf("UpdateApiKeyStatus err: %s", err.Error())
+		} else if count == 0 {
+			return fmt.Errorf("api key [%s] not found or unchanged", ctx.String("name"))
+		}
+		return nil
+	}
+}
+
+func runApiKeyList(ctx *cli.Context) error {
+	dbDao, err := initApiKeyDao(ctx)
+	if err != nil {
+		return err
+	}
+	list, err := dbDao.FindApiKeys()
+	if err != nil {
+		return fmt.Errorf("FindApiKeys err: %s", err.Error())
+	}
+	for _, v := range list {
+		status := "enabled"
+		if v.Status == tables.ApiKeyStatusDisabled {
+			status = "disabled"
+		}
+		fmt.Printf("%s\t%s\trate=%g\tburst=%d\tdaily_quota=%d\t%s\n", v.Name, status, v.Rate, v.Burst, v.DailyQuota, v.CreatedAt.Format("2006-01-02"))
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"das-account-indexer/config"
+	"das-account-indexer/dao"
+	"das-account-indexer/export"
+	"fmt"
+	"github.com/dotbitHQ/das-lib/common"
+	"github.com/urfave/cli/v2"
+	"os"
+)
+
+var exportCommand = &cli.Command{
+	Name:  "export",
+	Usage: "Export accounts with their records, reverse records and DID cells",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:    "config",
+			Aliases: []string{"c"},
+			Usage:   "Load configuration from `FILE`",
+		},
+		&cli.StringFlag{
+			Name:  "format",
+			Value: export.FormatNdjson,
+			Usage: "Output format, ndjson or csv",
+		},
+		&cli.StringFlag{
+			Name:     "out",
+			Aliases:  []string{"o"},
+			Usage:    "Write to `FILE`, stdout is taken by the log",
+			Required: true,
+		},
+		&cli.StringFlag{
+			Name:  "parent-account",
+			Usage: "Only the sub-accounts of `ACCOUNT`",
+		},
+		&cli.Uint64Flag{
+			Name:  "expired-after",
+			Usage: "Only accounts expiring at or after the unix `TIME`",
+		},
+		&cli.Uint64Flag{
+			Name:  "expired-before",
+			Usage: "Only accounts expiring before the unix `TIME`",
+		},
+		&cli.Uint64Flag{
+			Name:  "since-block",
+			Usage: "Only accounts changed at or after block `NUMBER`",
+		},
+	},
+	Action: runExport,
+}
+
+func runExport(ctx *cli.Context) error {
+	if err := config.InitCfg(ctx.String("config")); err != nil {
+		return err
+	}
+	dbDao, err := dao.NewGormDB(config.Cfg.DB.Mysql)
+	if err != nil {
+		return fmt.Errorf("dao.NewGormDB err: %s", err.Error())
+	}
+
+	filter := dao.ExportFilter{
+		ExpiredAfter:  ctx.Uint64("expired-after"),
+		ExpiredBefore: ctx.Uint64("expired-before"),
+		SinceBlock:    ctx.Uint64("since-block"),
+	}
+	if parent := ctx.String("parent-account"); parent != "" {
+		filter.ParentAccountId = common.Bytes2Hex(common.GetAccountIdByAccount(parent))
+	}
+
+	out, err := os.Create(ctx.String("out"))
+	if err != nil {
+		return fmt.Errorf("os.Create err: %s", err.Error())
+	}
+	defer out.Close()
+	w := bufio.NewWriter(out)
+	count, err := export.Export(context.Background(), dbDao, w, ctx.String("format"), filter, nil)
+	if err != nil {
+		return fmt.Errorf("export.Export err: %s", err.Error())
+	}
+	if err = w.Flush(); err != nil {
+		return fmt.Errorf("Flush err: %s", err.Error())
+	}
+	log.Info("export done:", count)
+	return nil
+}
+
+var changefeedCommand = &cli.Command{
+	Name:  "changefeed",
+	Usage: "Export everything changed in a block range as ndjson",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:    "config",
+			Aliases: []string{"c"},
+			Usage:   "Load configuration from `FILE`",
+		},
+		&cli.StringFlag{
+			Name:     "out",
+			Aliases:  []string{"o"},
+			Usage:    "Write to `FILE`, stdout is taken by the log",
+			Required: true,
+		},
+		&cli.Uint64Flag{
+			Name:     "from-block",
+			Usage:    "First block `NUMBER` of the range",
+			Required: true,
+		},
+		&cli.Uint64Flag{
+			Name:     "to-block",
+			Usage:    "Last block `NUMBER` of the range",
+			Required: true,
+		},
+	},
+	Action: runChangefeed,
+}
+
+func runChangefeed(ctx *cli.Context) error {
+	if err := config.InitCfg(ctx.String("config")); err != nil {
+		return err
+	}
+	dbDao, err := dao.NewGormDB(config.Cfg.DB.Mysql)
+	if err != nil {
+		return fmt.Errorf("dao.NewGormDB err: %s", err.Error())
+	}
+
+	block, err := dbDao.FindCurrentBlockInfo()
+	if err != nil {
+		return fmt.Errorf("FindCurrentBlockInfo err: %s", err.Error())
+	} else if earliest := export.ChangefeedEarliestBlock(block.BlockNumber); ctx.Uint64("from-block") < earliest {
+		return fmt.Errorf("from-block is older than the retention window, earliest block: %d", earliest)
+	}
+
+	out, err := os.Create(ctx.String("out"))
+	if err != nil {
+		return fmt.Errorf("os.Create err: %s", err.Error())
+	}
+	defer out.Close()
+	w := bufio.NewWriter(out)
+	count, err := export.Changefeed(context.Background(), dbDao, w, ctx.Uint64("from-block"), ctx.Uint64("to-block"), nil)
+	if err != nil {
+		return fmt.Errorf("export.Changefeed err: %s", err.Error())
+	}
+	if err = w.Flush(); err != nil {
+		return fmt.Errorf("Flush err: %s", err.Error())
+	}
+	log.Info("changefeed done:", count)
+	return nil
+}
@@ -2,23 +2,29 @@ package main
 
 import (
 	"context"
+	"crypto/ecdsa"
 	"das-account-indexer/block_parser"
+	"das-account-indexer/cache"
 	"das-account-indexer/config"
 	"das-account-indexer/dao"
+	"das-account-indexer/dns_server"
 	"das-account-indexer/http_server"
 	"das-account-indexer/http_server/handle"
+	"das-account-indexer/notify"
 	"das-account-indexer/prometheus"
+	"das-account-indexer/sign"
 	"encoding/json"
 	"fmt"
 	"github.com/dotbitHQ/das-lib/common"
 	"github.com/dotbitHQ/das-lib/core"
 	"github.com/dotbitHQ/das-lib/txbuilder"
-	"github.com/go-redis/redis"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/nervosnetwork/ckb-sdk-go/rpc"
 	"github.com/scorpiotzh/mylog"
 	"github.com/scorpiotzh/toolib"
 	"github.com/urfave/cli/v2"
 	"os"
+	"strings"
 	"sync"
 	"time"
 )
@@ -45,7 +51,8 @@ func main() {
 				Usage:   "Server Type, ``(default): api and timer server, `api`: api server, `timer`: timer server",
 			},
 		},
-		Action: runServer,
+		Action:   runServer,
+		Commands: []*cli.Command{exportCommand, changefeedCommand, apiKeyCommand},
 	}
 
 	if err := app.Run(os.Args); err != nil {
@@ -79,10 +86,14 @@ func runServer(ctx *cli.Context) error {
 	log.Info("db ok")
 
 	// cache
-	red, err := toolib.NewRedisClient(config.Cfg.Cache.Redis.Addr, config.Cfg.Cache.Redis.Password, config.Cfg.Cache.Redis.DbNum)
+	// a redis down at startup leaves the cache and das core degraded, not the service down
+	red, err := cache.NewRedisClient(config.Cfg.Cache.Redis.Addr, config.Cfg.Cache.Redis.Password, config.Cfg.Cache.Redis.DbNum)
 	if err != nil {
 		log.Error("NewRedisClient err:", err.Error())
 	}
+	tieredCache := cache.NewTieredCache(config.Cfg.Cache.Lru.Size, red)
+	tieredCache.RunHealthCheck(ctxServer, &wgServer, time.Second*10)
+	log.Info("cache ok:", tieredCache.Mode())
 
 	// ckb node
 	ckbClient, err := rpc.DialWithIndexer(config.Cfg.Chain.CkbUrl, config.Cfg.Chain.IndexUrl)
@@ -129,7 +140,7 @@ func runServer(ctx *cli.Context) error {
 	mode := ctx.String("mode")
 
 	if mode == "api" {
-		if err := initApiServer(txBuilderBase, dasCore, dbDao, red); err != nil {
+		if err := initApiServer(txBuilderBase, dasCore, dbDao, tieredCache); err != nil {
 			return fmt.Errorf("initApiServer err : %s", err.Error())
 		}
 	} else if mode == "timer" {
@@ -140,7 +151,7 @@ func runServer(ctx *cli.Context) error {
 		if err := initTimer(dasCore, dbDao); err != nil {
 			return fmt.Errorf("initTimer err : %s", err.Error())
 		}
-		if err := initApiServer(txBuilderBase, dasCore, dbDao, red); err != nil {
+		if err := initApiServer(txBuilderBase, dasCore, dbDao, tieredCache); err != nil {
 			return fmt.Errorf("initApiServer err : %s", err.Error())
 		}
 	}
@@ -181,10 +192,38 @@ func initTimer(dasCore *core.DasCore, dbDao *dao.DbDao) error {
 		return fmt.Errorf("RunParser err: %s", err.Error())
 	}
 	log.Info("block parser ok")
+
+	// expiry notices
+	cfgNotify := config.Cfg.ExpiryNotify
+	var channels []notify.ExpiryChannel
+	if cfgNotify.Webhook.Url != "" {
+		channels = append(channels, &notify.WebhookChannel{Url: cfgNotify.Webhook.Url, Secret: cfgNotify.Webhook.Secret})
+	}
+	if cfgNotify.Smtp.Addr != "" {
+		channels = append(channels, &notify.SmtpChannel{Addr: cfgNotify.Smtp.Addr, From: cfgNotify.Smtp.From})
+	}
+	if len(channels) > 0 {
+		es := notify.ExpiryScheduler{
+			Ctx:      ctxServer,
+			Wg:       &wgServer,
+			DbDao:    dbDao,
+			Channels: channels,
+			Stages:   cfgNotify.Stages,
+			Interval: time.Hour,
+		}
+		if len(es.Stages) == 0 {
+			es.Stages = []uint64{30, 7, 1}
+		}
+		if cfgNotify.Interval > 0 {
+			es.Interval = time.Duration(cfgNotify.Interval) * time.Second
+		}
+		es.Run()
+		log.Info("expiry notice ok")
+	}
 	return nil
 }
 
-func initApiServer(txBuilderBase *txbuilder.DasTxBuilderBase, dasCore *core.DasCore, dbDao *dao.DbDao, red *redis.Client) error {
+func initApiServer(txBuilderBase *txbuilder.DasTxBuilderBase, dasCore *core.DasCore, dbDao *dao.DbDao, tieredCache *cache.TieredCache) error {
 	builderConfigCell, err := dasCore.ConfigCellDataBuilderByTypeArgsList(
 		common.ConfigCellTypeArgsPreservedAccount00,
 		common.ConfigCellTypeArgsPreservedAccount01,
@@ -228,23 +267,56 @@ func initApiServer(txBuilderBase *txbuilder.DasTxBuilderBase, dasCore *core.DasC
 		mapReservedAccounts = builderConfigCell.ConfigCellPreservedAccountMap
 		mapUnAvailableAccounts = builderConfigCell.ConfigCellUnavailableAccountMap
 	}
+	var signer *sign.Signer
+	if config.Cfg.Sign.PrivateKey != "" {
+		if signer, err = sign.NewSigner(config.Cfg.Sign.Alg, config.Cfg.Sign.PrivateKey); err != nil {
+			return fmt.Errorf("NewSigner err: %s", err.Error())
+		}
+		log.Info("response signing:", signer.Alg(), signer.KeyId())
+	}
+	var ccipKey *ecdsa.PrivateKey
+	if config.Cfg.Ccip.PrivateKey != "" {
+		if ccipKey, err = crypto.HexToECDSA(strings.TrimPrefix(config.Cfg.Ccip.PrivateKey, "0x")); err != nil {
+			return fmt.Errorf("ccip private key err: %s", err.Error())
+		}
+		log.Info("ccip gateway signer:", crypto.PubkeyToAddress(ccipKey.PublicKey).Hex())
+	}
 	// http server
 	hs := &http_server.HttpServer{
 		Ctx: ctxServer,
 		//Address:        config.Cfg.Server.HttpServerAddr,
 		AddressIndexer: config.Cfg.Server.HttpServerAddrIndexer,
+		AddressGateway: config.Cfg.Gateway.Addr,
 		//AddressReverse: config.Cfg.Server.HttpServerAddrReverse,
 		H: &handle.HttpHandle{
 			Ctx:                    ctxServer,
-			Red:                    red,
+			Cache:                  tieredCache,
 			DbDao:                  dbDao,
 			DasCore:                dasCore,
 			TxBuilderBase:          txBuilderBase,
 			MapReservedAccounts:    mapReservedAccounts,
 			MapUnAvailableAccounts: mapUnAvailableAccounts,
+			Signer:                 signer,
+			CcipKey:                ccipKey,
 		},
 	}
 	hs.Run()
 	log.Info("http server ok")
+
+	// dns server
+	if config.Cfg.Dns.Addr != "" {
+		ds := &dns_server.DnsServer{
+			Ctx:   ctxServer,
+			Wg:    &wgServer,
+			Addr:  config.Cfg.Dns.Addr,
+			Zone:  config.Cfg.Dns.Zone,
+			Ttl:   config.Cfg.Dns.Ttl,
+			DbDao: dbDao,
+		}
+		if err := ds.Run(); err != nil {
+			return fmt.Errorf("dns server Run err: %s", err.Error())
+		}
+		log.Info("dns server ok")
+	}
 	return nil
 }
@@ -61,12 +61,77 @@ type CfgServer struct {
 		Mysql DbMysql `json:"mysql" yaml:"mysql"`
 	} `json:"db" yaml:"db"`
 	Cache struct {
+		Lru struct {
+			Size int `json:"size" yaml:"size"`
+		} `json:"lru" yaml:"lru"`
 		Redis struct {
 			Addr     string `json:"addr" yaml:"addr"`
 			Password string `json:"password" yaml:"password"`
 			DbNum    int    `json:"db_num" yaml:"db_num"`
 		} `json:"redis" yaml:"redis"`
 	} `json:"cache" yaml:"cache"`
+	JsonRpc struct {
+		Strict     bool `json:"strict" yaml:"strict"`
+		BatchLimit int  `json:"batch_limit" yaml:"batch_limit"`
+	} `json:"json_rpc" yaml:"json_rpc"`
+	Graphql struct {
+		MaxDepth int `json:"max_depth" yaml:"max_depth"`
+		MaxCost  int `json:"max_cost" yaml:"max_cost"`
+	} `json:"graphql" yaml:"graphql"`
+	Ccip struct {
+		PrivateKey string `json:"-" yaml:"private_key"`
+		Ttl        uint64 `json:"ttl" yaml:"ttl"`
+		NameSuffix string `json:"name_suffix" yaml:"name_suffix"`
+	} `json:"ccip" yaml:"ccip"`
+	Dns struct {
+		Addr string `json:"addr" yaml:"addr"`
+		Zone string `json:"zone" yaml:"zone"`
+		Ttl  uint32 `json:"ttl" yaml:"ttl"`
+	} `json:"dns" yaml:"dns"`
+	Gateway struct {
+		Addr     string `json:"addr" yaml:"addr"`
+		Domain   string `json:"domain" yaml:"domain"`
+		Redirect bool   `json:"redirect" yaml:"redirect"`
+		Ttl      uint32 `json:"ttl" yaml:"ttl"`
+		Ipfs     string `json:"ipfs" yaml:"ipfs"`
+		Arweave  string `json:"arweave" yaml:"arweave"`
+		Resilio  string `json:"resilio" yaml:"resilio"`
+	} `json:"gateway" yaml:"gateway"`
+	WellKnown struct {
+		Domain string            `json:"domain" yaml:"domain"`
+		Hosts  map[string]string `json:"hosts" yaml:"hosts"`
+	} `json:"well_known" yaml:"well_known"`
+	Export struct {
+		Token     string `json:"-" yaml:"token"`
+		Retention uint64 `json:"retention" yaml:"retention"`
+	} `json:"export" yaml:"export"`
+	ExpiryNotify struct {
+		Interval uint64   `json:"interval" yaml:"interval"`
+		Stages   []uint64 `json:"stages" yaml:"stages"`
+		Webhook  struct {
+			Url    string `json:"url" yaml:"url"`
+			Secret string `json:"-" yaml:"secret"`
+		} `json:"webhook" yaml:"webhook"`
+		Smtp struct {
+			Addr string `json:"addr" yaml:"addr"`
+			From string `json:"from" yaml:"from"`
+		} `json:"smtp" yaml:"smtp"`
+	} `json:"expiry_notify" yaml:"expiry_notify"`
+	ApiKey struct {
+		Enable   bool        `json:"enable" yaml:"enable"`
+		Required bool        `json:"required" yaml:"required"`
+		Ip       ApiLimit    `json:"ip" yaml:"ip"`
+		Proxies  []string    `json:"proxies" yaml:"proxies"` // ips or cidrs whose X-Real-IP is trusted
+		Keys     []ApiKeyCfg `json:"-" yaml:"keys"`
+	} `json:"api_key" yaml:"api_key"`
+	Sign struct {
+		Alg        string `json:"alg" yaml:"alg"`
+		PrivateKey string `json:"-" yaml:"private_key"`
+	} `json:"sign" yaml:"sign"`
+	History struct {
+		Enable    bool   `json:"enable" yaml:"enable"`
+		Retention uint64 `json:"retention" yaml:"retention"`
+	} `json:"history" yaml:"history"`
 	Das struct {
 		AccountMinLength     int `json:"account_min_length" yaml:"account_min_length"`
 		AccountMaxLength     int `json:"account_max_length" yaml:"account_max_length"`
@@ -83,3 +148,15 @@ type DbMysql struct {
 	MaxOpenConn int    `json:"max_open_conn" yaml:"max_open_conn"`
 	MaxIdleConn int    `json:"max_idle_conn" yaml:"max_idle_conn"`
 }
+
+type ApiLimit struct {
+	Rate       float64 `json:"rate" yaml:"rate"` // calls per second, 0 is unlimited
+	Burst      int     `json:"burst" yaml:"burst"`
+	DailyQuota int64   `json:"daily_quota" yaml:"daily_quota"` // calls per UTC day, 0 is unlimited
+}
+
+type ApiKeyCfg struct {
+	Name     string `json:"name" yaml:"name"`
+	Key      string `json:"-" yaml:"key"`
+	ApiLimit `yaml:",inline"`
+}
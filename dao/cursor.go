@@ -0,0 +1,19 @@
+package dao
+
+import "gorm.io/gorm"
+
+// Cursor marks the last row of a page for keyset pagination, list queries are ordered by account then id
+type Cursor struct {
+	Account string `json:"a"`
+	Id      uint64 `json:"i"`
+}
+
+func cursorScope(cursor *Cursor, accountColumn, idColumn string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if cursor == nil {
+			return db
+		}
+		return db.Where("("+accountColumn+">? OR ("+accountColumn+"=? AND "+idColumn+">?))",
+			cursor.Account, cursor.Account, cursor.Id)
+	}
+}
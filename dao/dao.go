@@ -9,7 +9,8 @@ import (
 )
 
 type DbDao struct {
-	db *gorm.DB
+	db                 *gorm.DB
+	parsingBlockNumber uint64 // block the parser is on, deletes are logged with it
 }
 
 func NewGormDB(dbMysql config.DbMysql) (*DbDao, error) {
@@ -27,11 +28,22 @@ func NewGormDB(dbMysql config.DbMysql) (*DbDao, error) {
 		&tables.TableRecordsInfo{},
 		&tables.TableReverseInfo{},
 		&tables.TableDidCellInfo{},
+		&tables.TableBackfillInfo{},
+		&tables.TableDeletedInfo{},
+		&tables.TableSubAccountStatsDaily{},
+		&tables.TableSubAccountStatsOwner{},
+		&tables.TableExpiryNotice{},
+		&tables.TableApiKey{},
+		&tables.TableStateHistory{},
 	); err != nil {
 		return nil, err
 	}
 
-	return &DbDao{db: db}, nil
+	d := DbDao{db: db}
+	if err = db.Callback().Delete().Before("gorm:delete").Register("das:deleted_info", d.logDeleted); err != nil {
+		return nil, fmt.Errorf("Register err: %s", err.Error())
+	}
+	return &d, nil
 }
 
 func (d *DbDao) Transaction(fn func(tx *gorm.DB) error) error {
@@ -3,9 +3,11 @@ package dao
 import (
 	"das-account-indexer/tables"
 	"errors"
+	"fmt"
 	"github.com/dotbitHQ/das-lib/common"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
+	"strings"
 	"time"
 )
 
@@ -17,6 +19,7 @@ func (d *DbDao) UpdateAccountInfo(account *tables.TableAccountInfo, records []ta
 				"owner_algorithm_id", "owner_chain_type", "owner",
 				"manager_algorithm_id", "manager_chain_type", "manager",
 				"status", "registered_at", "expired_at",
+				"account_length", "char_set",
 			}),
 		}).Create(&account).Error; err != nil {
 			return err
@@ -111,6 +114,7 @@ func (d *DbDao) UpdateAccountInfoList(accounts []tables.TableAccountInfo, record
 				"owner_algorithm_id", "owner_chain_type", "owner",
 				"manager_algorithm_id", "manager_chain_type", "manager",
 				"status", "registered_at", "expired_at",
+				"account_length", "char_set",
 			}),
 		}).Create(&accounts).Error; err != nil {
 			return err
@@ -146,17 +150,19 @@ func (d *DbDao) FindAccountListByAddress(chainType common.ChainType, address str
 	return
 }
 
-func (d *DbDao) FindAccountNameListByAddress(chainType common.ChainType, address, role string, limit, offset int) (list []tables.TableAccountInfo, err error) {
+func (d *DbDao) FindAccountNameListByAddress(chainType common.ChainType, address, role string, cursor *Cursor, limit, offset int) (list []tables.TableAccountInfo, err error) {
 	if role == "" || role == "owner" {
-		err = d.db.Select("account,registered_at,expired_at").
+		err = d.db.Select("id,account,registered_at,expired_at").
 			Where(" owner_chain_type=? AND owner=? AND `status`!=? and expired_at >= ?",
 				chainType, address, tables.AccountStatusOnLock, time.Now().Unix()-90*86400).
-			Order("account").Limit(limit).Offset(offset).Find(&list).Error
+			Scopes(cursorScope(cursor, "account", "id")).
+			Order("account,id").Limit(limit).Offset(offset).Find(&list).Error
 	} else if role == "manager" {
-		err = d.db.Select("account,registered_at,expired_at").
+		err = d.db.Select("id,account,registered_at,expired_at").
 			Where(" manager_chain_type=? AND manager=? AND `status`!=? and expired_at >= ?",
 				chainType, address, tables.AccountStatusOnLock, time.Now().Unix()-90*86400).
-			Order("account").Limit(limit).Offset(offset).Find(&list).Error
+			Scopes(cursorScope(cursor, "account", "id")).
+			Order("account,id").Limit(limit).Offset(offset).Find(&list).Error
 	}
 	return
 }
@@ -183,51 +189,55 @@ func (d *DbDao) EnableSubAccount(accountInfo tables.TableAccountInfo) error {
 
 func (d *DbDao) CreateSubAccount(subAccountIds []string, accountInfos []tables.TableAccountInfo, parentAccountInfo tables.TableAccountInfo, records []tables.TableRecordsInfo) error {
 	return d.db.Transaction(func(tx *gorm.DB) error {
-		if len(subAccountIds) > 0 {
-			if err := tx.Where(" account_id IN(?) ", subAccountIds).
-				Delete(&tables.TableRecordsInfo{}).Error; err != nil {
-				return err
+		return withSubAccountStats(tx, subAccountIds, func(tx *gorm.DB) error {
+			if len(subAccountIds) > 0 {
+				if err := tx.Where(" account_id IN(?) ", subAccountIds).
+					Delete(&tables.TableRecordsInfo{}).Error; err != nil {
+					return err
+				}
 			}
-		}
-		if len(records) > 0 {
-			if err := tx.Create(&records).Error; err != nil {
-				return err
+			if len(records) > 0 {
+				if err := tx.Create(&records).Error; err != nil {
+					return err
+				}
 			}
-		}
-		if len(accountInfos) > 0 {
-			if err := tx.Clauses(clause.Insert{
-				Modifier: "IGNORE",
-			}).Create(&accountInfos).Error; err != nil {
-				return err
+			if len(accountInfos) > 0 {
+				if err := tx.Clauses(clause.Insert{
+					Modifier: "IGNORE",
+				}).Create(&accountInfos).Error; err != nil {
+					return err
+				}
 			}
-		}
-		if parentAccountInfo.AccountId != "" {
-			if err := tx.Select("block_number", "block_timestamp", "outpoint").
-				Where("account_id = ?", parentAccountInfo.AccountId).
-				Updates(parentAccountInfo).Error; err != nil {
-				return err
+			if parentAccountInfo.AccountId != "" {
+				if err := tx.Select("block_number", "block_timestamp", "outpoint").
+					Where("account_id = ?", parentAccountInfo.AccountId).
+					Updates(parentAccountInfo).Error; err != nil {
+					return err
+				}
 			}
-		}
 
-		return nil
+			return nil
+		})
 	})
 }
 
 func (d *DbDao) EditOwnerSubAccount(accountInfo tables.TableAccountInfo) error {
 	return d.db.Transaction(func(tx *gorm.DB) error {
-		if err := tx.Select("block_number", "block_timestamp", "outpoint",
-			"manager_chain_type", "manager", "manager_algorithm_id",
-			"owner_chain_type", "owner", "owner_algorithm_id", "nonce").
-			Where("account_id = ?", accountInfo.AccountId).
-			Updates(accountInfo).Error; err != nil {
-			return err
-		}
+		return withSubAccountStats(tx, []string{accountInfo.AccountId}, func(tx *gorm.DB) error {
+			if err := tx.Select("block_number", "block_timestamp", "outpoint",
+				"manager_chain_type", "manager", "manager_algorithm_id",
+				"owner_chain_type", "owner", "owner_algorithm_id", "nonce").
+				Where("account_id = ?", accountInfo.AccountId).
+				Updates(accountInfo).Error; err != nil {
+				return err
+			}
 
-		if err := tx.Where("account_id = ?", accountInfo.AccountId).Delete(&tables.TableRecordsInfo{}).Error; err != nil {
-			return err
-		}
+			if err := tx.Where("account_id = ?", accountInfo.AccountId).Delete(&tables.TableRecordsInfo{}).Error; err != nil {
+				return err
+			}
 
-		return nil
+			return nil
+		})
 	})
 }
 
@@ -269,17 +279,37 @@ func (d *DbDao) RenewSubAccount(accountInfos []tables.TableAccountInfo) error {
 	}).Create(&accountInfos).Error
 }
 
+func (d *DbDao) RenewSubAccountList(accountInfos []tables.TableAccountInfo) error {
+	var accountIds []string
+	for _, v := range accountInfos {
+		accountIds = append(accountIds, v.AccountId)
+	}
+	return d.db.Transaction(func(tx *gorm.DB) error {
+		return withSubAccountStats(tx, accountIds, func(tx *gorm.DB) error {
+			for i := range accountInfos {
+				accountInfo := accountInfos[i]
+				if err := tx.Where("id=?", accountInfo.Id).Updates(&accountInfo).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	})
+}
+
 func (d *DbDao) RecycleSubAccount(accountId []string) error {
 	return d.db.Transaction(func(tx *gorm.DB) error {
-		if err := tx.Where("account_id IN(?)", accountId).Delete(&tables.TableAccountInfo{}).Error; err != nil {
-			return err
-		}
+		return withSubAccountStats(tx, accountId, func(tx *gorm.DB) error {
+			if err := tx.Where("account_id IN(?)", accountId).Delete(&tables.TableAccountInfo{}).Error; err != nil {
+				return err
+			}
 
-		if err := tx.Where("account_id IN(?)", accountId).Delete(&tables.TableRecordsInfo{}).Error; err != nil {
-			return err
-		}
+			if err := tx.Where("account_id IN(?)", accountId).Delete(&tables.TableRecordsInfo{}).Error; err != nil {
+				return err
+			}
 
-		return nil
+			return nil
+		})
 	})
 }
 
@@ -317,6 +347,14 @@ func (d *DbDao) RecycleExpiredAccount(accountInfo tables.TableAccountInfo, accou
 			if err := tx.Where("parent_account_id=?", accountId).Delete(&tables.TableRecordsInfo{}).Error; err != nil {
 				return err
 			}
+
+			if err := tx.Where("parent_account_id=?", accountId).Delete(&tables.TableSubAccountStatsDaily{}).Error; err != nil {
+				return err
+			}
+
+			if err := tx.Where("parent_account_id=?", accountId).Delete(&tables.TableSubAccountStatsOwner{}).Error; err != nil {
+				return err
+			}
 		}
 
 		return nil
@@ -331,9 +369,19 @@ func (d *DbDao) UpdateAccountOutpoint(accountId, outpoint string) error {
 		}).Error
 }
 
-func (d *DbDao) GetSubAccountListByParentAccountId(parentAccountId string, limit, offset int) (list []tables.TableAccountInfo, err error) {
+func (d *DbDao) GetSubAccountListByParentAccountId(parentAccountId string, cursor *Cursor, limit, offset int) (list []tables.TableAccountInfo, err error) {
 	err = d.db.Where("parent_account_id=?", parentAccountId).
-		Order("account").Limit(limit).Offset(offset).
+		Scopes(cursorScope(cursor, "account", "id")).
+		Order("account,id").Limit(limit).Offset(offset).
+		Find(&list).Error
+	return
+}
+
+// FindSubAccountsByParentIds gives the sub-accounts of all the parents, by parent then account
+func (d *DbDao) FindSubAccountsByParentIds(parentAccountIds []string, limit int) (list []tables.TableAccountInfo, err error) {
+	err = d.db.Select("id,account_id,parent_account_id,account,owner_chain_type,owner,registered_at,expired_at").
+		Where("parent_account_id IN(?)", parentAccountIds).
+		Order("parent_account_id,account").Limit(limit).
 		Find(&list).Error
 	return
 }
@@ -367,15 +415,17 @@ func (d *DbDao) DelSubAccounts(subAccIds []string) error {
 		return nil
 	}
 	return d.db.Transaction(func(tx *gorm.DB) error {
-		if err := tx.Where("account_id IN(?)", subAccIds).
-			Delete(&tables.TableAccountInfo{}).Error; err != nil {
-			return err
-		}
-		if err := tx.Where("account_id IN(?)", subAccIds).
-			Delete(&tables.TableRecordsInfo{}).Error; err != nil {
-			return err
-		}
-		return nil
+		return withSubAccountStats(tx, subAccIds, func(tx *gorm.DB) error {
+			if err := tx.Where("account_id IN(?)", subAccIds).
+				Delete(&tables.TableAccountInfo{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("account_id IN(?)", subAccIds).
+				Delete(&tables.TableRecordsInfo{}).Error; err != nil {
+				return err
+			}
+			return nil
+		})
 	})
 }
 
@@ -389,15 +439,20 @@ func (d *DbDao) UpdateAccounts(accounts []map[string]interface{}) error {
 			action := account["action"]
 			delete(account, "action")
 
-			if err := tx.Model(&tables.TableAccountInfo{}).Where("account_id=?", accId).
-				Updates(account).Error; err != nil {
-				return err
-			}
-
-			if action == common.SubActionFullfillApproval {
-				if err := tx.Where("account_id = ?", accId).Delete(&tables.TableRecordsInfo{}).Error; err != nil {
+			if err := withSubAccountStats(tx, []string{fmt.Sprint(accId)}, func(tx *gorm.DB) error {
+				if err := tx.Model(&tables.TableAccountInfo{}).Where("account_id=?", accId).
+					Updates(account).Error; err != nil {
 					return err
 				}
+
+				if action == common.SubActionFullfillApproval {
+					if err := tx.Where("account_id = ?", accId).Delete(&tables.TableRecordsInfo{}).Error; err != nil {
+						return err
+					}
+				}
+				return nil
+			}); err != nil {
+				return err
 			}
 		}
 		return nil
@@ -485,3 +540,187 @@ func (d *DbDao) GetTotalDobs() (count int64, err error) {
 		Where("expired_at>?", timestamp).Count(&count).Error
 	return
 }
+
+type AccountNameSearchMode = string
+
+const (
+	AccountNameSearchModePrefix   AccountNameSearchMode = "prefix"
+	AccountNameSearchModeContains AccountNameSearchMode = "contains"
+	AccountNameSearchModeSuffix   AccountNameSearchMode = "suffix"
+)
+
+type AccountNameFilter struct {
+	Keyword          string
+	Mode             AccountNameSearchMode
+	MinLength        uint32
+	MaxLength        uint32
+	CharSet          uint64 // the chars of the first label all come from these sets
+	RegisteredAfter  uint64
+	RegisteredBefore uint64
+	ExpiredAfter     uint64
+	ExpiredBefore    uint64
+	SubAccount       bool
+}
+
+func (d *DbDao) SearchAccountName(filter AccountNameFilter, cursor *Cursor, limit, offset int) (list []tables.TableAccountInfo, err error) {
+	err = d.accountNameQuery(filter).Scopes(cursorScope(cursor, "account", "id")).
+		Order("account,id").Limit(limit).Offset(offset).
+		Find(&list).Error
+	return
+}
+
+func (d *DbDao) SearchAccountNameTotal(filter AccountNameFilter) (count int64, err error) {
+	err = d.accountNameQuery(filter).Count(&count).Error
+	return
+}
+
+func (d *DbDao) accountNameQuery(filter AccountNameFilter) *gorm.DB {
+	db := d.db.Model(tables.TableAccountInfo{}).Where("`status`!=?", tables.AccountStatusOnLock)
+	if !filter.SubAccount {
+		db = db.Where("parent_account_id=''")
+	}
+	if filter.Keyword != "" {
+		keyword := escapeLike(filter.Keyword)
+		switch filter.Mode {
+		case AccountNameSearchModeContains:
+			db = db.Where("SUBSTRING_INDEX(account,'.',1) LIKE ?", "%"+keyword+"%")
+		case AccountNameSearchModeSuffix:
+			db = db.Where("SUBSTRING_INDEX(account,'.',1) LIKE ?", "%"+keyword)
+		default:
+			db = db.Where("account LIKE ?", keyword+"%")
+		}
+	}
+	if filter.MinLength > 0 {
+		db = db.Where("account_length>=?", filter.MinLength)
+	}
+	if filter.MaxLength > 0 {
+		db = db.Where("account_length<=?", filter.MaxLength)
+	}
+	if filter.CharSet > 0 {
+		db = db.Where("char_set>0 AND char_set|?=?", filter.CharSet, filter.CharSet)
+	}
+	if filter.RegisteredAfter > 0 {
+		db = db.Where("registered_at>=?", filter.RegisteredAfter)
+	}
+	if filter.RegisteredBefore > 0 {
+		db = db.Where("registered_at<?", filter.RegisteredBefore)
+	}
+	if filter.ExpiredAfter > 0 {
+		db = db.Where("expired_at>=?", filter.ExpiredAfter)
+	}
+	if filter.ExpiredBefore > 0 {
+		db = db.Where("expired_at<?", filter.ExpiredBefore)
+	}
+	return db
+}
+
+func escapeLike(str string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(str)
+}
+
+func (d *DbDao) FindAccountsWithoutNameMeta(lastId uint64, limit int) (list []tables.TableAccountInfo, err error) {
+	err = d.db.Select("id,account").
+		Where("id>? AND account_length=0 AND account!=''", lastId).
+		Order("id").Limit(limit).Find(&list).Error
+	return
+}
+
+func (d *DbDao) UpdateAccountNameMeta(list []tables.TableAccountInfo) error {
+	return d.db.Transaction(func(tx *gorm.DB) error {
+		for _, v := range list {
+			if err := tx.Model(tables.TableAccountInfo{}).Where("id=?", v.Id).
+				Updates(map[string]interface{}{
+					"account_length": v.AccountLength,
+					"char_set":       v.CharSet,
+				}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+type ExportFilter struct {
+	ParentAccountId string
+	ExpiredAfter    uint64
+	ExpiredBefore   uint64
+	SinceBlock      uint64 // accounts changed at or after this block
+	UntilBlock      uint64 // accounts changed at or before this block
+}
+
+// FindExportAccounts returns the page of accounts after lastId, exports walk the table by id so memory does not grow with it
+func (d *DbDao) FindExportAccounts(filter ExportFilter, lastId uint64, limit int) (list []tables.TableAccountInfo, err error) {
+	db := d.db.Where("id>?", lastId)
+	if filter.ParentAccountId != "" {
+		db = db.Where("parent_account_id=?", filter.ParentAccountId)
+	}
+	if filter.ExpiredAfter > 0 {
+		db = db.Where("expired_at>=?", filter.ExpiredAfter)
+	}
+	if filter.ExpiredBefore > 0 {
+		db = db.Where("expired_at<?", filter.ExpiredBefore)
+	}
+	if filter.SinceBlock > 0 {
+		db = db.Where("block_number>=?", filter.SinceBlock)
+	}
+	if filter.UntilBlock > 0 {
+		db = db.Where("block_number<=?", filter.UntilBlock)
+	}
+	err = db.Order("id").Limit(limit).Find(&list).Error
+	return
+}
+
+// expiringAccountScope is the accounts of an address still within the grace period and expiring before expiredBefore
+// expiringSkipStatus leaves out locked accounts and upgraded ones, the DID cell of an upgraded account
+// is what expires and its owner is not the one in t_account_info
+var expiringSkipStatus = []tables.AccountStatus{tables.AccountStatusOnLock, tables.AccountStatusOnUpgrade}
+
+func expiringAccountScope(chainType common.ChainType, address, role string, expiredBefore uint64) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if role == "manager" {
+			db = db.Where(" manager_chain_type=? AND manager=? ", chainType, address)
+		} else {
+			db = db.Where(" owner_chain_type=? AND owner=? ", chainType, address)
+		}
+		return db.Where(" `status` NOT IN(?) AND expired_at>=? AND expired_at<=? ",
+			expiringSkipStatus, time.Now().Unix()-90*86400, expiredBefore)
+	}
+}
+
+func (d *DbDao) FindExpiringAccountList(chainType common.ChainType, address, role string, expiredBefore uint64, cursor *Cursor, limit, offset int) (list []tables.TableAccountInfo, err error) {
+	err = d.db.Select("id,account_id,account,expired_at").
+		Scopes(expiringAccountScope(chainType, address, role, expiredBefore), cursorScope(cursor, "account", "id")).
+		Order("account,id").Limit(limit).Offset(offset).Find(&list).Error
+	return
+}
+
+func (d *DbDao) FindTotalExpiringAccountList(chainType common.ChainType, address, role string, expiredBefore uint64) (count int64, err error) {
+	err = d.db.Model(tables.TableAccountInfo{}).
+		Scopes(expiringAccountScope(chainType, address, role, expiredBefore)).
+		Count(&count).Error
+	return
+}
+
+// FindAccountsExpiringBetween gives the accounts with expired_at in (from, to], by id, upgraded ones are found as DID cells
+func (d *DbDao) FindAccountsExpiringBetween(from, to, lastId uint64, limit int) (list []tables.TableAccountInfo, err error) {
+	err = d.db.Select("id,account_id,account,owner_chain_type,owner,manager_chain_type,manager,expired_at").
+		Where(" `status` NOT IN(?) AND expired_at>? AND expired_at<=? AND id>? ", expiringSkipStatus, from, to, lastId).
+		Order("id").Limit(limit).Find(&list).Error
+	return
+}
+
+// FindAccountNameListByAddresses is FindAccountNameListByAddress for many addresses of a chain type, without pagination
+func (d *DbDao) FindAccountNameListByAddresses(chainType common.ChainType, addresses []string, role string, limit int) (list []tables.TableAccountInfo, err error) {
+	if len(addresses) == 0 {
+		return
+	}
+	db := d.db.Select("id,account_id,account,owner_chain_type,owner,manager_chain_type,manager,registered_at,expired_at")
+	if role == "manager" {
+		db = db.Where(" manager_chain_type=? AND manager IN(?) ", chainType, addresses)
+	} else {
+		db = db.Where(" owner_chain_type=? AND owner IN(?) ", chainType, addresses)
+	}
+	err = db.Where(" `status`!=? AND expired_at>=? ", tables.AccountStatusOnLock, time.Now().Unix()-90*86400).
+		Order("account,id").Limit(limit).Find(&list).Error
+	return
+}
@@ -0,0 +1,24 @@
+package dao
+
+import (
+	"das-account-indexer/tables"
+)
+
+func (d *DbDao) FindEnabledApiKeys() (list []tables.TableApiKey, err error) {
+	err = d.db.Where(" status=? ", tables.ApiKeyStatusEnabled).Find(&list).Error
+	return
+}
+
+func (d *DbDao) FindApiKeys() (list []tables.TableApiKey, err error) {
+	err = d.db.Order("id").Find(&list).Error
+	return
+}
+
+func (d *DbDao) CreateApiKey(info *tables.TableApiKey) error {
+	return d.db.Create(info).Error
+}
+
+func (d *DbDao) UpdateApiKeyStatus(name string, status tables.ApiKeyStatus) (int64, error) {
+	res := d.db.Model(tables.TableApiKey{}).Where(" name=? ", name).Update("status", status)
+	return res.RowsAffected, res.Error
+}
@@ -0,0 +1,18 @@
+package dao
+
+import (
+	"das-account-indexer/tables"
+	"gorm.io/gorm/clause"
+)
+
+func (d *DbDao) GetBackfillInfo(name string) (info tables.TableBackfillInfo, err error) {
+	err = d.db.Where(" name=? ", name).Limit(1).Find(&info).Error
+	return
+}
+
+func (d *DbDao) SaveBackfillInfo(name string, lastId uint64, done bool) error {
+	info := tables.TableBackfillInfo{Name: name, LastId: lastId, Done: done}
+	return d.db.Clauses(clause.OnConflict{
+		DoUpdates: clause.AssignmentColumns([]string{"last_id", "done"}),
+	}).Create(&info).Error
+}
@@ -0,0 +1,82 @@
+package dao
+
+import (
+	"das-account-indexer/tables"
+	"fmt"
+	"gorm.io/gorm"
+	"sync/atomic"
+)
+
+// the key each logged table is deleted by, records are logged once per account as they are replaced as a whole
+var deletedEntities = map[string]struct {
+	entity string
+	column string
+}{
+	tables.TableNameAccountInfo: {tables.DeletedEntityAccount, "account_id"},
+	tables.TableNameRecordsInfo: {tables.DeletedEntityRecords, "account_id"},
+	tables.TableNameDidCellInfo: {tables.DeletedEntityDidCell, "outpoint"},
+	tables.TableNameReverseInfo: {tables.DeletedEntityReverse, "outpoint"},
+}
+
+func (d *DbDao) SetParsingBlockNumber(blockNumber uint64) {
+	atomic.StoreUint64(&d.parsingBlockNumber, blockNumber)
+}
+
+// logDeleted runs before each delete and writes the keys of the rows about to go to t_deleted_info,
+// inside the same transaction as the delete
+func (d *DbDao) logDeleted(db *gorm.DB) {
+	if db.Error != nil || db.Statement.Schema == nil {
+		return
+	}
+	e, ok := deletedEntities[db.Statement.Schema.Table]
+	if !ok {
+		return
+	}
+	where, ok := db.Statement.Clauses["WHERE"]
+	if !ok {
+		return
+	}
+
+	var keys []string
+	tx := db.Session(&gorm.Session{NewDB: true})
+	if err := tx.Table(db.Statement.Schema.Table).Clauses(where.Expression).Distinct().Pluck(e.column, &keys).Error; err != nil {
+		_ = db.AddError(fmt.Errorf("logDeleted Pluck err: %s", err.Error()))
+		return
+	} else if len(keys) == 0 {
+		return
+	}
+	if err := d.createDeletedInfo(tx, e.entity, keys); err != nil {
+		_ = db.AddError(fmt.Errorf("logDeleted Create err: %s", err.Error()))
+	}
+}
+
+// createDeletedInfo logs keys as deleted at the block being parsed, also for a row that moves to a new key in place
+func (d *DbDao) createDeletedInfo(tx *gorm.DB, entity string, keys []string) error {
+	blockNumber := atomic.LoadUint64(&d.parsingBlockNumber)
+	list := make([]tables.TableDeletedInfo, 0, len(keys))
+	for _, v := range keys {
+		list = append(list, tables.TableDeletedInfo{BlockNumber: blockNumber, Entity: entity, EntityKey: v})
+	}
+	return tx.Create(&list).Error
+}
+
+func (d *DbDao) FindDeletedInfoByBlockRange(fromBlock, toBlock, lastId uint64, limit int) (list []tables.TableDeletedInfo, err error) {
+	err = d.db.Where("id>? AND block_number BETWEEN ? AND ?", lastId, fromBlock, toBlock).
+		Order("id").Limit(limit).Find(&list).Error
+	return
+}
+
+// PruneDeletedInfo deletes the deletes logged before beforeBlock, the changefeed no longer serves them
+func (d *DbDao) PruneDeletedInfo(beforeBlock uint64) (int64, error) {
+	var total int64
+	for {
+		res := d.db.Where("block_number<?", beforeBlock).Limit(10000).Delete(&tables.TableDeletedInfo{})
+		if res.Error != nil {
+			return total, res.Error
+		}
+		total += res.RowsAffected
+		if res.RowsAffected < 10000 {
+			return total, nil
+		}
+	}
+}
@@ -23,7 +23,7 @@ func (d *DbDao) CreateDidCellRecordsInfos(outpoint string, didCellInfo tables.Ta
 			Updates(didCellInfo).Error; err != nil {
 			return err
 		}
-		return nil
+		return d.moveDidCellOutpoint(tx, outpoint, didCellInfo.Outpoint)
 	})
 }
 
@@ -33,6 +33,8 @@ func (d *DbDao) EditDidCellOwner(outpoint string, didCellInfo tables.TableDidCel
 			Where("outpoint = ?", outpoint).
 			Updates(didCellInfo).Error; err != nil {
 			return err
+		} else if err = d.moveDidCellOutpoint(tx, outpoint, didCellInfo.Outpoint); err != nil {
+			return err
 		}
 		if err := tx.Where("account_id = ?", didCellInfo.AccountId).
 			Delete(&tables.TableRecordsInfo{}).Error; err != nil {
@@ -47,6 +49,15 @@ func (d *DbDao) EditDidCellOwner(outpoint string, didCellInfo tables.TableDidCel
 	})
 }
 
+// moveDidCellOutpoint logs the old outpoint of a DID cell rewritten in place as deleted,
+// no delete runs for it, so the changefeed and the state history would keep it otherwise
+func (d *DbDao) moveDidCellOutpoint(tx *gorm.DB, oldOutpoint, outpoint string) error {
+	if oldOutpoint == outpoint {
+		return nil
+	}
+	return d.createDeletedInfo(tx, tables.DeletedEntityDidCell, []string{oldOutpoint})
+}
+
 func (d *DbDao) DidCellRecycle(outpoint, accountId string) error {
 	return d.db.Transaction(func(tx *gorm.DB) error {
 		if err := tx.Where("account_id=?", accountId).Delete(&tables.TableRecordsInfo{}).Error; err != nil {
@@ -105,8 +116,8 @@ func (d *DbDao) DidCellRecycleList(oldOutpointList []string, accountIds []string
 	})
 }
 
-func (d *DbDao) QueryDidCell(args string, didType tables.DidCellStatus, limit, offset int) (didList []tables.TableDidCellInfo, err error) {
-	sql := d.db.Where(" args= ?", args)
+func (d *DbDao) QueryDidCell(args string, didType tables.DidCellStatus, cursor *Cursor, limit, offset int) (didList []tables.TableDidCellInfo, err error) {
+	sql := d.db.Where(" args= ?", args).Scopes(cursorScope(cursor, "account", "id")).Order("account,id")
 	timestamp := tables.GetDidCellRecycleExpiredAt()
 	if didType == tables.DidCellStatusNormal {
 		sql.Where("expired_at > ?", timestamp)
@@ -143,3 +154,44 @@ func (d *DbDao) GetAccountInfoByOutpoint(outpoint string) (acc tables.TableDidCe
 	err = d.db.Where(" outpoint= ? ", outpoint).Find(&acc).Error
 	return
 }
+
+func (d *DbDao) FindDidCellListByAccountIds(accountIds []string) (list []tables.TableDidCellInfo, err error) {
+	err = d.db.Where("account_id IN(?)", accountIds).Order("expired_at DESC").Find(&list).Error
+	return
+}
+
+func (d *DbDao) FindDidCellsByBlockRange(fromBlock, toBlock, lastId uint64, limit int) (list []tables.TableDidCellInfo, err error) {
+	err = d.db.Where("id>? AND block_number BETWEEN ? AND ?", lastId, fromBlock, toBlock).
+		Order("id").Limit(limit).Find(&list).Error
+	return
+}
+
+func (d *DbDao) QueryExpiringDidCell(args string, expiredBefore uint64, cursor *Cursor, limit, offset int) (didList []tables.TableDidCellInfo, err error) {
+	err = d.db.Where(" args=? AND expired_at>? AND expired_at<=? ", args, tables.GetDidCellRecycleExpiredAt(), expiredBefore).
+		Scopes(cursorScope(cursor, "account", "id")).
+		Order("account,id").Limit(limit).Offset(offset).Find(&didList).Error
+	return
+}
+
+func (d *DbDao) QueryExpiringDidCellTotal(args string, expiredBefore uint64) (count int64, err error) {
+	err = d.db.Model(tables.TableDidCellInfo{}).
+		Where(" args=? AND expired_at>? AND expired_at<=? ", args, tables.GetDidCellRecycleExpiredAt(), expiredBefore).
+		Count(&count).Error
+	return
+}
+
+// FindDidCellsExpiringBetween gives the DID cells with expired_at in (from, to], by id
+func (d *DbDao) FindDidCellsExpiringBetween(from, to, lastId uint64, limit int) (list []tables.TableDidCellInfo, err error) {
+	err = d.db.Where(" expired_at>? AND expired_at<=? AND id>? ", from, to, lastId).
+		Order("id").Limit(limit).Find(&list).Error
+	return
+}
+
+func (d *DbDao) QueryDidCellByArgsList(argsList []string, limit int) (didList []tables.TableDidCellInfo, err error) {
+	if len(argsList) == 0 {
+		return
+	}
+	err = d.db.Where(" args IN(?) AND expired_at>? ", argsList, tables.GetDidCellRecycleExpiredAt()).
+		Order("account,id").Limit(limit).Find(&didList).Error
+	return
+}
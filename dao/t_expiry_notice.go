@@ -0,0 +1,46 @@
+package dao
+
+import (
+	"das-account-indexer/tables"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func (d *DbDao) FindExpiryNotices(accountIds []string, stage string) (list []tables.TableExpiryNotice, err error) {
+	if len(accountIds) == 0 {
+		return
+	}
+	err = d.db.Where(" account_id IN(?) AND stage=? ", accountIds, stage).Find(&list).Error
+	return
+}
+
+// ClaimExpiryNotice inserts the notice as pending, false when it is there already,
+// claiming before sending is what keeps a notice from going out twice
+func (d *DbDao) ClaimExpiryNotice(notice *tables.TableExpiryNotice) (bool, error) {
+	notice.Status = tables.ExpiryNoticeStatusPending
+	notice.Attempts = 1
+	res := d.db.Clauses(clause.Insert{Modifier: "IGNORE"}).Create(notice)
+	return res.RowsAffected == 1, res.Error
+}
+
+// RetryExpiryNotice claims a failed notice again, while it has fewer than maxAttempts
+func (d *DbDao) RetryExpiryNotice(id uint64, maxAttempts int) (bool, error) {
+	res := d.db.Model(tables.TableExpiryNotice{}).
+		Where(" id=? AND status=? AND attempts<? ", id, tables.ExpiryNoticeStatusFailed, maxAttempts).
+		Updates(map[string]interface{}{
+			"status":   tables.ExpiryNoticeStatusPending,
+			"attempts": gorm.Expr("attempts+1"),
+		})
+	return res.RowsAffected == 1, res.Error
+}
+
+func (d *DbDao) UpdateExpiryNoticeStatus(id uint64, status tables.ExpiryNoticeStatus, errMsg string) error {
+	if len(errMsg) > 1024 {
+		errMsg = errMsg[:1024]
+	}
+	return d.db.Model(tables.TableExpiryNotice{}).Where(" id=? ", id).
+		Updates(map[string]interface{}{
+			"status":  status,
+			"err_msg": errMsg,
+		}).Error
+}
@@ -1,6 +1,9 @@
 package dao
 
-import "das-account-indexer/tables"
+import (
+	"das-account-indexer/tables"
+	"gorm.io/gorm"
+)
 
 func (d *DbDao) FindAccountRecordsByAccountId(accountId string) (list []tables.TableRecordsInfo, err error) {
 	err = d.db.Where(" account_id=? ", accountId).Find(&list).Error
@@ -16,3 +19,50 @@ func (d *DbDao) FindRecordByAccountIdAddressValue(accountId, value string) (r ta
 	err = d.db.Where(" `account_id`=? AND `type`='address' AND `value`=? ", accountId, value).Find(&r).Limit(1).Error
 	return
 }
+
+// RecordValueAccount is a row of FindAccountIdsByRecordValue, with the account and id the page is ordered by
+type RecordValueAccount struct {
+	AccountId string
+	Account   string
+	Id        uint64
+}
+
+func (d *DbDao) FindAccountIdsByRecordValue(recordType string, keys, values []string, cursor *Cursor, limit, offset int) (list []RecordValueAccount, err error) {
+	err = d.recordValueQuery(recordType, keys, values).Scopes(cursorScope(cursor, "a.account", "a.id")).
+		Select("r.account_id, a.account, a.id").Group("r.account_id, a.account, a.id").
+		Order("a.account, a.id").Limit(limit).Offset(offset).
+		Find(&list).Error
+	return
+}
+
+func (d *DbDao) FindTotalAccountIdsByRecordValue(recordType string, keys, values []string) (count int64, err error) {
+	err = d.recordValueQuery(recordType, keys, values).
+		Distinct("r.account_id").Count(&count).Error
+	return
+}
+
+func (d *DbDao) FindRecordsByAccountIdsValue(accountIds []string, recordType string, keys, values []string) (list []tables.TableRecordsInfo, err error) {
+	if len(accountIds) == 0 {
+		return
+	}
+	err = d.db.Where(" account_id IN(?) AND `type`=? AND `key` IN(?) AND `value` IN(?) ",
+		accountIds, recordType, keys, values).Find(&list).Error
+	return
+}
+
+// accounts in cross-chain lock do not expose their records
+func (d *DbDao) recordValueQuery(recordType string, keys, values []string) *gorm.DB {
+	return d.db.Table(tables.TableNameRecordsInfo+" r").
+		Joins("JOIN "+tables.TableNameAccountInfo+" a ON a.account_id=r.account_id").
+		Where(" r.`type`=? AND r.`key` IN(?) AND r.`value` IN(?) AND a.`status`!=? ",
+			recordType, keys, values, tables.AccountStatusOnLock)
+}
+
+func (d *DbDao) FindRecordsByAccountIdsKey(accountIds []string, recordType, key string) (list []tables.TableRecordsInfo, err error) {
+	if len(accountIds) == 0 {
+		return
+	}
+	err = d.db.Where(" account_id IN(?) AND `type`=? AND `key`=? ", accountIds, recordType, key).
+		Order("id").Find(&list).Error
+	return
+}
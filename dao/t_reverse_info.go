@@ -59,3 +59,14 @@ func (d *DbDao) GetReverseListByAccount(account string) (list []tables.TableReve
 	err = d.db.Where("account=?", account).Find(&list).Order("id DESC").Limit(100).Error
 	return
 }
+
+func (d *DbDao) FindReverseListByAccounts(accounts []string) (list []tables.TableReverseInfo, err error) {
+	err = d.db.Where("account IN(?)", accounts).Order("id").Find(&list).Error
+	return
+}
+
+func (d *DbDao) FindReversesByBlockRange(fromBlock, toBlock, lastId uint64, limit int) (list []tables.TableReverseInfo, err error) {
+	err = d.db.Where("id>? AND block_number BETWEEN ? AND ?", lastId, fromBlock, toBlock).
+		Order("id").Limit(limit).Find(&list).Error
+	return
+}
@@ -0,0 +1,441 @@
+package dao
+
+import (
+	"das-account-indexer/tables"
+	"encoding/json"
+	"fmt"
+	"github.com/dotbitHQ/das-lib/common"
+	"gorm.io/gorm"
+)
+
+// state history for as_of_block queries. After each block the states of what it changed are written,
+// found from the block_number the parser stamps on each row and from t_deleted_info, as the changefeed finds them:
+// the open row of each key is closed at the block, and a row is opened for each key that still exists.
+
+const stateHistoryBatch = 1000
+
+type stateHistory struct {
+	blockNumber uint64
+	closeKeys   map[string][]string // by entity
+	rows        []tables.TableStateHistory
+}
+
+func (s *stateHistory) add(entity, entityKey string, lookupKeys []string, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("json.Marshal err: %s", err.Error())
+	}
+	s.closeKeys[entity] = append(s.closeKeys[entity], entityKey)
+	for _, v := range lookupKeys {
+		s.rows = append(s.rows, tables.TableStateHistory{
+			Entity:    entity,
+			EntityKey: entityKey,
+			LookupKey: v,
+			FromBlock: s.blockNumber,
+			ToBlock:   tables.StateHistoryOpen,
+			Data:      string(data),
+		})
+	}
+	return nil
+}
+
+func reverseLookupKey(chainType common.ChainType, address string) string {
+	return fmt.Sprintf("%d:%s", chainType, address)
+}
+
+func reverseLookupKeys(r *tables.TableReverseInfo) []string {
+	keys := []string{reverseLookupKey(r.ChainType, r.Address)}
+	for _, v := range []string{r.P2shP2wpkh, r.P2tr} {
+		if v != "" {
+			keys = append(keys, reverseLookupKey(r.ChainType, v))
+		}
+	}
+	return keys
+}
+
+func (s *stateHistory) addAccounts(list []tables.TableAccountInfo) error {
+	for i, v := range list {
+		if err := s.add(tables.DeletedEntityAccount, v.AccountId, []string{v.AccountId}, &list[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *stateHistory) addDidCells(list []tables.TableDidCellInfo) error {
+	for i, v := range list {
+		if err := s.add(tables.DeletedEntityDidCell, v.Outpoint, []string{v.AccountId}, &list[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *stateHistory) addReverses(list []tables.TableReverseInfo) error {
+	for i, v := range list {
+		if err := s.add(tables.DeletedEntityReverse, v.Outpoint, reverseLookupKeys(&v), &list[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addRecords adds the record list of each account, an account without records only has its open row closed
+func (s *stateHistory) addRecords(d *DbDao, accountIds []string) error {
+	for start := 0; start < len(accountIds); start += stateHistoryBatch {
+		end := start + stateHistoryBatch
+		if end > len(accountIds) {
+			end = len(accountIds)
+		}
+		list, err := d.FindRecordsByAccountIds(accountIds[start:end])
+		if err != nil {
+			return fmt.Errorf("FindRecordsByAccountIds err: %s", err.Error())
+		}
+		var mapRecords = make(map[string][]tables.TableRecordsInfo)
+		for _, v := range list {
+			mapRecords[v.AccountId] = append(mapRecords[v.AccountId], v)
+		}
+		for _, v := range accountIds[start:end] {
+			if records, ok := mapRecords[v]; ok {
+				if err = s.add(tables.DeletedEntityRecords, v, []string{v}, records); err != nil {
+					return err
+				}
+			} else {
+				s.closeKeys[tables.DeletedEntityRecords] = append(s.closeKeys[tables.DeletedEntityRecords], v)
+			}
+		}
+	}
+	return nil
+}
+
+func (s *stateHistory) write(tx *gorm.DB) error {
+	for entity, keys := range s.closeKeys {
+		for start := 0; start < len(keys); start += stateHistoryBatch {
+			end := start + stateHistoryBatch
+			if end > len(keys) {
+				end = len(keys)
+			}
+			if err := tx.Model(tables.TableStateHistory{}).
+				Where("entity=? AND entity_key IN(?) AND to_block=?", entity, keys[start:end], tables.StateHistoryOpen).
+				Update("to_block", s.blockNumber).Error; err != nil {
+				return err
+			}
+		}
+	}
+	if len(s.rows) > 0 {
+		if err := tx.CreateInBatches(&s.rows, stateHistoryBatch).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// closeMovedDidCells closes the open states of the accounts of list whose outpoint is gone from t_did_cell_info,
+// a DID cell moved to a new outpoint in place leaves no delete behind to close its old one.
+// States closed at the block by an earlier parse of it are taken too, they are opened again before the close.
+func (d *DbDao) closeMovedDidCells(s *stateHistory, list []tables.TableDidCellInfo) error {
+	if len(list) == 0 {
+		return nil
+	}
+	var accountIds []string
+	for _, v := range list {
+		accountIds = append(accountIds, v.AccountId)
+	}
+	current, err := d.FindDidCellListByAccountIds(accountIds)
+	if err != nil {
+		return fmt.Errorf("FindDidCellListByAccountIds err: %s", err.Error())
+	}
+	var outpoints = make(map[string]struct{})
+	for _, v := range current {
+		outpoints[v.Outpoint] = struct{}{}
+	}
+	var keys []string
+	if err = d.db.Model(tables.TableStateHistory{}).
+		Where("entity=? AND lookup_key IN(?) AND to_block>=?", tables.DeletedEntityDidCell, accountIds, s.blockNumber).
+		Distinct().Pluck("entity_key", &keys).Error; err != nil {
+		return fmt.Errorf("Pluck err: %s", err.Error())
+	}
+	for _, v := range keys {
+		if _, ok := outpoints[v]; !ok {
+			s.closeKeys[tables.DeletedEntityDidCell] = append(s.closeKeys[tables.DeletedEntityDidCell], v)
+		}
+	}
+	return nil
+}
+
+func (d *DbDao) GetStateHistoryStart() (start tables.TableStateHistory, err error) {
+	err = d.db.Where("entity=?", tables.StateHistoryEntityStart).Limit(1).Find(&start).Error
+	return
+}
+
+func (d *DbDao) DeleteStateHistoryStart() error {
+	return d.db.Where("entity=?", tables.StateHistoryEntityStart).Delete(&tables.TableStateHistory{}).Error
+}
+
+// SaveStateHistory writes the states changed by a parsed block, a block parsed again after a fork replaces them
+func (d *DbDao) SaveStateHistory(blockNumber uint64) error {
+	start, err := d.GetStateHistoryStart()
+	if err != nil {
+		return fmt.Errorf("GetStateHistoryStart err: %s", err.Error())
+	} else if start.Id == 0 {
+		return nil
+	} else if blockNumber <= start.FromBlock {
+		// a fork reaching back before the history began, what it holds is of the old chain
+		return d.InitStateHistory(blockNumber)
+	}
+
+	s := stateHistory{blockNumber: blockNumber, closeKeys: make(map[string][]string)}
+	var recordAccountIds []string
+	var mapRecordAccountIds = make(map[string]struct{})
+	addRecordAccountId := func(accountId string) {
+		if _, ok := mapRecordAccountIds[accountId]; !ok {
+			mapRecordAccountIds[accountId] = struct{}{}
+			recordAccountIds = append(recordAccountIds, accountId)
+		}
+	}
+
+	var lastId uint64
+	for {
+		list, err := d.FindDeletedInfoByBlockRange(blockNumber, blockNumber, lastId, stateHistoryBatch)
+		if err != nil {
+			return fmt.Errorf("FindDeletedInfoByBlockRange err: %s", err.Error())
+		}
+		for _, v := range list {
+			if v.Entity == tables.DeletedEntityRecords {
+				addRecordAccountId(v.EntityKey)
+			} else {
+				s.closeKeys[v.Entity] = append(s.closeKeys[v.Entity], v.EntityKey)
+			}
+		}
+		if len(list) < stateHistoryBatch {
+			break
+		}
+		lastId = list[len(list)-1].Id
+	}
+
+	lastId = 0
+	for {
+		list, err := d.FindExportAccounts(ExportFilter{SinceBlock: blockNumber, UntilBlock: blockNumber}, lastId, stateHistoryBatch)
+		if err != nil {
+			return fmt.Errorf("FindExportAccounts err: %s", err.Error())
+		} else if err = s.addAccounts(list); err != nil {
+			return err
+		}
+		for _, v := range list {
+			addRecordAccountId(v.AccountId)
+		}
+		if len(list) < stateHistoryBatch {
+			break
+		}
+		lastId = list[len(list)-1].Id
+	}
+
+	// a DID cell edit rewrites the records without touching the account row
+	lastId = 0
+	for {
+		list, err := d.FindDidCellsByBlockRange(blockNumber, blockNumber, lastId, stateHistoryBatch)
+		if err != nil {
+			return fmt.Errorf("FindDidCellsByBlockRange err: %s", err.Error())
+		} else if err = s.addDidCells(list); err != nil {
+			return err
+		} else if err = d.closeMovedDidCells(&s, list); err != nil {
+			return err
+		}
+		for _, v := range list {
+			addRecordAccountId(v.AccountId)
+		}
+		if len(list) < stateHistoryBatch {
+			break
+		}
+		lastId = list[len(list)-1].Id
+	}
+
+	lastId = 0
+	for {
+		list, err := d.FindReversesByBlockRange(blockNumber, blockNumber, lastId, stateHistoryBatch)
+		if err != nil {
+			return fmt.Errorf("FindReversesByBlockRange err: %s", err.Error())
+		} else if err = s.addReverses(list); err != nil {
+			return err
+		}
+		if len(list) < stateHistoryBatch {
+			break
+		}
+		lastId = list[len(list)-1].Id
+	}
+
+	if err = s.addRecords(d, recordAccountIds); err != nil {
+		return err
+	}
+
+	return d.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("from_block>=?", blockNumber).Delete(&tables.TableStateHistory{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(tables.TableStateHistory{}).
+			Where("to_block>=? AND to_block<?", blockNumber, tables.StateHistoryOpen).
+			Update("to_block", tables.StateHistoryOpen).Error; err != nil {
+			return err
+		}
+		return s.write(tx)
+	})
+}
+
+// InitStateHistory starts the history over with the current state of every table as of blockNumber,
+// the parser has to be stopped while it runs. The start row goes in last, a history without it is built again.
+func (d *DbDao) InitStateHistory(blockNumber uint64) error {
+	for {
+		res := d.db.Where("id>0").Limit(stateHistoryBatch * 10).Delete(&tables.TableStateHistory{})
+		if res.Error != nil {
+			return fmt.Errorf("Delete err: %s", res.Error.Error())
+		} else if res.RowsAffected < stateHistoryBatch*10 {
+			break
+		}
+	}
+
+	var lastId uint64
+	for {
+		list, err := d.FindExportAccounts(ExportFilter{}, lastId, stateHistoryBatch)
+		if err != nil {
+			return fmt.Errorf("FindExportAccounts err: %s", err.Error())
+		}
+		s := stateHistory{blockNumber: blockNumber, closeKeys: make(map[string][]string)}
+		if err = s.addAccounts(list); err != nil {
+			return err
+		}
+		var accountIds []string
+		for _, v := range list {
+			accountIds = append(accountIds, v.AccountId)
+		}
+		if err = s.addRecords(d, accountIds); err != nil {
+			return err
+		} else if err = s.write(d.db); err != nil {
+			return fmt.Errorf("write accounts err: %s", err.Error())
+		}
+		if len(list) < stateHistoryBatch {
+			break
+		}
+		lastId = list[len(list)-1].Id
+	}
+
+	lastId = 0
+	for {
+		list, err := d.FindDidCellsByBlockRange(0, tables.StateHistoryOpen, lastId, stateHistoryBatch)
+		if err != nil {
+			return fmt.Errorf("FindDidCellsByBlockRange err: %s", err.Error())
+		}
+		s := stateHistory{blockNumber: blockNumber, closeKeys: make(map[string][]string)}
+		if err = s.addDidCells(list); err != nil {
+			return err
+		} else if err = s.write(d.db); err != nil {
+			return fmt.Errorf("write did cells err: %s", err.Error())
+		}
+		if len(list) < stateHistoryBatch {
+			break
+		}
+		lastId = list[len(list)-1].Id
+	}
+
+	lastId = 0
+	for {
+		list, err := d.FindReversesByBlockRange(0, tables.StateHistoryOpen, lastId, stateHistoryBatch)
+		if err != nil {
+			return fmt.Errorf("FindReversesByBlockRange err: %s", err.Error())
+		}
+		s := stateHistory{blockNumber: blockNumber, closeKeys: make(map[string][]string)}
+		if err = s.addReverses(list); err != nil {
+			return err
+		} else if err = s.write(d.db); err != nil {
+			return fmt.Errorf("write reverses err: %s", err.Error())
+		}
+		if len(list) < stateHistoryBatch {
+			break
+		}
+		lastId = list[len(list)-1].Id
+	}
+
+	start := tables.TableStateHistory{Entity: tables.StateHistoryEntityStart, FromBlock: blockNumber, ToBlock: tables.StateHistoryOpen}
+	return d.db.Create(&start).Error
+}
+
+// PruneStateHistory deletes the states that ended at or before beforeBlock, and moves the start up to it
+func (d *DbDao) PruneStateHistory(beforeBlock uint64) (int64, error) {
+	var total int64
+	for {
+		res := d.db.Where("to_block<=?", beforeBlock).Limit(stateHistoryBatch * 10).Delete(&tables.TableStateHistory{})
+		if res.Error != nil {
+			return total, res.Error
+		}
+		total += res.RowsAffected
+		if res.RowsAffected < stateHistoryBatch*10 {
+			break
+		}
+	}
+	err := d.db.Model(tables.TableStateHistory{}).
+		Where("entity=? AND from_block<?", tables.StateHistoryEntityStart, beforeBlock).
+		Update("from_block", beforeBlock).Error
+	return total, err
+}
+
+// findStateAsOf gives the state of lookupKey valid at blockNumber, the newest first when there are several
+func (d *DbDao) findStateAsOf(entity, lookupKey string, blockNumber uint64) (list []tables.TableStateHistory, err error) {
+	err = d.db.Where("entity=? AND lookup_key=? AND from_block<=? AND to_block>?", entity, lookupKey, blockNumber, blockNumber).
+		Order("from_block DESC,id DESC").Find(&list).Error
+	return
+}
+
+func (d *DbDao) FindAccountInfoAsOf(accountId string, blockNumber uint64) (accountInfo tables.TableAccountInfo, err error) {
+	list, err := d.findStateAsOf(tables.DeletedEntityAccount, accountId, blockNumber)
+	if err != nil || len(list) == 0 {
+		return
+	}
+	err = json.Unmarshal([]byte(list[0].Data), &accountInfo)
+	return
+}
+
+func (d *DbDao) FindAccountRecordsAsOf(accountId string, blockNumber uint64) (list []tables.TableRecordsInfo, err error) {
+	states, err := d.findStateAsOf(tables.DeletedEntityRecords, accountId, blockNumber)
+	if err != nil || len(states) == 0 {
+		return
+	}
+	err = json.Unmarshal([]byte(states[0].Data), &list)
+	return
+}
+
+// GetDidCellAsOf is GetDidCellByAccountId at blockNumber
+func (d *DbDao) GetDidCellAsOf(accountId string, blockNumber uint64) (info tables.TableDidCellInfo, err error) {
+	states, err := d.findStateAsOf(tables.DeletedEntityDidCell, accountId, blockNumber)
+	if err != nil {
+		return
+	}
+	for _, v := range states {
+		var didCell tables.TableDidCellInfo
+		if err = json.Unmarshal([]byte(v.Data), &didCell); err != nil {
+			return
+		} else if info.Id == 0 || didCell.ExpiredAt > info.ExpiredAt {
+			info = didCell
+		}
+	}
+	return
+}
+
+// FindLatestReverseRecordAsOf is FindLatestReverseRecord at blockNumber
+func (d *DbDao) FindLatestReverseRecordAsOf(chainType common.ChainType, address, btcAddr string, blockNumber uint64) (r tables.TableReverseInfo, err error) {
+	if btcAddr != "" {
+		address = btcAddr
+	}
+	states, err := d.findStateAsOf(tables.DeletedEntityReverse, reverseLookupKey(chainType, address), blockNumber)
+	if err != nil {
+		return
+	}
+	for _, v := range states {
+		var reverse tables.TableReverseInfo
+		if err = json.Unmarshal([]byte(v.Data), &reverse); err != nil {
+			return
+		} else if r.Id == 0 || reverse.BlockNumber > r.BlockNumber ||
+			(reverse.BlockNumber == r.BlockNumber && reverse.Outpoint > r.Outpoint) {
+			r = reverse
+		}
+	}
+	return
+}
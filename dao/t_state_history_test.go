@@ -0,0 +1,110 @@
+package dao
+
+import (
+	"das-account-indexer/tables"
+	"testing"
+)
+
+// a DID cell moved to a new outpoint, in place with and without the old one logged and by delete and insert, then recycled
+func TestDidCellStateHistory(t *testing.T) {
+	d := testDbDao(t)
+	const (
+		accountId = "0x00000000000000000000000000000000test5050"
+		block     = uint64(9000000)
+	)
+	outpoints := []string{
+		"0x0000000000000000000000000000000000000000000000000000000000005050-0",
+		"0x0000000000000000000000000000000000000000000000000000000000005051-0",
+		"0x0000000000000000000000000000000000000000000000000000000000005052-0",
+		"0x0000000000000000000000000000000000000000000000000000000000005053-0",
+	}
+	cleanUp := func() {
+		d.db.Where("account_id=?", accountId).Delete(&tables.TableDidCellInfo{})
+		d.db.Where("entity=? AND entity_key IN(?)", tables.DeletedEntityDidCell, outpoints).Delete(&tables.TableDeletedInfo{})
+		d.db.Where("id>0").Delete(&tables.TableStateHistory{})
+	}
+	cleanUp()
+	defer cleanUp()
+
+	didCell := func(blockNumber uint64, outpoint string) tables.TableDidCellInfo {
+		return tables.TableDidCellInfo{
+			BlockNumber: blockNumber,
+			Outpoint:    outpoint,
+			AccountId:   accountId,
+			Account:     "test5050.bit",
+			Args:        "0x01",
+			ExpiredAt:   1900000000,
+		}
+	}
+
+	d.SetParsingBlockNumber(block)
+	if err := d.DidCellUpdateList(nil, []tables.TableDidCellInfo{didCell(block, outpoints[0])}, nil, nil); err != nil {
+		t.Fatal(err)
+	} else if err = d.InitStateHistory(block); err != nil {
+		t.Fatal(err)
+	}
+
+	// moved in place, as an owner edit does
+	d.SetParsingBlockNumber(block + 1)
+	if err := d.EditDidCellOwner(outpoints[0], didCell(block+1, outpoints[1]), nil); err != nil {
+		t.Fatal(err)
+	} else if err = d.SaveStateHistory(block + 1); err != nil {
+		t.Fatal(err)
+	}
+
+	// moved in place with nothing logged, the state is closed by account
+	d.SetParsingBlockNumber(block + 2)
+	if err := d.db.Model(tables.TableDidCellInfo{}).Where("outpoint=?", outpoints[1]).
+		Updates(map[string]interface{}{"outpoint": outpoints[2], "block_number": block + 2}).Error; err != nil {
+		t.Fatal(err)
+	} else if err = d.SaveStateHistory(block + 2); err != nil {
+		t.Fatal(err)
+	}
+	// parsed again after a fork, what it closed is closed again
+	if err := d.SaveStateHistory(block + 2); err != nil {
+		t.Fatal(err)
+	}
+
+	// moved by delete and insert, as the parser does
+	d.SetParsingBlockNumber(block + 3)
+	if err := d.DidCellUpdateList([]string{outpoints[2]}, []tables.TableDidCellInfo{didCell(block+3, outpoints[3])}, []string{accountId}, nil); err != nil {
+		t.Fatal(err)
+	} else if err = d.SaveStateHistory(block + 3); err != nil {
+		t.Fatal(err)
+	}
+
+	d.SetParsingBlockNumber(block + 4)
+	if err := d.DidCellRecycleList([]string{outpoints[3]}, []string{accountId}); err != nil {
+		t.Fatal(err)
+	} else if err = d.SaveStateHistory(block + 4); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, v := range []struct {
+		blockNumber uint64
+		outpoint    string
+	}{
+		{block, outpoints[0]},
+		{block + 1, outpoints[1]},
+		{block + 2, outpoints[2]},
+		{block + 3, outpoints[3]},
+		{block + 4, ""},
+		{block + 100, ""},
+	} {
+		info, err := d.GetDidCellAsOf(accountId, v.blockNumber)
+		if err != nil {
+			t.Fatal(err)
+		} else if info.Outpoint != v.outpoint {
+			t.Fatal("as of", v.blockNumber, "outpoint:", info.Outpoint, "want:", v.outpoint)
+		}
+	}
+
+	var open int64
+	if err := d.db.Model(tables.TableStateHistory{}).
+		Where("entity=? AND lookup_key=? AND to_block=?", tables.DeletedEntityDidCell, accountId, tables.StateHistoryOpen).
+		Count(&open).Error; err != nil {
+		t.Fatal(err)
+	} else if open != 0 {
+		t.Fatal("open states left:", open)
+	}
+}
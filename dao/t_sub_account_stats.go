@@ -0,0 +1,232 @@
+package dao
+
+import (
+	"das-account-indexer/tables"
+	"github.com/dotbitHQ/das-lib/common"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"sort"
+)
+
+const secondsPerDay = 86400
+
+type subAccountStatsRow struct {
+	AccountId       string
+	ParentAccountId string
+	OwnerChainType  common.ChainType
+	Owner           string
+	RegisteredAt    uint64
+	ExpiredAt       uint64
+}
+
+type subAccountStatsDailyKey struct {
+	parentAccountId string
+	kind            tables.SubAccountStatsKind
+	day             uint64
+}
+
+type subAccountStatsOwnerKey struct {
+	parentAccountId string
+	chainType       common.ChainType
+	owner           string
+}
+
+func findSubAccountStatsRows(tx *gorm.DB, accountIds []string) (map[string]subAccountStatsRow, error) {
+	var list []subAccountStatsRow
+	if err := tx.Model(tables.TableAccountInfo{}).
+		Select("account_id,parent_account_id,owner_chain_type,owner,registered_at,expired_at").
+		Where("account_id IN(?) AND parent_account_id!=''", accountIds).
+		Find(&list).Error; err != nil {
+		return nil, err
+	}
+	var res = make(map[string]subAccountStatsRow, len(list))
+	for _, v := range list {
+		res[v.AccountId] = v
+	}
+	return res, nil
+}
+
+// withSubAccountStats runs fn and adds what it changed of the given sub-accounts to the stats, in the same transaction,
+// so a create, renew, transfer or recycle only has to name the sub-accounts it touches
+func withSubAccountStats(tx *gorm.DB, accountIds []string, fn func(tx *gorm.DB) error) error {
+	if len(accountIds) == 0 {
+		return fn(tx)
+	}
+	before, err := findSubAccountStatsRows(tx, accountIds)
+	if err != nil {
+		return err
+	}
+	if err = fn(tx); err != nil {
+		return err
+	}
+	after, err := findSubAccountStatsRows(tx, accountIds)
+	if err != nil {
+		return err
+	}
+
+	var daily = make(map[subAccountStatsDailyKey]int64)
+	var owners = make(map[subAccountStatsOwnerKey]int64)
+	for k, v := range before {
+		daily[subAccountStatsDailyKey{v.ParentAccountId, tables.SubAccountStatsKindExpire, v.ExpiredAt / secondsPerDay}]--
+		owners[subAccountStatsOwnerKey{v.ParentAccountId, v.OwnerChainType, v.Owner}]--
+		// mints count the sub-accounts still there, as RebuildSubAccountStats does
+		if _, ok := after[k]; !ok {
+			daily[subAccountStatsDailyKey{v.ParentAccountId, tables.SubAccountStatsKindMint, v.RegisteredAt / secondsPerDay}]--
+		}
+	}
+	for k, v := range after {
+		daily[subAccountStatsDailyKey{v.ParentAccountId, tables.SubAccountStatsKindExpire, v.ExpiredAt / secondsPerDay}]++
+		owners[subAccountStatsOwnerKey{v.ParentAccountId, v.OwnerChainType, v.Owner}]++
+		if _, ok := before[k]; !ok {
+			daily[subAccountStatsDailyKey{v.ParentAccountId, tables.SubAccountStatsKindMint, v.RegisteredAt / secondsPerDay}]++
+		}
+	}
+
+	var dailyList []tables.TableSubAccountStatsDaily
+	for k, v := range daily {
+		if v != 0 {
+			dailyList = append(dailyList, tables.TableSubAccountStatsDaily{ParentAccountId: k.parentAccountId, Kind: k.kind, Day: k.day, Count: v})
+		}
+	}
+	var ownerList []tables.TableSubAccountStatsOwner
+	for k, v := range owners {
+		if v != 0 {
+			ownerList = append(ownerList, tables.TableSubAccountStatsOwner{ParentAccountId: k.parentAccountId, OwnerChainType: k.chainType, Owner: k.owner, Count: v})
+		}
+	}
+	// rows are locked in the same order by every transaction
+	sort.Slice(dailyList, func(i, j int) bool {
+		a, b := dailyList[i], dailyList[j]
+		if a.ParentAccountId != b.ParentAccountId {
+			return a.ParentAccountId < b.ParentAccountId
+		} else if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		return a.Day < b.Day
+	})
+	sort.Slice(ownerList, func(i, j int) bool {
+		a, b := ownerList[i], ownerList[j]
+		if a.ParentAccountId != b.ParentAccountId {
+			return a.ParentAccountId < b.ParentAccountId
+		} else if a.OwnerChainType != b.OwnerChainType {
+			return a.OwnerChainType < b.OwnerChainType
+		}
+		return a.Owner < b.Owner
+	})
+
+	addCount := clause.OnConflict{DoUpdates: clause.Assignments(map[string]interface{}{"count": gorm.Expr("count+VALUES(count)")})}
+	if len(dailyList) > 0 {
+		if err = tx.Clauses(addCount).Create(&dailyList).Error; err != nil {
+			return err
+		}
+	}
+	if len(ownerList) > 0 {
+		if err = tx.Clauses(addCount).Create(&ownerList).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *DbDao) SubAccountStatsEmpty() (bool, error) {
+	var info tables.TableSubAccountStatsOwner
+	err := d.db.Select("id").Limit(1).Find(&info).Error
+	return info.Id == 0, err
+}
+
+// RebuildSubAccountStats computes the stats of every parent again from t_account_info,
+// the parser has to be stopped while it runs
+func (d *DbDao) RebuildSubAccountStats() error {
+	return d.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id>0").Delete(&tables.TableSubAccountStatsDaily{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("id>0").Delete(&tables.TableSubAccountStatsOwner{}).Error; err != nil {
+			return err
+		}
+
+		for kind, column := range map[tables.SubAccountStatsKind]string{
+			tables.SubAccountStatsKindExpire: "expired_at",
+			tables.SubAccountStatsKindMint:   "registered_at",
+		} {
+			var list []tables.TableSubAccountStatsDaily
+			if err := tx.Model(tables.TableAccountInfo{}).
+				Select("parent_account_id,? AS kind,"+column+" DIV ? AS day,COUNT(*) AS count", kind, secondsPerDay).
+				Where("parent_account_id!=''").
+				Group("parent_account_id,day").
+				Find(&list).Error; err != nil {
+				return err
+			}
+			if len(list) > 0 {
+				if err := tx.CreateInBatches(&list, 1000).Error; err != nil {
+					return err
+				}
+			}
+		}
+
+		var owners []tables.TableSubAccountStatsOwner
+		if err := tx.Model(tables.TableAccountInfo{}).
+			Select("parent_account_id,owner_chain_type,owner,COUNT(*) AS count").
+			Where("parent_account_id!=''").
+			Group("parent_account_id,owner_chain_type,owner").
+			Find(&owners).Error; err != nil {
+			return err
+		}
+		if len(owners) > 0 {
+			if err := tx.CreateInBatches(&owners, 1000).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (d *DbDao) GetSubAccountStatsDaily(parentAccountId string, kind tables.SubAccountStatsKind, fromDay, toDay uint64) (list []tables.TableSubAccountStatsDaily, err error) {
+	err = d.db.Where("parent_account_id=? AND kind=? AND day BETWEEN ? AND ? AND count>0", parentAccountId, kind, fromDay, toDay).
+		Order("day").Find(&list).Error
+	return
+}
+
+// GetSubAccountStatsExpiring counts the sub-accounts expiring before each of the days, from fromDay on
+func (d *DbDao) GetSubAccountStatsExpiring(parentAccountId string, fromDay uint64, days []uint64) ([]int64, error) {
+	res := make([]int64, len(days))
+	for i, v := range days {
+		var count int64
+		if err := d.db.Model(tables.TableSubAccountStatsDaily{}).Select("IFNULL(SUM(count),0)").
+			Where("parent_account_id=? AND kind=? AND day>=? AND day<?", parentAccountId, tables.SubAccountStatsKindExpire, fromDay, v).
+			Scan(&count).Error; err != nil {
+			return nil, err
+		}
+		res[i] = count
+	}
+	return res, nil
+}
+
+type SubAccountStatsTotal struct {
+	ParentAccountId string
+	Total           int64
+	Owners          int64
+}
+
+// GetSubAccountStatsTotals gives the number of sub-accounts and of owners of each parent
+func (d *DbDao) GetSubAccountStatsTotals(parentAccountIds []string) (map[string]SubAccountStatsTotal, error) {
+	var list []SubAccountStatsTotal
+	if err := d.db.Model(tables.TableSubAccountStatsOwner{}).
+		Select("parent_account_id,SUM(count) AS total,COUNT(*) AS owners").
+		Where("parent_account_id IN(?) AND count>0", parentAccountIds).
+		Group("parent_account_id").
+		Find(&list).Error; err != nil {
+		return nil, err
+	}
+	var res = make(map[string]SubAccountStatsTotal, len(list))
+	for _, v := range list {
+		res[v.ParentAccountId] = v
+	}
+	return res, nil
+}
+
+func (d *DbDao) GetSubAccountStatsTopOwners(parentAccountId string, limit int) (list []tables.TableSubAccountStatsOwner, err error) {
+	err = d.db.Where("parent_account_id=? AND count>0", parentAccountId).
+		Order("count DESC,id").Limit(limit).Find(&list).Error
+	return
+}
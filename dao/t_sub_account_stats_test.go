@@ -0,0 +1,90 @@
+package dao
+
+import (
+	"das-account-indexer/config"
+	"das-account-indexer/tables"
+	"gorm.io/gorm"
+	"os"
+	"testing"
+)
+
+// testDbDao connects to the mysql of DAS_TEST_MYSQL_ADDR, the test rows go in and out of its tables
+// and the sub-account stats and t_state_history are built over, so it has to be a database of its own
+func testDbDao(t *testing.T) *DbDao {
+	addr := os.Getenv("DAS_TEST_MYSQL_ADDR")
+	if addr == "" {
+		t.Skip("DAS_TEST_MYSQL_ADDR not set")
+	}
+	d, err := NewGormDB(config.DbMysql{
+		Addr:        addr,
+		User:        os.Getenv("DAS_TEST_MYSQL_USER"),
+		Password:    os.Getenv("DAS_TEST_MYSQL_PASSWORD"),
+		DbName:      os.Getenv("DAS_TEST_MYSQL_DB_NAME"),
+		MaxOpenConn: 5,
+		MaxIdleConn: 2,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+// a sub-account minted and recycled leaves the mint counts as RebuildSubAccountStats builds them
+func TestSubAccountStatsRecycle(t *testing.T) {
+	d := testDbDao(t)
+	const (
+		parentAccountId = "0x00000000000000000000000000000000test4400"
+		accountId       = "0x00000000000000000000000000000000test4401"
+		registeredAt    = uint64(1700000000)
+	)
+	day := registeredAt / secondsPerDay
+	cleanUp := func() {
+		d.db.Where("account_id=?", accountId).Delete(&tables.TableAccountInfo{})
+		d.db.Where("parent_account_id=?", parentAccountId).Delete(&tables.TableSubAccountStatsDaily{})
+		d.db.Where("parent_account_id=?", parentAccountId).Delete(&tables.TableSubAccountStatsOwner{})
+	}
+	cleanUp()
+	defer cleanUp()
+
+	mints := func() int64 {
+		list, err := d.GetSubAccountStatsDaily(parentAccountId, tables.SubAccountStatsKindMint, day, day)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var count int64
+		for _, v := range list {
+			count += v.Count
+		}
+		return count
+	}
+	ids := []string{accountId}
+
+	if err := withSubAccountStats(d.db, ids, func(tx *gorm.DB) error {
+		return tx.Create(&tables.TableAccountInfo{
+			AccountId:       accountId,
+			ParentAccountId: parentAccountId,
+			Account:         "test4401.test4400.bit",
+			Owner:           "0x01",
+			RegisteredAt:    registeredAt,
+			ExpiredAt:       registeredAt + 365*secondsPerDay,
+		}).Error
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if got := mints(); got != 1 {
+		t.Fatalf("mints after create: %d, want 1", got)
+	}
+
+	if err := withSubAccountStats(d.db, ids, func(tx *gorm.DB) error {
+		return tx.Where("account_id=?", accountId).Delete(&tables.TableAccountInfo{}).Error
+	}); err != nil {
+		t.Fatal(err)
+	}
+	incremental := mints()
+	if err := d.RebuildSubAccountStats(); err != nil {
+		t.Fatal(err)
+	}
+	if rebuilt := mints(); incremental != rebuilt || rebuilt != 0 {
+		t.Fatalf("mints after recycle: incremental %d, rebuilt %d, want 0", incremental, rebuilt)
+	}
+}
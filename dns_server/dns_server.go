@@ -0,0 +1,87 @@
+package dns_server
+
+import (
+	"context"
+	"das-account-indexer/dao"
+	"fmt"
+	"github.com/miekg/dns"
+	"github.com/scorpiotzh/mylog"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	log = mylog.NewLogger("dns_server", mylog.LevelDebug)
+)
+
+const defaultTtl = 300
+
+// DnsServer answers queries for <account>.<zone> from the dns.* and dweb.* records of the account,
+// e.g. with zone bit.example.com, alice.bit.example.com serves the records of alice.bit
+type DnsServer struct {
+	Ctx   context.Context
+	Wg    *sync.WaitGroup
+	Addr  string
+	Zone  string
+	Ttl   uint32 // used when a record has no ttl
+	DbDao *dao.DbDao
+
+	zone string
+	srv  []*dns.Server
+}
+
+func (d *DnsServer) Run() error {
+	if d.Zone == "" {
+		return fmt.Errorf("dns zone is empty")
+	}
+	d.zone = dns.Fqdn(strings.ToLower(d.Zone))
+	if d.Ttl == 0 {
+		d.Ttl = defaultTtl
+	}
+
+	for _, network := range []string{"udp", "tcp"} {
+		srv := &dns.Server{Addr: d.Addr, Net: network, Handler: d, ReadTimeout: time.Second * 5, WriteTimeout: time.Second * 5}
+		if err := listenAndServe(srv); err != nil {
+			d.Shutdown()
+			return fmt.Errorf("dns server %s listen err: %s", network, err.Error())
+		}
+		d.srv = append(d.srv, srv)
+	}
+
+	d.Wg.Add(1)
+	go func() {
+		defer d.Wg.Done()
+		<-d.Ctx.Done()
+		d.Shutdown()
+	}()
+	return nil
+}
+
+// listenAndServe returns once srv listens, or with the error it failed to listen with
+func listenAndServe(srv *dns.Server) error {
+	started := make(chan struct{})
+	failed := make(chan error, 1)
+	srv.NotifyStartedFunc = func() { close(started) }
+	go func() {
+		if err := srv.ListenAndServe(); err != nil {
+			log.Error("dns_server run err:", srv.Net, err.Error())
+			failed <- err
+		}
+	}()
+	select {
+	case <-started:
+		return nil
+	case err := <-failed:
+		return err
+	}
+}
+
+func (d *DnsServer) Shutdown() {
+	log.Warn("dns server Shutdown ... ")
+	for _, srv := range d.srv {
+		if err := srv.Shutdown(); err != nil {
+			log.Error("dns server Shutdown err:", srv.Net, err.Error())
+		}
+	}
+}
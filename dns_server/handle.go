@@ -0,0 +1,176 @@
+package dns_server
+
+import (
+	"das-account-indexer/tables"
+	"fmt"
+	"github.com/dotbitHQ/das-lib/common"
+	"github.com/miekg/dns"
+	"golang.org/x/net/idna"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	dnsLinkPrefix = "_dnslink."
+	dnsUdpSize    = 1232 // advertised in EDNS0 answers, the size of DNS flag day 2020
+)
+
+func (d *DnsServer) ServeDNS(w dns.ResponseWriter, r *dns.Msg) {
+	m := new(dns.Msg)
+	m.SetReply(r)
+	m.Authoritative = true
+	if len(r.Question) != 1 {
+		m.Rcode = dns.RcodeFormatError
+	} else if err := d.answer(m, r.Question[0]); err != nil {
+		log.Error("answer err:", err.Error(), r.Question[0].String())
+		m.Rcode = dns.RcodeServerFailure
+	}
+	truncate(w, r, m)
+	if err := w.WriteMsg(m); err != nil {
+		log.Error("WriteMsg err:", err.Error())
+	}
+}
+
+// truncate fits udp answers into the buffer size the client advertised with EDNS0, 512 bytes without,
+// large TXT sets then come with the TC bit and the client asks again over tcp
+func truncate(w dns.ResponseWriter, r, m *dns.Msg) {
+	size := dns.MinMsgSize
+	if opt := r.IsEdns0(); opt != nil {
+		m.SetEdns0(dnsUdpSize, opt.Do())
+		if int(opt.UDPSize()) > size {
+			size = int(opt.UDPSize())
+		}
+	}
+	if _, ok := w.RemoteAddr().(*net.TCPAddr); ok {
+		size = dns.MaxMsgSize
+	}
+	m.Truncate(size)
+}
+
+func (d *DnsServer) answer(m *dns.Msg, q dns.Question) error {
+	qName := strings.ToLower(q.Name)
+	if !dns.IsSubDomain(d.zone, qName) {
+		m.Rcode = dns.RcodeRefused
+		return nil
+	}
+	if qName == d.zone {
+		if q.Qtype == dns.TypeSOA {
+			m.Answer = append(m.Answer, d.soa())
+		} else {
+			m.Ns = append(m.Ns, d.soa())
+		}
+		return nil
+	}
+
+	name := strings.TrimSuffix(qName, "."+d.zone)
+	dnsLink := strings.HasPrefix(name, dnsLinkPrefix)
+	name = strings.TrimPrefix(name, dnsLinkPrefix)
+	account, err := idna.ToUnicode(name)
+	if err != nil || !strings.HasSuffix(account, common.DasAccountSuffix) {
+		m.Rcode = dns.RcodeNameError
+		m.Ns = append(m.Ns, d.soa())
+		return nil
+	}
+
+	accountId := common.Bytes2Hex(common.GetAccountIdByAccount(account))
+	acc, err := d.DbDao.FindAccountInfoByAccountId(accountId)
+	if err != nil {
+		return fmt.Errorf("FindAccountInfoByAccountId err: %s", err.Error())
+	}
+	// missing, expired and cross-chain locked accounts do not resolve
+	if acc.Id == 0 || acc.ExpiredAt <= uint64(time.Now().Unix()) || acc.Status == tables.AccountStatusOnLock {
+		m.Rcode = dns.RcodeNameError
+		m.Ns = append(m.Ns, d.soa())
+		return nil
+	}
+	list, err := d.DbDao.FindAccountRecordsByAccountId(accountId)
+	if err != nil {
+		return fmt.Errorf("FindAccountRecordsByAccountId err: %s", err.Error())
+	}
+
+	m.Answer = append(m.Answer, d.recordsToRR(q.Name, q.Qtype, dnsLink, list)...)
+	if len(m.Answer) == 0 {
+		m.Ns = append(m.Ns, d.soa())
+	}
+	return nil
+}
+
+// recordsToRR converts the records matching qType, a CNAME answers any type as it does in a zone file
+func (d *DnsServer) recordsToRR(name string, qType uint16, dnsLink bool, list []tables.TableRecordsInfo) []dns.RR {
+	var res, cname []dns.RR
+	for _, v := range list {
+		hdr := dns.RR_Header{Name: name, Class: dns.ClassINET, Ttl: d.recordTtl(v.Ttl)}
+		value := strings.TrimSpace(v.Value)
+		if dnsLink {
+			if v.Type != "dweb" || (qType != dns.TypeTXT && qType != dns.TypeANY) {
+				continue
+			}
+			if protocol := strings.ToLower(v.Key); protocol == "ipfs" || protocol == "ipns" {
+				value = strings.TrimPrefix(value, protocol+"://")
+				hdr.Rrtype = dns.TypeTXT
+				res = append(res, &dns.TXT{Hdr: hdr, Txt: []string{fmt.Sprintf("dnslink=/%s/%s", protocol, value)}})
+			}
+			continue
+		}
+		if v.Type != "dns" {
+			continue
+		}
+
+		switch rrType := strings.ToUpper(v.Key); {
+		case rrType == "CNAME":
+			if _, ok := dns.IsDomainName(value); ok {
+				hdr.Rrtype = dns.TypeCNAME
+				cname = append(cname, &dns.CNAME{Hdr: hdr, Target: dns.Fqdn(value)})
+			}
+		case rrType == "A" && (qType == dns.TypeA || qType == dns.TypeANY):
+			if ip := net.ParseIP(value); ip != nil && ip.To4() != nil {
+				hdr.Rrtype = dns.TypeA
+				res = append(res, &dns.A{Hdr: hdr, A: ip.To4()})
+			}
+		case rrType == "AAAA" && (qType == dns.TypeAAAA || qType == dns.TypeANY):
+			if ip := net.ParseIP(value); ip != nil && ip.To4() == nil {
+				hdr.Rrtype = dns.TypeAAAA
+				res = append(res, &dns.AAAA{Hdr: hdr, AAAA: ip})
+			}
+		case rrType == "TXT" && (qType == dns.TypeTXT || qType == dns.TypeANY):
+			hdr.Rrtype = dns.TypeTXT
+			res = append(res, &dns.TXT{Hdr: hdr, Txt: splitTxt(value)})
+		}
+	}
+	if len(cname) > 0 && qType != dns.TypeANY {
+		return cname[:1]
+	}
+	return append(res, cname...)
+}
+
+func (d *DnsServer) recordTtl(ttl string) uint32 {
+	if n, err := strconv.ParseUint(strings.TrimSpace(ttl), 10, 32); err == nil && n > 0 {
+		return uint32(n)
+	}
+	return d.Ttl
+}
+
+func (d *DnsServer) soa() dns.RR {
+	return &dns.SOA{
+		Hdr:     dns.RR_Header{Name: d.zone, Rrtype: dns.TypeSOA, Class: dns.ClassINET, Ttl: d.Ttl},
+		Ns:      "ns." + d.zone,
+		Mbox:    "hostmaster." + d.zone,
+		Serial:  uint32(time.Now().Unix()),
+		Refresh: 3600,
+		Retry:   600,
+		Expire:  86400,
+		Minttl:  d.Ttl,
+	}
+}
+
+// splitTxt cuts a value into the 255 byte strings a TXT record is made of
+func splitTxt(value string) []string {
+	var res []string
+	for len(value) > 255 {
+		res = append(res, value[:255])
+		value = value[255:]
+	}
+	return append(res, value)
+}
@@ -0,0 +1,209 @@
+package export
+
+import (
+	"context"
+	"das-account-indexer/config"
+	"das-account-indexer/dao"
+	"das-account-indexer/tables"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// changefeed of a block range, built from the block_number the parser writes to each row and from t_deleted_info.
+// Tables keep the latest state only, so a row changed in the range shows up with its current value,
+// in the range of its last change. Deletes are written first and existing rows after them,
+// a key deleted and created again in the range ends up existing, as does a record list
+// that is deleted and written back on each edit.
+
+const (
+	EntityAccount = tables.DeletedEntityAccount // key account_id, value the t_account_info row
+	EntityRecords = tables.DeletedEntityRecords // key account_id, value the full record list of the account
+	EntityDidCell = tables.DeletedEntityDidCell // key outpoint, value the t_did_cell_info row, a move to a new outpoint deletes the old one
+	EntityReverse = tables.DeletedEntityReverse // key outpoint, value the t_reverse_info row
+)
+
+const changefeedRetention = 259200 // blocks, about 30 days
+
+// ChangefeedEarliestBlock is the first block the changefeed serves when latestBlock is the last parsed one,
+// the parser drops the deletes logged before it. A consumer whose cursor falls behind resyncs from a full export.
+func ChangefeedEarliestBlock(latestBlock uint64) uint64 {
+	retention := config.Cfg.Export.Retention
+	if retention == 0 {
+		retention = changefeedRetention
+	}
+	if latestBlock <= retention {
+		return 0
+	}
+	return latestBlock - retention
+}
+
+type Change struct {
+	BlockNumber uint64      `json:"block_number"`
+	Entity      string      `json:"entity"`
+	Key         string      `json:"key"`
+	Deleted     bool        `json:"deleted"`
+	Value       interface{} `json:"value"` // null when deleted
+}
+
+// Changefeed writes everything changed in [fromBlock, toBlock] to w as ndjson, flush is called after each batch
+func Changefeed(ctx context.Context, dbDao *dao.DbDao, w io.Writer, fromBlock, toBlock uint64, flush func()) (int, error) {
+	if fromBlock > toBlock {
+		return 0, fmt.Errorf("block range invalid: %d-%d", fromBlock, toBlock)
+	}
+	f := changefeed{ctx: ctx, dbDao: dbDao, enc: json.NewEncoder(w), flush: flush}
+	for _, fn := range []func(from, to uint64) error{f.deleted, f.accounts, f.didCells, f.reverses} {
+		if err := fn(fromBlock, toBlock); err != nil {
+			return f.count, err
+		}
+	}
+	return f.count, nil
+}
+
+type changefeed struct {
+	ctx   context.Context
+	dbDao *dao.DbDao
+	enc   *json.Encoder
+	flush func()
+	count int
+}
+
+func (f *changefeed) write(list []Change) error {
+	for i := range list {
+		if err := f.enc.Encode(&list[i]); err != nil {
+			return fmt.Errorf("write err: %s", err.Error())
+		}
+	}
+	f.count += len(list)
+	if f.flush != nil {
+		f.flush()
+	}
+	return f.ctx.Err()
+}
+
+func (f *changefeed) deleted(from, to uint64) error {
+	var lastId uint64
+	for {
+		list, err := f.dbDao.FindDeletedInfoByBlockRange(from, to, lastId, batchSize)
+		if err != nil {
+			return fmt.Errorf("FindDeletedInfoByBlockRange err: %s", err.Error())
+		} else if len(list) == 0 {
+			return nil
+		}
+		lastId = list[len(list)-1].Id
+
+		changes := make([]Change, 0, len(list))
+		for _, v := range list {
+			changes = append(changes, Change{BlockNumber: v.BlockNumber, Entity: v.Entity, Key: v.EntityKey, Deleted: true})
+		}
+		if err = f.write(changes); err != nil || len(list) < batchSize {
+			return err
+		}
+	}
+}
+
+func (f *changefeed) accounts(from, to uint64) error {
+	var lastId uint64
+	filter := dao.ExportFilter{SinceBlock: from, UntilBlock: to}
+	for {
+		list, err := f.dbDao.FindExportAccounts(filter, lastId, batchSize)
+		if err != nil {
+			return fmt.Errorf("FindExportAccounts err: %s", err.Error())
+		} else if len(list) == 0 {
+			return nil
+		}
+		lastId = list[len(list)-1].Id
+
+		var accountIds []string
+		var blockNumbers = make(map[string]uint64)
+		changes := make([]Change, 0, len(list)*2)
+		for i, v := range list {
+			accountIds = append(accountIds, v.AccountId)
+			blockNumbers[v.AccountId] = v.BlockNumber
+			changes = append(changes, Change{BlockNumber: v.BlockNumber, Entity: EntityAccount, Key: v.AccountId, Value: &list[i]})
+		}
+		records, err := f.records(accountIds, blockNumbers)
+		if err != nil {
+			return err
+		}
+		if err = f.write(append(changes, records...)); err != nil || len(list) < batchSize {
+			return err
+		}
+	}
+}
+
+// didCells also writes the records of their accounts, a DID cell edit rewrites them without touching the account row
+func (f *changefeed) didCells(from, to uint64) error {
+	var lastId uint64
+	for {
+		list, err := f.dbDao.FindDidCellsByBlockRange(from, to, lastId, batchSize)
+		if err != nil {
+			return fmt.Errorf("FindDidCellsByBlockRange err: %s", err.Error())
+		} else if len(list) == 0 {
+			return nil
+		}
+		lastId = list[len(list)-1].Id
+
+		var accountIds []string
+		var blockNumbers = make(map[string]uint64)
+		changes := make([]Change, 0, len(list)*2)
+		for i, v := range list {
+			if _, ok := blockNumbers[v.AccountId]; !ok {
+				accountIds = append(accountIds, v.AccountId)
+			}
+			if v.BlockNumber > blockNumbers[v.AccountId] {
+				blockNumbers[v.AccountId] = v.BlockNumber
+			}
+			changes = append(changes, Change{BlockNumber: v.BlockNumber, Entity: EntityDidCell, Key: v.Outpoint, Value: &list[i]})
+		}
+		records, err := f.records(accountIds, blockNumbers)
+		if err != nil {
+			return err
+		}
+		if err = f.write(append(changes, records...)); err != nil || len(list) < batchSize {
+			return err
+		}
+	}
+}
+
+func (f *changefeed) reverses(from, to uint64) error {
+	var lastId uint64
+	for {
+		list, err := f.dbDao.FindReversesByBlockRange(from, to, lastId, batchSize)
+		if err != nil {
+			return fmt.Errorf("FindReversesByBlockRange err: %s", err.Error())
+		} else if len(list) == 0 {
+			return nil
+		}
+		lastId = list[len(list)-1].Id
+
+		changes := make([]Change, 0, len(list))
+		for i, v := range list {
+			changes = append(changes, Change{BlockNumber: v.BlockNumber, Entity: EntityReverse, Key: v.Outpoint, Value: &list[i]})
+		}
+		if err = f.write(changes); err != nil || len(list) < batchSize {
+			return err
+		}
+	}
+}
+
+// records gives one change per account, an account without records gets an empty list
+func (f *changefeed) records(accountIds []string, blockNumbers map[string]uint64) ([]Change, error) {
+	list, err := f.dbDao.FindRecordsByAccountIds(accountIds)
+	if err != nil {
+		return nil, fmt.Errorf("FindRecordsByAccountIds err: %s", err.Error())
+	}
+	var mapRecords = make(map[string][]Record)
+	for _, v := range list {
+		mapRecords[v.AccountId] = append(mapRecords[v.AccountId], Record{Type: v.Type, Key: v.Key, Label: v.Label, Value: v.Value, Ttl: v.Ttl})
+	}
+	changes := make([]Change, 0, len(accountIds))
+	for _, v := range accountIds {
+		records := mapRecords[v]
+		if records == nil {
+			records = make([]Record, 0)
+		}
+		changes = append(changes, Change{BlockNumber: blockNumbers[v], Entity: EntityRecords, Key: v, Value: records})
+	}
+	return changes, nil
+}
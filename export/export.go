@@ -0,0 +1,215 @@
+package export
+
+import (
+	"context"
+	"das-account-indexer/dao"
+	"das-account-indexer/tables"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"github.com/dotbitHQ/das-lib/common"
+	"io"
+	"strconv"
+)
+
+// bulk export of accounts with their records, reverse records and DID cells, served by the api and the export command
+
+const (
+	FormatNdjson = "ndjson"
+	FormatCsv    = "csv"
+
+	batchSize = 500
+)
+
+type Row struct {
+	Account            string                `json:"account"`
+	AccountId          string                `json:"account_id"`
+	ParentAccountId    string                `json:"parent_account_id"`
+	OwnerChainType     common.ChainType      `json:"owner_chain_type"`
+	OwnerAlgorithmId   common.DasAlgorithmId `json:"owner_algorithm_id"`
+	Owner              string                `json:"owner"`
+	ManagerChainType   common.ChainType      `json:"manager_chain_type"`
+	ManagerAlgorithmId common.DasAlgorithmId `json:"manager_algorithm_id"`
+	Manager            string                `json:"manager"`
+	Status             tables.AccountStatus  `json:"status"`
+	RegisteredAt       uint64                `json:"registered_at"`
+	ExpiredAt          uint64                `json:"expired_at"`
+	BlockNumber        uint64                `json:"block_number"`
+	Outpoint           string                `json:"outpoint"`
+	Records            []Record              `json:"records"`
+	ReverseAddresses   []ReverseAddress      `json:"reverse_addresses"` // addresses with a reverse record of the account
+	DidCell            *DidCell              `json:"did_cell"`
+}
+
+type Record struct {
+	Type  string `json:"type"`
+	Key   string `json:"key"`
+	Label string `json:"label"`
+	Value string `json:"value"`
+	Ttl   string `json:"ttl"`
+}
+
+type ReverseAddress struct {
+	ChainType   common.ChainType `json:"chain_type"`
+	Address     string           `json:"address"`
+	BlockNumber uint64           `json:"block_number"`
+}
+
+type DidCell struct {
+	Outpoint     string `json:"outpoint"`
+	Args         string `json:"args"`
+	LockCodeHash string `json:"lock_code_hash"`
+	ExpiredAt    uint64 `json:"expired_at"`
+}
+
+var csvHeader = []string{
+	"account", "account_id", "parent_account_id",
+	"owner_chain_type", "owner_algorithm_id", "owner",
+	"manager_chain_type", "manager_algorithm_id", "manager",
+	"status", "registered_at", "expired_at", "block_number", "outpoint",
+	"records", "reverse_addresses", "did_cell",
+}
+
+// Export writes the accounts matching filter to w, a batch at a time, flush is called after each batch
+// so a stream reaches its reader as it is read from the db
+func Export(ctx context.Context, dbDao *dao.DbDao, w io.Writer, format string, filter dao.ExportFilter, flush func()) (int, error) {
+	var write func(row *Row) error
+	var csvWriter *csv.Writer
+	switch format {
+	case FormatNdjson:
+		enc := json.NewEncoder(w)
+		write = func(row *Row) error { return enc.Encode(row) }
+	case FormatCsv:
+		csvWriter = csv.NewWriter(w)
+		if err := csvWriter.Write(csvHeader); err != nil {
+			return 0, fmt.Errorf("csv Write err: %s", err.Error())
+		}
+		write = func(row *Row) error { return csvWriter.Write(row.csv()) }
+	default:
+		return 0, fmt.Errorf("format invalid: %s", format)
+	}
+
+	var count int
+	var lastId uint64
+	for {
+		if err := ctx.Err(); err != nil {
+			return count, err
+		}
+		list, err := dbDao.FindExportAccounts(filter, lastId, batchSize)
+		if err != nil {
+			return count, fmt.Errorf("FindExportAccounts err: %s", err.Error())
+		} else if len(list) == 0 {
+			break
+		}
+		lastId = list[len(list)-1].Id
+
+		rows, err := exportRows(dbDao, list)
+		if err != nil {
+			return count, err
+		}
+		for i := range rows {
+			if err = write(&rows[i]); err != nil {
+				return count, fmt.Errorf("write err: %s", err.Error())
+			}
+		}
+		count += len(rows)
+		if csvWriter != nil {
+			csvWriter.Flush()
+			if err = csvWriter.Error(); err != nil {
+				return count, fmt.Errorf("csv Flush err: %s", err.Error())
+			}
+		}
+		if flush != nil {
+			flush()
+		}
+		if len(list) < batchSize {
+			break
+		}
+	}
+	return count, nil
+}
+
+func exportRows(dbDao *dao.DbDao, list []tables.TableAccountInfo) ([]Row, error) {
+	var accountIds, accounts []string
+	for _, v := range list {
+		accountIds = append(accountIds, v.AccountId)
+		accounts = append(accounts, v.Account)
+	}
+	records, err := dbDao.FindRecordsByAccountIds(accountIds)
+	if err != nil {
+		return nil, fmt.Errorf("FindRecordsByAccountIds err: %s", err.Error())
+	}
+	reverses, err := dbDao.FindReverseListByAccounts(accounts)
+	if err != nil {
+		return nil, fmt.Errorf("FindReverseListByAccounts err: %s", err.Error())
+	}
+	didCells, err := dbDao.FindDidCellListByAccountIds(accountIds)
+	if err != nil {
+		return nil, fmt.Errorf("FindDidCellListByAccountIds err: %s", err.Error())
+	}
+
+	var mapRecords = make(map[string][]Record)
+	for _, v := range records {
+		mapRecords[v.AccountId] = append(mapRecords[v.AccountId], Record{Type: v.Type, Key: v.Key, Label: v.Label, Value: v.Value, Ttl: v.Ttl})
+	}
+	var mapReverses = make(map[string][]ReverseAddress)
+	for _, v := range reverses {
+		mapReverses[v.Account] = append(mapReverses[v.Account], ReverseAddress{ChainType: v.ChainType, Address: v.Address, BlockNumber: v.BlockNumber})
+	}
+	// the list is by expiry, the first is the current cell
+	var mapDidCells = make(map[string]*DidCell)
+	for _, v := range didCells {
+		if _, ok := mapDidCells[v.AccountId]; !ok {
+			mapDidCells[v.AccountId] = &DidCell{Outpoint: v.Outpoint, Args: v.Args, LockCodeHash: v.LockCodeHash, ExpiredAt: v.ExpiredAt}
+		}
+	}
+
+	rows := make([]Row, 0, len(list))
+	for _, v := range list {
+		row := Row{
+			Account:            v.Account,
+			AccountId:          v.AccountId,
+			ParentAccountId:    v.ParentAccountId,
+			OwnerChainType:     v.OwnerChainType,
+			OwnerAlgorithmId:   v.OwnerAlgorithmId,
+			Owner:              v.Owner,
+			ManagerChainType:   v.ManagerChainType,
+			ManagerAlgorithmId: v.ManagerAlgorithmId,
+			Manager:            v.Manager,
+			Status:             v.Status,
+			RegisteredAt:       v.RegisteredAt,
+			ExpiredAt:          v.ExpiredAt,
+			BlockNumber:        v.BlockNumber,
+			Outpoint:           v.Outpoint,
+			Records:            mapRecords[v.AccountId],
+			ReverseAddresses:   mapReverses[v.Account],
+			DidCell:            mapDidCells[v.AccountId],
+		}
+		if row.Records == nil {
+			row.Records = make([]Record, 0)
+		}
+		if row.ReverseAddresses == nil {
+			row.ReverseAddresses = make([]ReverseAddress, 0)
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// csv keeps the lists as json in their cells
+func (r *Row) csv() []string {
+	records, _ := json.Marshal(r.Records)
+	reverses, _ := json.Marshal(r.ReverseAddresses)
+	var didCell []byte
+	if r.DidCell != nil {
+		didCell, _ = json.Marshal(r.DidCell)
+	}
+	return []string{
+		r.Account, r.AccountId, r.ParentAccountId,
+		strconv.Itoa(int(r.OwnerChainType)), strconv.Itoa(int(r.OwnerAlgorithmId)), r.Owner,
+		strconv.Itoa(int(r.ManagerChainType)), strconv.Itoa(int(r.ManagerAlgorithmId)), r.Manager,
+		strconv.Itoa(int(r.Status)), strconv.FormatUint(r.RegisteredAt, 10), strconv.FormatUint(r.ExpiredAt, 10),
+		strconv.FormatUint(r.BlockNumber, 10), r.Outpoint,
+		string(records), string(reverses), string(didCell),
+	}
+}
@@ -0,0 +1,64 @@
+package http_server
+
+import (
+	"bytes"
+	"das-account-indexer/cache"
+	"das-account-indexer/http_server/handle"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/scorpiotzh/toolib"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+// errNoContent marks responses without a body, such as JSON-RPC notifications, they are neither cached nor rewritten
+var errNoContent = errors.New("no content")
+
+// errNoCache marks responses already written that must not be cached, such as json-rpc calls of uncached methods
+var errNoCache = errors.New("no cache")
+
+func middlewareCache(c cache.Cache, dataExpiration, lockExpiration, updateExpiration time.Duration, respHandle toolib.MiddlewareRespHandle) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		key := toolib.Md5Hash([]byte(ctx.Request.URL.String()))
+		if ctx.Request.Method == http.MethodPost {
+			bodyBytes, _ := ctx.GetRawData()
+			ctx.Request.Body = ioutil.NopCloser(bytes.NewBuffer(bodyBytes))
+			key = toolib.Md5Hash(append([]byte(ctx.Request.URL.String()), bodyBytes...))
+		}
+		cacheHandle := func() (string, error) {
+			blw := &bodyWriter{body: bytes.NewBufferString(""), ResponseWriter: ctx.Writer}
+			ctx.Writer = blw
+			ctx.Next()
+			// no cache failed req
+			if statusCode := ctx.Writer.Status(); statusCode == http.StatusNoContent {
+				return "", errNoContent
+			} else if statusCode != http.StatusOK {
+				return "", fmt.Errorf("status code [%d]", statusCode)
+			}
+			if blw.body.Len() == 0 {
+				return "", fmt.Errorf("body is nil")
+			}
+			if ctx.GetBool(handle.CtxKeyNoCache) {
+				return "", errNoCache
+			}
+			return blw.body.String(), nil
+		}
+		res, err := cache.CacheBy(c, key, dataExpiration, lockExpiration, updateExpiration, cacheHandle)
+		if err == errNoContent || err == errNoCache {
+			return
+		}
+		respHandle(ctx, res, err)
+	}
+}
+
+type bodyWriter struct {
+	gin.ResponseWriter
+	body *bytes.Buffer
+}
+
+func (b bodyWriter) Write(bys []byte) (int, error) {
+	b.body.Write(bys)
+	return b.ResponseWriter.Write(bys)
+}
@@ -1,6 +1,10 @@
 package code
 
-import "encoding/json"
+import (
+	"das-account-indexer/sign"
+	"encoding/json"
+	api_code "github.com/dotbitHQ/das-lib/http_api"
+)
 
 type JsonRequest struct {
 	ID      interface{}     `json:"id"`
@@ -18,3 +22,93 @@ type JsonResponse struct {
 func (j *JsonResponse) ResultData(data interface{}) {
 	j.Result = data
 }
+
+// JSON-RPC 2.0 error codes, used by the strict mode of the indexer endpoint
+const (
+	JsonRpcCodeParseError     = -32700
+	JsonRpcCodeInvalidRequest = -32600
+	JsonRpcCodeMethodNotFound = -32601
+	JsonRpcCodeInvalidParams  = -32602
+	JsonRpcCodeInternalError  = -32603
+	JsonRpcCodeServerError    = -32000
+)
+
+// JsonRpcCall keeps the raw id, so that a missing id (a notification) can be told apart from null
+type JsonRpcCall struct {
+	ID      json.RawMessage `json:"id"`
+	JsonRpc string          `json:"jsonrpc"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params"`
+}
+
+func (j *JsonRpcCall) IsNotification() bool {
+	return len(j.ID) == 0
+}
+
+type JsonRpcError struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+type JsonRpcErrorData struct {
+	ErrNo api_code.ApiCode `json:"err_no"`
+}
+
+type JsonRpcResultResponse struct {
+	JsonRpc   string          `json:"jsonrpc"`
+	ID        interface{}     `json:"id"`
+	Result    interface{}     `json:"result"`
+	Signature *sign.Signature `json:"signature,omitempty"`
+}
+
+type JsonRpcErrorResponse struct {
+	JsonRpc   string          `json:"jsonrpc"`
+	ID        interface{}     `json:"id"`
+	Error     *JsonRpcError   `json:"error"`
+	Signature *sign.Signature `json:"signature,omitempty"`
+}
+
+func NewJsonRpcError(id json.RawMessage, code int, message string) JsonRpcErrorResponse {
+	return JsonRpcErrorResponse{
+		JsonRpc: "2.0",
+		ID:      id,
+		Error:   &JsonRpcError{Code: code, Message: message},
+	}
+}
+
+// NewJsonRpcResponse turns an ApiResp into a JSON-RPC 2.0 result, or an error object carrying the err_no
+func NewJsonRpcResponse(id json.RawMessage, apiResp *api_code.ApiResp) interface{} {
+	if apiResp.ErrNo == api_code.ApiCodeSuccess {
+		return JsonRpcResultResponse{JsonRpc: "2.0", ID: id, Result: apiResp.Data}
+	}
+	resp := NewJsonRpcError(id, ApiCodeToJsonRpcCode(apiResp.ErrNo), apiResp.ErrMsg)
+	resp.Error.Data = JsonRpcErrorData{ErrNo: apiResp.ErrNo}
+	return resp
+}
+
+// NewSignedJsonRpcResponse is NewJsonRpcResponse with the signature of the response next to result or error
+func NewSignedJsonRpcResponse(id json.RawMessage, apiResp *api_code.ApiResp, signature *sign.Signature) interface{} {
+	switch resp := NewJsonRpcResponse(id, apiResp).(type) {
+	case JsonRpcResultResponse:
+		resp.Signature = signature
+		return resp
+	case JsonRpcErrorResponse:
+		resp.Signature = signature
+		return resp
+	default:
+		return resp
+	}
+}
+
+func ApiCodeToJsonRpcCode(errNo api_code.ApiCode) int {
+	switch errNo {
+	case api_code.ApiCodeParamsInvalid:
+		return JsonRpcCodeInvalidParams
+	case api_code.ApiCodeMethodNotExist:
+		return JsonRpcCodeMethodNotFound
+	case api_code.ApiCodeError500, api_code.ApiCodeDbError, api_code.ApiCodeCacheError:
+		return JsonRpcCodeInternalError
+	}
+	return JsonRpcCodeServerError
+}
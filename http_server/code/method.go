@@ -6,21 +6,34 @@ const (
 	MethodVersion    JsonRpcMethod = "das_version"
 	MethodDidNumber  JsonRpcMethod = "das_didNumber"
 	MethodServerInfo JsonRpcMethod = "das_serverInfo"
+	MethodSigningKey JsonRpcMethod = "das_signingKey"
 
 	MethodSearchAccount  JsonRpcMethod = "das_searchAccount"
 	MethodAddressAccount JsonRpcMethod = "das_getAddressAccount"
 
 	MethodAccountInfo           JsonRpcMethod = "das_accountInfo"
 	MethodAccountList           JsonRpcMethod = "das_accountList"
+	MethodExpiringAccountList   JsonRpcMethod = "das_expiringAccountList"
 	MethodAccountRecords        JsonRpcMethod = "das_accountRecords"
+	MethodRecordList            JsonRpcMethod = "das_recordList"
 	MethodBatchAccountRecords   JsonRpcMethod = "das_batchAccountRecords"
+	MethodBatchAccountList      JsonRpcMethod = "das_batchAccountList"
 	MethodAccountRecordsV2      JsonRpcMethod = "das_accountRecordsV2"
 	MethodReverseRecord         JsonRpcMethod = "das_reverseRecord"
 	MethodBatchReverseRecord    JsonRpcMethod = "das_batchReverseRecord"
+	MethodReverseRecordV2       JsonRpcMethod = "das_reverseRecordV2"
+	MethodBatchReverseRecordV2  JsonRpcMethod = "das_batchReverseRecordV2"
 	MethodBatchRegisterInfo     JsonRpcMethod = "das_batchRegisterInfo"
 	MethodAccountReverseAddress JsonRpcMethod = "das_accountReverseAddress"
+	MethodRecordsByValue        JsonRpcMethod = "das_recordsByValue"
+	MethodAccountNameSearch     JsonRpcMethod = "das_accountNameSearch"
+	MethodResolve               JsonRpcMethod = "das_resolve"
+	MethodAccountProfile        JsonRpcMethod = "das_accountProfile"
+	MethodBatchAccountProfile   JsonRpcMethod = "das_batchAccountProfile"
 
 	MethodSubAccountList   JsonRpcMethod = "das_subAccountList"
 	MethodSubAccountVerify JsonRpcMethod = "das_subAccountVerify"
+	MethodSubAccountStats  JsonRpcMethod = "das_subAccountStats"
+	MethodSubAccountTree   JsonRpcMethod = "das_subAccountTree"
 	MethodDidCellList      JsonRpcMethod = "das_didCellList"
 )
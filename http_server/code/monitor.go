@@ -47,3 +47,12 @@ func (b bodyWriter) Write(bys []byte) (int, error) {
 	b.body.Write(bys)
 	return b.ResponseWriter.Write(bys)
 }
+
+// DoMonitorStatus observes the status code only, for responses that are not api json such as proxied content
+func DoMonitorStatus(method string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		startTime := time.Now()
+		ctx.Next()
+		prometheus.Tools.Metrics.Api().WithLabelValues(method, fmt.Sprint(ctx.Writer.Status()), fmt.Sprint(api_code.ApiCodeSuccess), "").Observe(time.Since(startTime).Seconds())
+	}
+}
@@ -0,0 +1,28 @@
+package http_server
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/scorpiotzh/toolib"
+	"net/http"
+)
+
+// middlewareCors is toolib.MiddlewareCors with the api key header allowed and the limit headers
+// exposed, so that browser clients can send a key and read what is left of their limits
+func middlewareCors() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if origin := ctx.GetHeader("Origin"); origin != "" {
+			if toolib.AllowOriginFunc(origin) {
+				ctx.Header("Access-Control-Allow-Origin", origin)
+			}
+			ctx.Header("Access-Control-Allow-Methods", "POST, GET, OPTIONS, PUT, DELETE")
+			ctx.Header("Access-Control-Allow-Headers", "Content-Length,Content-Type,"+headerApiKey)
+			ctx.Header("Access-Control-Expose-Headers", "X-RateLimit-Limit,X-RateLimit-Remaining,X-Quota-Limit,X-Quota-Remaining,X-Quota-Reset,Retry-After")
+			ctx.Header("Access-Control-Allow-Credentials", "true")
+		}
+		if ctx.Request.Method == http.MethodOptions {
+			ctx.AbortWithStatus(http.StatusNoContent)
+		} else {
+			ctx.Next()
+		}
+	}
+}
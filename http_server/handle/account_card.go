@@ -0,0 +1,193 @@
+package handle
+
+import (
+	"bytes"
+	"context"
+	"das-account-indexer/tables"
+	"fmt"
+	"github.com/dotbitHQ/das-lib/common"
+	"github.com/gin-gonic/gin"
+	"github.com/scorpiotzh/toolib"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// profile cards of an account, rendered from indexer data only so the same data always gives the same bytes
+
+const cardMaxAge = 300
+
+type cardTemplate struct {
+	width, height int
+	background    string
+	foreground    string
+	muted         string
+	compact       bool // name and expiry only
+}
+
+var cardTemplates = map[string]cardTemplate{
+	"default": {width: 600, height: 315, background: "#ffffff", foreground: "#11142d", muted: "#6b7280"},
+	"dark":    {width: 600, height: 315, background: "#11142d", foreground: "#ffffff", muted: "#9ca3af"},
+	"compact": {width: 400, height: 120, background: "#ffffff", foreground: "#11142d", muted: "#6b7280", compact: true},
+}
+
+// avatar placeholder colors, picked by account id
+var cardAvatarColors = []string{"#2471fe", "#00c6a4", "#ff6b6b", "#9b5de5", "#f15bb5", "#fb8500", "#3a86ff", "#06d6a0"}
+
+type cardData struct {
+	account     string
+	accountId   string
+	expiredAt   uint64
+	subAccounts int64
+	profile     AccountProfile
+}
+
+// cardShape is a rect, a circle or a text, both renderers draw the same shapes
+type cardShape struct {
+	kind       string // rect, circle, text
+	x, y       int    // top left of a rect, center of a circle, baseline of a text
+	w, h, r    int
+	color      string
+	text       string
+	size       int
+	bold       bool
+	centerText bool
+}
+
+func (h *HttpHandle) AccountCard(ctx *gin.Context) {
+	var (
+		funcName = "AccountCard"
+		clientIp = GetClientIp(ctx)
+		account  = FormatSharpToDot(strings.TrimSpace(ctx.Query("account")))
+		name     = ctx.DefaultQuery("template", "default")
+		png      = strings.HasSuffix(ctx.Request.URL.Path, ".png")
+	)
+	log.Info("ApiReq:", funcName, clientIp, account, name, ctx.Request.Context())
+
+	tpl, ok := cardTemplates[name]
+	if !ok {
+		ctx.String(http.StatusBadRequest, "template invalid")
+		return
+	} else if account == "" || !strings.HasSuffix(account, common.DasAccountSuffix) || strings.ContainsAny(account, " _") {
+		ctx.String(http.StatusBadRequest, "account invalid")
+		return
+	}
+	data, err := h.cardData(ctx.Request.Context(), account)
+	if err != nil {
+		log.Error("cardData err:", err.Error(), funcName, clientIp, ctx.Request.Context())
+		ctx.String(http.StatusInternalServerError, "failed to get account")
+		return
+	} else if data == nil {
+		ctx.String(http.StatusNotFound, "account not exist")
+		return
+	}
+
+	shapes := cardLayout(tpl, data)
+	var buf bytes.Buffer
+	contentType := "image/svg+xml"
+	if png {
+		contentType = "image/png"
+		err = renderCardPng(&buf, tpl, shapes)
+	} else {
+		err = renderCardSvg(&buf, tpl, shapes)
+	}
+	if err != nil {
+		log.Error("render card err:", err.Error(), funcName, ctx.Request.Context())
+		ctx.String(http.StatusInternalServerError, "failed to render card")
+		return
+	}
+
+	etag := fmt.Sprintf(`"%s"`, toolib.Md5Hash(buf.Bytes()))
+	ctx.Header("Cache-Control", fmt.Sprintf("public, max-age=%d", cardMaxAge))
+	ctx.Header("ETag", etag)
+	if ctx.GetHeader("If-None-Match") == etag {
+		ctx.Status(http.StatusNotModified)
+		return
+	}
+	ctx.Data(http.StatusOK, contentType, buf.Bytes())
+}
+
+func (h *HttpHandle) cardData(ctx context.Context, account string) (*cardData, error) {
+	accountId := common.Bytes2Hex(common.GetAccountIdByAccount(account))
+	acc, err := h.DbDao.FindAccountInfoByAccountId(accountId)
+	if err != nil {
+		return nil, fmt.Errorf("FindAccountInfoByAccountId err: %s", err.Error())
+	} else if acc.Id == 0 {
+		return nil, nil
+	}
+	data := cardData{
+		account:   acc.Account,
+		accountId: acc.AccountId,
+		expiredAt: acc.ExpiredAt,
+		profile:   AccountProfile{Socials: make([]ProfileSocial, 0), Addresses: make([]ProfileAddress, 0)},
+	}
+	if acc.Status != tables.AccountStatusOnLock {
+		list, err := h.DbDao.FindAccountRecordsByAccountId(accountId)
+		if err != nil {
+			return nil, fmt.Errorf("FindAccountRecordsByAccountId err: %s", err.Error())
+		}
+		fillAccountProfile(&data.profile, list)
+	}
+	if acc.ParentAccountId == "" {
+		if data.subAccounts, err = h.DbDao.GetSubAccountListCountByParentAccountId(accountId); err != nil {
+			return nil, fmt.Errorf("GetSubAccountListCountByParentAccountId err: %s", err.Error())
+		}
+	}
+	return &data, nil
+}
+
+func cardLayout(tpl cardTemplate, data *cardData) []cardShape {
+	avatarColor := cardAvatarColors[int(common.Hex2Bytes(data.accountId)[0])%len(cardAvatarColors)]
+	initial := strings.ToUpper(string([]rune(data.account)[:1]))
+	info := fmt.Sprintf("Expires %s", time.Unix(int64(data.expiredAt), 0).UTC().Format("2006-01-02"))
+	if data.subAccounts > 0 {
+		info += fmt.Sprintf(" | %d sub-accounts", data.subAccounts)
+	}
+	shapes := []cardShape{{kind: "rect", w: tpl.width, h: tpl.height, color: tpl.background}}
+
+	if tpl.compact {
+		return append(shapes,
+			cardShape{kind: "circle", x: 60, y: 60, r: 36, color: avatarColor},
+			cardShape{kind: "text", x: 60, y: 74, text: initial, size: 39, bold: true, color: "#ffffff", centerText: true},
+			cardTextShape(115, 58, tpl.width, data.account, 26, true, tpl.foreground),
+			cardTextShape(115, 88, tpl.width, info, 13, false, tpl.muted),
+		)
+	}
+
+	shapes = append(shapes,
+		cardShape{kind: "circle", x: 90, y: 110, r: 50, color: avatarColor},
+		cardShape{kind: "text", x: 90, y: 127, text: initial, size: 52, bold: true, color: "#ffffff", centerText: true},
+		cardTextShape(170, 105, tpl.width, data.account, 39, true, tpl.foreground),
+		cardTextShape(170, 140, tpl.width, info, 13, false, tpl.muted),
+	)
+	// key records, at most four lines
+	var lines []string
+	if p := data.profile; p.Description != "" {
+		lines = append(lines, p.Description)
+	}
+	if p := data.profile; p.Website != "" {
+		lines = append(lines, "website: "+p.Website)
+	}
+	for _, v := range data.profile.Socials {
+		lines = append(lines, fmt.Sprintf("%s: %s", v.Platform, v.Handle))
+	}
+	for _, v := range data.profile.Addresses {
+		lines = append(lines, fmt.Sprintf("%s: %s", v.Chain, v.Address))
+	}
+	for i, v := range lines {
+		if i == 4 {
+			break
+		}
+		shapes = append(shapes, cardTextShape(40, 205+i*26, tpl.width, v, 13, false, tpl.foreground))
+	}
+	return shapes
+}
+
+// cardTextShape cuts text that would run past the right margin, at about 0.6em per char
+func cardTextShape(x, y, width int, text string, size int, bold bool, color string) cardShape {
+	runes := []rune(text)
+	if maxChars := (width - x - 20) * 10 / (size * 6); len(runes) > maxChars && maxChars > 3 {
+		text = string(runes[:maxChars-3]) + "..."
+	}
+	return cardShape{kind: "text", x: x, y: y, text: text, size: size, bold: bold, color: color}
+}
@@ -0,0 +1,112 @@
+package handle
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"strconv"
+	"strings"
+)
+
+func renderCardSvg(w io.Writer, tpl cardTemplate, shapes []cardShape) error {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`, tpl.width, tpl.height, tpl.width, tpl.height)
+	for _, s := range shapes {
+		switch s.kind {
+		case "rect":
+			fmt.Fprintf(&buf, `<rect x="%d" y="%d" width="%d" height="%d" fill="%s"/>`, s.x, s.y, s.w, s.h, s.color)
+		case "circle":
+			fmt.Fprintf(&buf, `<circle cx="%d" cy="%d" r="%d" fill="%s"/>`, s.x, s.y, s.r, s.color)
+		case "text":
+			var attrs string
+			if s.bold {
+				attrs += ` font-weight="bold"`
+			}
+			if s.centerText {
+				attrs += ` text-anchor="middle"`
+			}
+			fmt.Fprintf(&buf, `<text x="%d" y="%d" font-family="Helvetica, Arial, sans-serif" font-size="%d" fill="%s"%s>`, s.x, s.y, s.size, s.color, attrs)
+			if err := xml.EscapeText(&buf, []byte(s.text)); err != nil {
+				return fmt.Errorf("xml.EscapeText err: %s", err.Error())
+			}
+			buf.WriteString(`</text>`)
+		}
+	}
+	buf.WriteString(`</svg>`)
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// renderCardPng rasterizes the shapes with the 7x13 bitmap font scaled to the text size,
+// glyphs missing from the font are drawn as boxes
+func renderCardPng(w io.Writer, tpl cardTemplate, shapes []cardShape) error {
+	img := image.NewRGBA(image.Rect(0, 0, tpl.width, tpl.height))
+	for _, s := range shapes {
+		c, err := parseHexColor(s.color)
+		if err != nil {
+			return err
+		}
+		switch s.kind {
+		case "rect":
+			draw.Draw(img, image.Rect(s.x, s.y, s.x+s.w, s.y+s.h), image.NewUniform(c), image.Point{}, draw.Src)
+		case "circle":
+			for y := s.y - s.r; y <= s.y+s.r; y++ {
+				for x := s.x - s.r; x <= s.x+s.r; x++ {
+					if (x-s.x)*(x-s.x)+(y-s.y)*(y-s.y) <= s.r*s.r {
+						img.Set(x, y, c)
+					}
+				}
+			}
+		case "text":
+			drawCardText(img, s, c)
+		}
+	}
+	return png.Encode(w, img)
+}
+
+func drawCardText(img *image.RGBA, s cardShape, c color.Color) {
+	face := basicfont.Face7x13
+	scale := (s.size + 6) / face.Height
+	if scale < 1 {
+		scale = 1
+	}
+	width := font.MeasureString(face, s.text).Ceil() + 1
+	mask := image.NewAlpha(image.Rect(0, 0, width, face.Height))
+	d := font.Drawer{Dst: mask, Src: image.Opaque, Face: face, Dot: fixed.P(0, face.Ascent)}
+	d.DrawString(s.text)
+	if s.bold {
+		d.Dot = fixed.P(1, face.Ascent)
+		d.DrawString(s.text)
+	}
+
+	left, top := s.x, s.y-face.Ascent*scale
+	if s.centerText {
+		left -= width * scale / 2
+	}
+	src := image.NewUniform(c)
+	for y := 0; y < face.Height; y++ {
+		for x := 0; x < width; x++ {
+			if mask.AlphaAt(x, y).A == 0 {
+				continue
+			}
+			r := image.Rect(left+x*scale, top+y*scale, left+(x+1)*scale, top+(y+1)*scale)
+			draw.Draw(img, r, src, image.Point{}, draw.Over)
+		}
+	}
+}
+
+func parseHexColor(s string) (color.RGBA, error) {
+	v, err := strconv.ParseUint(strings.TrimPrefix(s, "#"), 16, 32)
+	if err != nil || len(s) != 7 {
+		return color.RGBA{}, fmt.Errorf("color invalid: %s", s)
+	}
+	return color.RGBA{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v), A: 0xff}, nil
+}
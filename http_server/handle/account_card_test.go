@@ -0,0 +1,136 @@
+package handle
+
+import (
+	"bytes"
+	"das-account-indexer/config"
+	"das-account-indexer/dao"
+	"das-account-indexer/tables"
+	"github.com/dotbitHQ/das-lib/common"
+	"github.com/gin-gonic/gin"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+func testCardData() *cardData {
+	return &cardData{
+		account:     "phone.bit",
+		accountId:   common.Bytes2Hex(common.GetAccountIdByAccount("phone.bit")),
+		expiredAt:   1753185972,
+		subAccounts: 12,
+		profile: AccountProfile{
+			Description: "a <card> & its records",
+			Website:     "https://example.com",
+			Socials:     []ProfileSocial{{Platform: "twitter", Handle: "phone"}},
+			Addresses:   []ProfileAddress{{Chain: "ETH", Address: "0xc9f53b1d85356b60453f867610888d89a0b667ad"}},
+		},
+	}
+}
+
+// the same data gives the same bytes in every template, so the ETag holds across servers and restarts
+func TestCardRender(t *testing.T) {
+	for name, tpl := range cardTemplates {
+		for _, render := range []struct {
+			format string
+			fn     func(io.Writer, cardTemplate, []cardShape) error
+		}{
+			{"svg", renderCardSvg},
+			{"png", renderCardPng},
+		} {
+			var first, second bytes.Buffer
+			if err := render.fn(&first, tpl, cardLayout(tpl, testCardData())); err != nil {
+				t.Fatal(name, render.format, err)
+			} else if err = render.fn(&second, tpl, cardLayout(tpl, testCardData())); err != nil {
+				t.Fatal(name, render.format, err)
+			}
+			if first.Len() == 0 || !bytes.Equal(first.Bytes(), second.Bytes()) {
+				t.Fatal(name, render.format, "rendered differently:", first.Len(), second.Len())
+			}
+		}
+	}
+}
+
+// the handler against the mysql of DAS_TEST_MYSQL_ADDR: two requests give the same bytes and ETag,
+// a request with that ETag gets 304 and an account not in t_account_info 404
+func TestAccountCard(t *testing.T) {
+	addr := os.Getenv("DAS_TEST_MYSQL_ADDR")
+	if addr == "" {
+		t.Skip("DAS_TEST_MYSQL_ADDR not set")
+	}
+	dbDao, err := dao.NewGormDB(config.DbMysql{
+		Addr:        addr,
+		User:        os.Getenv("DAS_TEST_MYSQL_USER"),
+		Password:    os.Getenv("DAS_TEST_MYSQL_PASSWORD"),
+		DbName:      os.Getenv("DAS_TEST_MYSQL_DB_NAME"),
+		MaxOpenConn: 5,
+		MaxIdleConn: 2,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	const account = "test4100card.bit"
+	accountId := common.Bytes2Hex(common.GetAccountIdByAccount(account))
+	cleanUp := func() {
+		if err := dbDao.DelSubAccounts([]string{accountId}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cleanUp()
+	defer cleanUp()
+	if err = dbDao.UpdateAccountInfo(&tables.TableAccountInfo{
+		AccountId: accountId,
+		Account:   account,
+		Owner:     "0xc9f53b1d85356b60453f867610888d89a0b667ad",
+		ExpiredAt: 1753185972,
+	}, []tables.TableRecordsInfo{
+		{AccountId: accountId, Account: account, Type: "profile", Key: "description", Value: "a card"},
+		{AccountId: accountId, Account: account, Type: "address", Key: "60", Value: "0xc9f53b1d85356b60453f867610888d89a0b667ad"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	h := &HttpHandle{DbDao: dbDao}
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.GET("/v1/account/card.svg", h.AccountCard)
+	engine.GET("/v1/account/card.png", h.AccountCard)
+	serve := func(path, etag string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		return w
+	}
+
+	for _, path := range []string{
+		"/v1/account/card.svg?account=" + account,
+		"/v1/account/card.png?account=" + account + "&template=dark",
+	} {
+		first, second := serve(path, ""), serve(path, "")
+		if first.Code != http.StatusOK || second.Code != http.StatusOK {
+			t.Fatal(path, "status:", first.Code, second.Code, first.Body.String())
+		}
+		etag := first.Header().Get("ETag")
+		if etag == "" || etag != second.Header().Get("ETag") {
+			t.Fatal(path, "etag:", etag, second.Header().Get("ETag"))
+		} else if !bytes.Equal(first.Body.Bytes(), second.Body.Bytes()) {
+			t.Fatal(path, "rendered differently")
+		}
+		if w := serve(path, etag); w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+			t.Fatal(path, "if-none-match:", w.Code, w.Body.Len())
+		}
+	}
+
+	for _, path := range []string{
+		"/v1/account/card.svg?account=test4100missing.bit",
+		"/v1/account/card.png?account=test4100missing.bit",
+	} {
+		if w := serve(path, ""); w.Code != http.StatusNotFound {
+			t.Fatal(path, "status:", w.Code, w.Body.String())
+		}
+	}
+}
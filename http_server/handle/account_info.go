@@ -4,27 +4,26 @@ import (
 	"context"
 	"das-account-indexer/config"
 	"das-account-indexer/tables"
-	"encoding/json"
 	"fmt"
 	"github.com/dotbitHQ/das-lib/common"
 	"github.com/dotbitHQ/das-lib/core"
 	"github.com/dotbitHQ/das-lib/http_api"
-	"github.com/gin-gonic/gin"
 	"github.com/nervosnetwork/ckb-sdk-go/address"
 	"github.com/nervosnetwork/ckb-sdk-go/types"
-	"github.com/scorpiotzh/toolib"
-	"net/http"
 	"strings"
 )
 
 type ReqAccountInfo struct {
 	Account   string `json:"account"`
 	AccountId string `json:"account_id"`
+	WithProof bool   `json:"with_proof"`
+	AsOfBlock uint64 `json:"as_of_block"` // the account at the end of this block, 0 for now
 }
 
 type RespAccountInfo struct {
 	OutPoint    *types.OutPoint `json:"out_point"`
 	AccountInfo AccountInfo     `json:"account_info"`
+	Proof       *CellProof      `json:"proof,omitempty"` // of the account cell, with with_proof
 }
 
 type AccountInfo struct {
@@ -46,52 +45,12 @@ type AccountInfo struct {
 	DisplayName        string                   `json:"display_name"`
 }
 
-func (h *HttpHandle) JsonRpcAccountInfo(p json.RawMessage, apiResp *http_api.ApiResp) {
-	var req []ReqAccountInfo
-	err := json.Unmarshal(p, &req)
-	if err != nil {
-		log.Error("json.Unmarshal err:", err.Error())
-		apiResp.ApiRespErr(http_api.ApiCodeParamsInvalid, "params invalid")
-		return
-	}
-	if len(req) != 1 {
-		log.Error("len(req) is:", len(req))
-		apiResp.ApiRespErr(http_api.ApiCodeParamsInvalid, "params invalid")
-		return
-	}
-
-	if err = h.doAccountInfo(h.Ctx, &req[0], apiResp); err != nil {
-		log.Error("doAccountInfo err:", err.Error())
-	}
-}
-
-func (h *HttpHandle) AccountInfo(ctx *gin.Context) {
-	var (
-		funcName = "AccountInfo"
-		req      ReqAccountInfo
-		apiResp  http_api.ApiResp
-		err      error
-		clientIp = GetClientIp(ctx)
-	)
-
-	if err := ctx.ShouldBindJSON(&req); err != nil {
-		log.Error("ShouldBindJSON err: ", err.Error(), funcName, ctx.Request.Context())
-		apiResp.ApiRespErr(http_api.ApiCodeParamsInvalid, "params invalid")
-		ctx.JSON(http.StatusOK, apiResp)
-		return
-	}
-	log.Info("ApiReq:", ctx.Request.Host, funcName, clientIp, toolib.JsonString(req), ctx.Request.Context())
-
-	if err = h.doAccountInfo(ctx.Request.Context(), &req, &apiResp); err != nil {
-		log.Error("doAccountInfo err:", err.Error(), funcName, ctx.Request.Context())
-	}
-
-	ctx.JSON(http.StatusOK, apiResp)
-}
-
 func (h *HttpHandle) doAccountInfo(ctx context.Context, req *ReqAccountInfo, apiResp *http_api.ApiResp) error {
 	var resp RespAccountInfo
 
+	if ok, err := h.checkAsOfBlock(req.AsOfBlock, apiResp); !ok {
+		return err
+	}
 	accountId := req.AccountId
 	if accountId == "" {
 		req.Account = strings.TrimSpace(req.Account)
@@ -102,7 +61,7 @@ func (h *HttpHandle) doAccountInfo(ctx context.Context, req *ReqAccountInfo, api
 		}
 		accountId = common.Bytes2Hex(common.GetAccountIdByAccount(req.Account))
 	}
-	accountInfo, err := h.DbDao.FindAccountInfoByAccountId(accountId)
+	accountInfo, err := h.findAccountInfoAsOf(accountId, req.AsOfBlock)
 	if err != nil {
 		log.Error(ctx, "FindAccountInfoByAccountName err:", err.Error(), req.Account)
 		apiResp.ApiRespErr(http_api.ApiCodeDbError, "find account info err")
@@ -133,7 +92,7 @@ func (h *HttpHandle) doAccountInfo(ctx context.Context, req *ReqAccountInfo, api
 	}
 
 	if accountInfo.Status == tables.AccountStatusOnUpgrade {
-		didCell, err := h.DbDao.GetDidCellByAccountId(accountId)
+		didCell, err := h.getDidCellAsOf(accountId, req.AsOfBlock)
 		if err != nil {
 			apiResp.ApiRespErr(http_api.ApiCodeDbError, "find did cell info err")
 			return fmt.Errorf("GetDidCellByAccountId err: %s", err.Error())
@@ -194,6 +153,22 @@ func (h *HttpHandle) doAccountInfo(ctx context.Context, req *ReqAccountInfo, api
 		resp.AccountInfo.ManagerSubAid = accountInfo.ManagerSubAid
 	}
 
+	if req.WithProof {
+		// a sub-account lives in the smt of its parent's sub-account cell, its outpoint is only the transaction
+		// of its last change, and the owner of an upgraded account is in its DID cell, not the account cell
+		if accountInfo.ParentAccountId != "" {
+			apiResp.ApiRespErr(http_api.ApiCodeParamsInvalid, "with_proof is not supported for sub-accounts")
+			return nil
+		} else if accountInfo.Status == tables.AccountStatusOnUpgrade {
+			apiResp.ApiRespErr(http_api.ApiCodeParamsInvalid, "with_proof is not supported for accounts upgraded to DID cells")
+			return nil
+		}
+		if resp.Proof, err = h.getCellProof(ctx, accountInfo.Outpoint); err != nil {
+			apiResp.ApiRespErr(http_api.ApiCodeError500, "get proof err")
+			return fmt.Errorf("getCellProof err: %s", err.Error())
+		}
+	}
+
 	apiResp.ApiRespOK(resp)
 	return nil
 }
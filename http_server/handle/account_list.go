@@ -3,14 +3,10 @@ package handle
 import (
 	"context"
 	"das-account-indexer/tables"
-	"encoding/json"
 	"fmt"
 	"github.com/dotbitHQ/das-lib/common"
 	"github.com/dotbitHQ/das-lib/core"
 	"github.com/dotbitHQ/das-lib/http_api"
-	"github.com/gin-gonic/gin"
-	"github.com/scorpiotzh/toolib"
-	"net/http"
 )
 
 type ReqAccountList struct {
@@ -22,6 +18,7 @@ type ReqAccountList struct {
 type RespAccountList struct {
 	Total       int64                `json:"total"`
 	AccountList []RespAddressAccount `json:"account_list"`
+	NextCursor  string               `json:"next_cursor"`
 }
 
 type RespAddressAccount struct {
@@ -32,48 +29,6 @@ type RespAddressAccount struct {
 	ExpiredAt    uint64 `json:"expired_at"`
 }
 
-func (h *HttpHandle) JsonRpcAccountList(p json.RawMessage, apiResp *http_api.ApiResp) {
-	var req []ReqAccountList
-	err := json.Unmarshal(p, &req)
-	if err != nil {
-		log.Error("json.Unmarshal err:", err.Error())
-		apiResp.ApiRespErr(http_api.ApiCodeParamsInvalid, "params invalid")
-		return
-	}
-	if len(req) != 1 {
-		log.Error("len(req) is :", len(req))
-		apiResp.ApiRespErr(http_api.ApiCodeParamsInvalid, "params invalid")
-		return
-	}
-
-	if err = h.doAccountList(h.Ctx, &req[0], apiResp); err != nil {
-		log.Error("doAccountList err:", err.Error())
-	}
-}
-
-func (h *HttpHandle) AccountList(ctx *gin.Context) {
-	var (
-		funcName = "AccountList"
-		req      ReqAccountList
-		apiResp  http_api.ApiResp
-		err      error
-	)
-
-	if err := ctx.ShouldBindJSON(&req); err != nil {
-		log.Error("ShouldBindJSON err: ", err.Error(), funcName, ctx.Request.Context())
-		apiResp.ApiRespErr(http_api.ApiCodeParamsInvalid, "params invalid")
-		ctx.JSON(http.StatusOK, apiResp)
-		return
-	}
-	log.Info("ApiReq:", funcName, toolib.JsonString(req))
-
-	if err = h.doAccountList(ctx.Request.Context(), &req, &apiResp); err != nil {
-		log.Error("doAccountList err:", err.Error(), funcName, ctx.Request.Context())
-	}
-
-	ctx.JSON(http.StatusOK, apiResp)
-}
-
 func (h *HttpHandle) doAccountList(ctx context.Context, req *ReqAccountList, apiResp *http_api.ApiResp) error {
 	var resp RespAccountList
 	resp.AccountList = make([]RespAddressAccount, 0)
@@ -90,9 +45,14 @@ func (h *HttpHandle) doAccountList(ctx context.Context, req *ReqAccountList, api
 		return fmt.Errorf("FormatChainTypeAddress err: %s", err.Error())
 	}
 	log.Info(ctx, "doAccountList:", addrHex.ChainType, addrHex.AddressHex)
+	cursor, err := req.GetCursor()
+	if err != nil {
+		apiResp.ApiRespErr(http_api.ApiCodeParamsInvalid, "cursor invalid")
+		return nil
+	}
 
 	if addrHex.DasAlgorithmId == common.DasAlgorithmIdAnyLock {
-		didCells, err := h.DbDao.QueryDidCell(addrHex.AddressHex, tables.DidCellStatusNormal, req.GetLimit(), req.GetOffset())
+		didCells, err := h.DbDao.QueryDidCell(addrHex.AddressHex, tables.DidCellStatusNormal, cursor, req.GetLimit(), req.GetOffset())
 		if err != nil {
 			apiResp.ApiRespErr(http_api.ApiCodeDbError, "find did cell list err")
 			return fmt.Errorf("QueryDidCell err: %s", err.Error())
@@ -109,6 +69,9 @@ func (h *HttpHandle) doAccountList(ctx context.Context, req *ReqAccountList, api
 			resp.AccountList = append(resp.AccountList, tmp)
 			accIds = append(accIds, v.AccountId)
 		}
+		if l := len(didCells); l > 0 {
+			resp.NextCursor = req.NextCursor(l, didCells[l-1].Account, didCells[l-1].Id)
+		}
 		accs, err := h.DbDao.GetAccountByAccIds(accIds)
 		if err != nil {
 			apiResp.ApiRespErr(http_api.ApiCodeDbError, "find account list err")
@@ -131,7 +94,7 @@ func (h *HttpHandle) doAccountList(ctx context.Context, req *ReqAccountList, api
 		}
 		resp.Total = total
 	} else {
-		list, err := h.DbDao.FindAccountNameListByAddress(addrHex.ChainType, addrHex.AddressHex, req.Role, req.GetLimit(), req.GetOffset())
+		list, err := h.DbDao.FindAccountNameListByAddress(addrHex.ChainType, addrHex.AddressHex, req.Role, cursor, req.GetLimit(), req.GetOffset())
 		if err != nil {
 			log.Error(ctx, "FindAccountListByAddress err:", err.Error(), req.KeyInfo)
 			apiResp.ApiRespErr(http_api.ApiCodeDbError, "find account list err")
@@ -147,6 +110,9 @@ func (h *HttpHandle) doAccountList(ctx context.Context, req *ReqAccountList, api
 			}
 			resp.AccountList = append(resp.AccountList, tmp)
 		}
+		if l := len(list); l > 0 {
+			resp.NextCursor = req.NextCursor(l, list[l-1].Account, list[l-1].Id)
+		}
 		total, err := h.DbDao.FindTotalAccountNameListByAddress(addrHex.ChainType, addrHex.AddressHex, req.Role)
 		if err != nil {
 			apiResp.ApiRespErr(http_api.ApiCodeDbError, "search account total err")
@@ -0,0 +1,129 @@
+package handle
+
+import (
+	"context"
+	"das-account-indexer/dao"
+	"fmt"
+	"github.com/dotbitHQ/das-lib/common"
+	"github.com/dotbitHQ/das-lib/http_api"
+	"github.com/scorpiotzh/toolib"
+	"sort"
+	"strings"
+)
+
+type ReqAccountNameSearch struct {
+	Keyword          string   `json:"keyword"`
+	Mode             string   `json:"mode"` // prefix (default), contains, suffix
+	MinLength        uint32   `json:"min_length"`
+	MaxLength        uint32   `json:"max_length"`
+	CharSets         []string `json:"char_sets"` // Emoji, Digit, En, ZhHans, ZhHant, Ja, Ko, Ru, Tr, Th, Vi
+	RegisteredAfter  uint64   `json:"registered_after"`
+	RegisteredBefore uint64   `json:"registered_before"`
+	ExpiredAfter     uint64   `json:"expired_after"`
+	ExpiredBefore    uint64   `json:"expired_before"`
+	SubAccount       bool     `json:"sub_account"`
+	Pagination
+}
+
+type RespAccountNameSearch struct {
+	Total      int64                   `json:"total"`
+	List       []AccountNameSearchItem `json:"list"`
+	NextCursor string                  `json:"next_cursor"`
+}
+
+type AccountNameSearchItem struct {
+	AccountId     string   `json:"account_id"`
+	Account       string   `json:"account"`
+	DisplayName   string   `json:"display_name"`
+	AccountLength uint32   `json:"account_length"`
+	CharSets      []string `json:"char_sets"`
+	RegisteredAt  uint64   `json:"registered_at"`
+	ExpiredAt     uint64   `json:"expired_at"`
+}
+
+func (h *HttpHandle) doAccountNameSearch(ctx context.Context, req *ReqAccountNameSearch, apiResp *http_api.ApiResp) error {
+	var resp RespAccountNameSearch
+	resp.List = make([]AccountNameSearchItem, 0)
+
+	filter := dao.AccountNameFilter{
+		Keyword:          strings.ToLower(strings.TrimSpace(req.Keyword)),
+		Mode:             req.Mode,
+		MinLength:        req.MinLength,
+		MaxLength:        req.MaxLength,
+		RegisteredAfter:  req.RegisteredAfter,
+		RegisteredBefore: req.RegisteredBefore,
+		ExpiredAfter:     req.ExpiredAfter,
+		ExpiredBefore:    req.ExpiredBefore,
+		SubAccount:       req.SubAccount,
+	}
+	switch filter.Mode {
+	case "":
+		filter.Mode = dao.AccountNameSearchModePrefix
+	case dao.AccountNameSearchModePrefix, dao.AccountNameSearchModeContains, dao.AccountNameSearchModeSuffix:
+	default:
+		apiResp.ApiRespErr(http_api.ApiCodeParamsInvalid, "mode invalid")
+		return nil
+	}
+	if filter.MaxLength > 0 && filter.MinLength > filter.MaxLength {
+		apiResp.ApiRespErr(http_api.ApiCodeParamsInvalid, "length invalid")
+		return nil
+	}
+	for _, v := range req.CharSets {
+		charType, ok := common.AccountCharTypeNameMap[v]
+		if !ok {
+			apiResp.ApiRespErr(http_api.ApiCodeParamsInvalid, fmt.Sprintf("char set [%s] invalid", v))
+			return nil
+		}
+		filter.CharSet |= common.AccountCharTypeToUint64(charType)
+	}
+	if filter.Keyword == "" && filter.MinLength == 0 && filter.MaxLength == 0 && filter.CharSet == 0 {
+		apiResp.ApiRespErr(http_api.ApiCodeParamsInvalid, "params invalid")
+		return nil
+	}
+	log.Info(ctx, "doAccountNameSearch:", toolib.JsonString(filter))
+
+	cursor, err := req.GetCursor()
+	if err != nil {
+		apiResp.ApiRespErr(http_api.ApiCodeParamsInvalid, "cursor invalid")
+		return nil
+	}
+	list, err := h.DbDao.SearchAccountName(filter, cursor, req.GetLimit(), req.GetOffset())
+	if err != nil {
+		apiResp.ApiRespErr(http_api.ApiCodeDbError, "search account err")
+		return fmt.Errorf("SearchAccountName err: %s", err.Error())
+	}
+	total, err := h.DbDao.SearchAccountNameTotal(filter)
+	if err != nil {
+		apiResp.ApiRespErr(http_api.ApiCodeDbError, "search account total err")
+		return fmt.Errorf("SearchAccountNameTotal err: %s", err.Error())
+	}
+	resp.Total = total
+	for _, v := range list {
+		resp.List = append(resp.List, AccountNameSearchItem{
+			AccountId:     v.AccountId,
+			Account:       v.Account,
+			DisplayName:   FormatDisplayName(v.Account),
+			AccountLength: v.AccountLength,
+			CharSets:      charSetNames(v.CharSet),
+			RegisteredAt:  v.RegisteredAt,
+			ExpiredAt:     v.ExpiredAt,
+		})
+	}
+	if l := len(list); l > 0 {
+		resp.NextCursor = req.NextCursor(l, list[l-1].Account, list[l-1].Id)
+	}
+
+	apiResp.ApiRespOK(resp)
+	return nil
+}
+
+func charSetNames(charSet uint64) []string {
+	names := make([]string, 0)
+	for name, charType := range common.AccountCharTypeNameMap {
+		if charSet&common.AccountCharTypeToUint64(charType) > 0 {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
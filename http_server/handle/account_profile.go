@@ -0,0 +1,261 @@
+package handle
+
+import (
+	"context"
+	"das-account-indexer/tables"
+	"fmt"
+	"github.com/dotbitHQ/das-lib/common"
+	"github.com/dotbitHQ/das-lib/http_api"
+	"net/url"
+	"strings"
+)
+
+type ReqAccountProfile struct {
+	Account string `json:"account" binding:"required"`
+}
+
+type ReqBatchAccountProfile struct {
+	Accounts []string `json:"accounts" binding:"required,max=100"`
+}
+
+type RespBatchAccountProfile struct {
+	List []AccountProfile `json:"list"`
+}
+
+type AccountProfile struct {
+	Account     string           `json:"account"`
+	AccountId   string           `json:"account_id"`
+	DisplayName string           `json:"display_name"`
+	Avatar      string           `json:"avatar"`
+	Description string           `json:"description"`
+	Website     string           `json:"website"`
+	Email       string           `json:"email"`
+	Socials     []ProfileSocial  `json:"socials"`
+	Addresses   []ProfileAddress `json:"addresses"` // the first address record of each coin type
+	ErrMsg      string           `json:"err_msg,omitempty"`
+}
+
+type ProfileSocial struct {
+	Platform string `json:"platform"`
+	Handle   string `json:"handle"`
+	Url      string `json:"url"` // empty when the platform has no profile page by handle
+}
+
+type ProfileAddress struct {
+	CoinType common.CoinType `json:"coin_type"`
+	Chain    string          `json:"chain"`
+	Address  string          `json:"address"`
+}
+
+const (
+	profileErrNotExist = "account not exist"
+	profileErrOnLock   = "account cross-chain"
+)
+
+// profile pages of the social records, by handle
+var profileSocialUrls = map[string]string{
+	"twitter":   "https://twitter.com/%s",
+	"github":    "https://github.com/%s",
+	"telegram":  "https://t.me/%s",
+	"facebook":  "https://www.facebook.com/%s",
+	"instagram": "https://www.instagram.com/%s",
+	"reddit":    "https://www.reddit.com/user/%s",
+	"linkedin":  "https://www.linkedin.com/in/%s",
+	"medium":    "https://medium.com/@%s",
+	"youtube":   "https://www.youtube.com/@%s",
+	"tiktok":    "https://www.tiktok.com/@%s",
+	"dribbble":  "https://dribbble.com/%s",
+	"behance":   "https://www.behance.net/%s",
+	"weibo":     "https://weibo.com/%s",
+	"bilibili":  "https://space.bilibili.com/%s",
+	"discord":   "",
+	"nostr":     "",
+	"mastodon":  "",
+}
+
+func (h *HttpHandle) doAccountProfile(ctx context.Context, req *ReqAccountProfile, apiResp *http_api.ApiResp) error {
+	var batch = ReqBatchAccountProfile{Accounts: []string{req.Account}}
+	if err := h.doBatchAccountProfile(ctx, &batch, apiResp); err != nil || apiResp.ErrNo != http_api.ApiCodeSuccess {
+		return err
+	}
+	profile := apiResp.Data.(RespBatchAccountProfile).List[0]
+	switch profile.ErrMsg {
+	case "":
+		apiResp.ApiRespOK(profile)
+	case profileErrNotExist:
+		apiResp.ApiRespErr(http_api.ApiCodeAccountNotExist, "account not exist")
+	default:
+		apiResp.ApiRespErr(http_api.ApiCodeAccountOnLock, "account cross-chain")
+	}
+	return nil
+}
+
+func (h *HttpHandle) doBatchAccountProfile(ctx context.Context, req *ReqBatchAccountProfile, apiResp *http_api.ApiResp) error {
+	var resp RespBatchAccountProfile
+	resp.List = make([]AccountProfile, 0)
+
+	if count := len(req.Accounts); count == 0 || count > 100 {
+		apiResp.ApiRespErr(http_api.ApiCodeParamsInvalid, "Invalid number of accounts")
+		return nil
+	}
+
+	var accountIds []string
+	for _, v := range req.Accounts {
+		account := FormatSharpToDot(strings.TrimSpace(v))
+		if err := checkAccount(account, apiResp); err != nil {
+			log.Error(ctx, "checkAccount err: ", err.Error(), v)
+			return nil
+		}
+		accountId := common.Bytes2Hex(common.GetAccountIdByAccount(account))
+		accountIds = append(accountIds, accountId)
+		resp.List = append(resp.List, AccountProfile{
+			Account:     account,
+			AccountId:   accountId,
+			DisplayName: FormatDisplayName(account),
+			Socials:     make([]ProfileSocial, 0),
+			Addresses:   make([]ProfileAddress, 0),
+		})
+	}
+
+	list, err := h.DbDao.FindAccountInfoListByAccountIds(accountIds)
+	if err != nil {
+		apiResp.ApiRespErr(http_api.ApiCodeDbError, "find accounts err")
+		return fmt.Errorf("FindAccountInfoListByAccountIds err: %s", err.Error())
+	}
+	var mapAcc = make(map[string]tables.TableAccountInfo)
+	for i, v := range list {
+		mapAcc[v.AccountId] = list[i]
+	}
+
+	var okIds []string
+	for i, v := range resp.List {
+		if acc, ok := mapAcc[v.AccountId]; !ok {
+			resp.List[i].ErrMsg = profileErrNotExist
+		} else if acc.Status == tables.AccountStatusOnLock {
+			resp.List[i].ErrMsg = profileErrOnLock
+		} else {
+			okIds = append(okIds, v.AccountId)
+		}
+	}
+	if len(okIds) == 0 {
+		apiResp.ApiRespOK(resp)
+		return nil
+	}
+
+	records, err := h.DbDao.FindRecordsByAccountIds(okIds)
+	if err != nil {
+		apiResp.ApiRespErr(http_api.ApiCodeDbError, "find records err")
+		return fmt.Errorf("FindRecordsByAccountIds err: %s", err.Error())
+	}
+	var mapRecords = make(map[string][]tables.TableRecordsInfo)
+	for _, v := range records {
+		mapRecords[v.AccountId] = append(mapRecords[v.AccountId], v)
+	}
+	for i, v := range resp.List {
+		if rs, ok := mapRecords[v.AccountId]; ok {
+			fillAccountProfile(&resp.List[i], rs)
+		}
+	}
+
+	apiResp.ApiRespOK(resp)
+	return nil
+}
+
+// fillAccountProfile sets the profile fields from the records, the first record of a key wins
+func fillAccountProfile(profile *AccountProfile, list []tables.TableRecordsInfo) {
+	var mapCoinType = make(map[common.CoinType]struct{})
+	var mapSocial = make(map[string]struct{})
+	for _, v := range list {
+		value := strings.TrimSpace(v.Value)
+		if value == "" {
+			continue
+		}
+		switch v.Type {
+		case "profile":
+			switch v.Key {
+			case "avatar":
+				profile.Avatar = firstNonEmpty(profile.Avatar, value)
+			case "description":
+				profile.Description = firstNonEmpty(profile.Description, value)
+			case "website":
+				profile.Website = firstNonEmpty(profile.Website, value)
+			case "email":
+				profile.Email = firstNonEmpty(profile.Email, value)
+			default:
+				urlFormat, ok := profileSocialUrls[v.Key]
+				if _, dup := mapSocial[v.Key]; !ok || dup {
+					continue
+				}
+				mapSocial[v.Key] = struct{}{}
+				profile.Socials = append(profile.Socials, ProfileSocial{Platform: v.Key, Handle: value, Url: profileSocialUrl(urlFormat, value)})
+			}
+		case "address":
+			key := common.ConvertRecordsAddressKey(fmt.Sprintf("%s.%s", v.Type, v.Key))
+			coinType := common.CoinType(strings.TrimPrefix(key, "address."))
+			if _, ok := mapCoinType[coinType]; ok {
+				continue
+			}
+			mapCoinType[coinType] = struct{}{}
+			if addr, err := common.FormatAddressByCoinType(string(coinType), value); err == nil && addr != "" {
+				value = addr
+			}
+			profile.Addresses = append(profile.Addresses, ProfileAddress{
+				CoinType: coinType,
+				Chain:    strings.TrimPrefix(common.ConvertRecordsAddressCoinType(key), "address."),
+				Address:  value,
+			})
+		}
+	}
+}
+
+func firstNonEmpty(current, value string) string {
+	if current != "" {
+		return current
+	}
+	return value
+}
+
+// profileSocialUrl builds the profile page of a social record, the record may be the handle, @handle or a link to the page,
+// and the handle is escaped so it stays one path segment of the platform. Platforms without a page by handle keep a link as is
+func profileSocialUrl(urlFormat, value string) string {
+	isUrl := hasPrefixFold(value, "https://") || hasPrefixFold(value, "http://")
+	if urlFormat == "" {
+		if isUrl {
+			return value
+		}
+		return ""
+	}
+	handle := value
+	for _, prefix := range []string{"https://", "http://", "www."} {
+		handle = trimPrefixFold(handle, prefix)
+	}
+	page := strings.TrimPrefix(strings.TrimSuffix(urlFormat, "%s"), "https://www.")
+	page = strings.TrimPrefix(page, "https://")
+	if hasPrefixFold(handle, page) {
+		handle, isUrl = handle[len(page):], true
+	} else if page = strings.TrimSuffix(page, "@"); hasPrefixFold(handle, page) {
+		handle, isUrl = handle[len(page):], true
+	}
+	handle = strings.TrimPrefix(handle, "@")
+	if isUrl {
+		// a link may go on past the handle, e.g. twitter.com/alice/status/1 or t.me/alice?start=1
+		if i := strings.IndexAny(handle, "/?#"); i >= 0 {
+			handle = handle[:i]
+		}
+	}
+	if handle == "" {
+		return ""
+	}
+	return fmt.Sprintf(urlFormat, url.PathEscape(handle))
+}
+
+func hasPrefixFold(s, prefix string) bool {
+	return len(s) >= len(prefix) && strings.EqualFold(s[:len(prefix)], prefix)
+}
+
+func trimPrefixFold(s, prefix string) string {
+	if hasPrefixFold(s, prefix) {
+		return s[len(prefix):]
+	}
+	return s
+}
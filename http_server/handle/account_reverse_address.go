@@ -2,14 +2,10 @@ package handle
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"github.com/dotbitHQ/das-lib/common"
 	"github.com/dotbitHQ/das-lib/core"
 	"github.com/dotbitHQ/das-lib/http_api"
-	"github.com/gin-gonic/gin"
-	"github.com/scorpiotzh/toolib"
-	"net/http"
 	"strings"
 )
 
@@ -24,48 +20,6 @@ type RespAccountReverseAddress struct {
 type AccountReverseAddress struct {
 }
 
-func (h *HttpHandle) JsonRpcAccountReverseAddress(p json.RawMessage, apiResp *http_api.ApiResp) {
-	var req []ReqAccountReverseAddress
-	err := json.Unmarshal(p, &req)
-	if err != nil {
-		log.Error("json.Unmarshal err:", err.Error())
-		apiResp.ApiRespErr(http_api.ApiCodeParamsInvalid, "params invalid")
-		return
-	}
-	if len(req) != 1 {
-		log.Error("len(req) is :", len(req))
-		apiResp.ApiRespErr(http_api.ApiCodeParamsInvalid, "params invalid")
-		return
-	}
-
-	if err = h.doAccountReverseAddress(h.Ctx, &req[0], apiResp); err != nil {
-		log.Error("doAccountReverseAddress err:", err.Error())
-	}
-}
-
-func (h *HttpHandle) AccountReverseAddress(ctx *gin.Context) {
-	var (
-		funcName = "AccountReverseAddress"
-		req      ReqAccountReverseAddress
-		apiResp  http_api.ApiResp
-		err      error
-	)
-
-	if err := ctx.ShouldBindJSON(&req); err != nil {
-		log.Error("ShouldBindJSON err: ", err.Error(), funcName, ctx.Request.Context())
-		apiResp.ApiRespErr(http_api.ApiCodeParamsInvalid, "params invalid")
-		ctx.JSON(http.StatusOK, apiResp)
-		return
-	}
-	log.Info("ApiReq:", funcName, toolib.JsonString(req), ctx.Request.Context())
-
-	if err = h.doAccountReverseAddress(ctx.Request.Context(), &req, &apiResp); err != nil {
-		log.Error("doAccountReverseAddress err:", err.Error(), funcName, ctx.Request.Context())
-	}
-
-	ctx.JSON(http.StatusOK, apiResp)
-}
-
 func (h *HttpHandle) doAccountReverseAddress(ctx context.Context, req *ReqAccountReverseAddress, apiResp *http_api.ApiResp) error {
 	var resp RespAccountReverseAddress
 	resp.List = make([]core.ChainTypeAddress, 0)
@@ -1,14 +1,11 @@
 package handle
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
 	"github.com/dotbitHQ/das-lib/common"
 	"github.com/dotbitHQ/das-lib/core"
 	"github.com/dotbitHQ/das-lib/http_api"
-	"github.com/gin-gonic/gin"
-	"github.com/scorpiotzh/toolib"
-	"net/http"
 	"strings"
 )
 
@@ -16,56 +13,7 @@ type ReqAddressAccount struct {
 	Address string `json:"address"`
 }
 
-func (h *HttpHandle) JsonRpcAddressAccount(p json.RawMessage, apiResp *http_api.ApiResp) {
-	var req []ReqAddressAccount
-	err := json.Unmarshal(p, &req)
-	if err != nil {
-		log.Warn("json.Unmarshal err:", err.Error())
-		var reqOld []string
-		if err = json.Unmarshal(p, &reqOld); err != nil {
-			log.Error("json.Unmarshal old req err:", err.Error())
-			apiResp.ApiRespErr(http_api.ApiCodeParamsInvalid, "params invalid")
-			return
-		} else if len(reqOld) == 1 {
-			req[0] = ReqAddressAccount{Address: reqOld[0]}
-		}
-	}
-	if len(req) != 1 {
-		log.Error("len(req) is :", len(req))
-		apiResp.ApiRespErr(http_api.ApiCodeParamsInvalid, "params invalid")
-		return
-	}
-
-	if err = h.doAddressAccount(&req[0], apiResp); err != nil {
-		log.Error("doAddressAccount err:", err.Error())
-	}
-}
-
-func (h *HttpHandle) AddressAccount(ctx *gin.Context) {
-	var (
-		funcName = "AddressAccount"
-		req      ReqAddressAccount
-		apiResp  http_api.ApiResp
-		err      error
-		clientIp = GetClientIp(ctx)
-	)
-
-	if err := ctx.ShouldBindJSON(&req); err != nil {
-		log.Error("ShouldBindJSON err: ", err.Error(), funcName)
-		apiResp.ApiRespErr(http_api.ApiCodeParamsInvalid, "params invalid")
-		ctx.JSON(http.StatusOK, apiResp)
-		return
-	}
-	log.Info("ApiReq:", ctx.Request.Host, funcName, clientIp, toolib.JsonString(req))
-
-	if err = h.doAddressAccount(&req, &apiResp); err != nil {
-		log.Error("doAddressAccount err:", err.Error(), funcName)
-	}
-
-	ctx.JSON(http.StatusOK, apiResp)
-}
-
-func (h *HttpHandle) doAddressAccount(req *ReqAddressAccount, apiResp *http_api.ApiResp) error {
+func (h *HttpHandle) doAddressAccount(ctx context.Context, req *ReqAddressAccount, apiResp *http_api.ApiResp) error {
 	var resp = make([]RespSearchAccount, 0)
 
 	addrHex, err := formatAddress(h.DasCore.Daf(), req.Address)
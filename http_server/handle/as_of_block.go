@@ -0,0 +1,82 @@
+package handle
+
+import (
+	"das-account-indexer/tables"
+	"fmt"
+	"github.com/dotbitHQ/das-lib/common"
+	"github.com/dotbitHQ/das-lib/http_api"
+	"strings"
+)
+
+// as_of_block reads the state history the parser keeps when history.enable is set, 0 is the current state
+
+// checkAsOfBlock answers with an error when asOfBlock is out of the history, older than the retention window or not indexed yet
+func (h *HttpHandle) checkAsOfBlock(asOfBlock uint64, apiResp *http_api.ApiResp) (bool, error) {
+	if asOfBlock == 0 {
+		return true, nil
+	}
+	start, err := h.DbDao.GetStateHistoryStart()
+	if err != nil {
+		apiResp.ApiRespErr(http_api.ApiCodeDbError, "find state history err")
+		return false, fmt.Errorf("GetStateHistoryStart err: %s", err.Error())
+	} else if start.Id == 0 {
+		apiResp.ApiRespErr(http_api.ApiCodeParamsInvalid, "as_of_block not supported, state history is off")
+		return false, nil
+	}
+	block, err := h.DbDao.FindCurrentBlockInfo()
+	if err != nil {
+		apiResp.ApiRespErr(http_api.ApiCodeDbError, "find block info err")
+		return false, fmt.Errorf("FindCurrentBlockInfo err: %s", err.Error())
+	} else if asOfBlock > block.BlockNumber {
+		apiResp.ApiRespErr(http_api.ApiCodeSyncBlockNumber, fmt.Sprintf("as_of_block not indexed yet, latest block: %d", block.BlockNumber))
+		return false, nil
+	} else if asOfBlock < start.FromBlock {
+		apiResp.ApiRespErr(http_api.ApiCodeParamsInvalid, fmt.Sprintf("as_of_block is older than the retention window, earliest block: %d", start.FromBlock))
+		return false, nil
+	}
+	return true, nil
+}
+
+func (h *HttpHandle) findAccountInfoAsOf(accountId string, asOfBlock uint64) (tables.TableAccountInfo, error) {
+	if asOfBlock == 0 {
+		return h.DbDao.FindAccountInfoByAccountId(accountId)
+	}
+	return h.DbDao.FindAccountInfoAsOf(accountId, asOfBlock)
+}
+
+func (h *HttpHandle) findAccountRecordsAsOf(accountId string, asOfBlock uint64) ([]tables.TableRecordsInfo, error) {
+	if asOfBlock == 0 {
+		return h.DbDao.FindAccountRecordsByAccountId(accountId)
+	}
+	return h.DbDao.FindAccountRecordsAsOf(accountId, asOfBlock)
+}
+
+func (h *HttpHandle) getDidCellAsOf(accountId string, asOfBlock uint64) (tables.TableDidCellInfo, error) {
+	if asOfBlock == 0 {
+		return h.DbDao.GetDidCellByAccountId(accountId)
+	}
+	return h.DbDao.GetDidCellAsOf(accountId, asOfBlock)
+}
+
+func (h *HttpHandle) findLatestReverseRecordAsOf(chainType common.ChainType, address, btcAddr string, asOfBlock uint64) (tables.TableReverseInfo, error) {
+	if asOfBlock == 0 {
+		return h.DbDao.FindLatestReverseRecord(chainType, address, btcAddr)
+	}
+	return h.DbDao.FindLatestReverseRecordAsOf(chainType, address, btcAddr, asOfBlock)
+}
+
+func (h *HttpHandle) findRecordByAccountIdAddressValueAsOf(accountId, value string, asOfBlock uint64) (tables.TableRecordsInfo, error) {
+	if asOfBlock == 0 {
+		return h.DbDao.FindRecordByAccountIdAddressValue(accountId, value)
+	}
+	list, err := h.DbDao.FindAccountRecordsAsOf(accountId, asOfBlock)
+	if err != nil {
+		return tables.TableRecordsInfo{}, err
+	}
+	for _, v := range list {
+		if v.Type == "address" && strings.EqualFold(v.Value, value) {
+			return v, nil
+		}
+	}
+	return tables.TableRecordsInfo{}, nil
+}
@@ -0,0 +1,181 @@
+package handle
+
+import (
+	"context"
+	"das-account-indexer/tables"
+	"fmt"
+	"github.com/dotbitHQ/das-lib/common"
+	"github.com/dotbitHQ/das-lib/core"
+	"github.com/dotbitHQ/das-lib/http_api"
+	"sort"
+	"strings"
+)
+
+const (
+	batchAccountListMax     = 5000
+	batchAccountListTooMany = "more than 5000 accounts for one chain type, query fewer addresses"
+)
+
+type ReqBatchAccountList struct {
+	BatchKeyInfo []core.ChainTypeAddress `json:"batch_key_info"`
+	Role         string                  `json:"role"` // owner,manager
+}
+
+type RespBatchAccountList struct {
+	Total       int                   `json:"total"`
+	AccountList []BatchAddressAccount `json:"account_list"` // one per account, sorted by account
+	Truncated   bool                  `json:"truncated"`    // more than 5000 accounts in all, the rest are left out
+	ErrList     []BatchAccountListErr `json:"err_list"`     // key infos left out
+}
+
+type BatchAddressAccount struct {
+	AccountId    string `json:"account_id"`
+	Account      string `json:"account"`
+	DisplayName  string `json:"display_name"`
+	RegisteredAt uint64 `json:"registered_at"`
+	ExpiredAt    uint64 `json:"expired_at"`
+	Source       string `json:"source"`      // account, or did_cell for a ckb address
+	KeyIndexes   []int  `json:"key_indexes"` // the batch_key_info holding the account
+}
+
+type BatchAccountListErr struct {
+	Index  int    `json:"index"`
+	ErrMsg string `json:"err_msg"`
+}
+
+// doBatchAccountList is doAccountList for up to 100 addresses, with one query per chain type
+// and one for all the ckb addresses, the DID cells of which are stored apart
+func (h *HttpHandle) doBatchAccountList(ctx context.Context, req *ReqBatchAccountList, apiResp *http_api.ApiResp) error {
+	var resp RespBatchAccountList
+	resp.AccountList = make([]BatchAddressAccount, 0)
+	resp.ErrList = make([]BatchAccountListErr, 0)
+
+	if count := len(req.BatchKeyInfo); count == 0 || count > 100 {
+		apiResp.ApiRespErr(http_api.ApiCodeParamsInvalid, "Invalid number of key info")
+		return nil
+	}
+	if req.Role != "" && req.Role != "owner" && req.Role != "manager" {
+		apiResp.ApiRespErr(http_api.ApiCodeParamsInvalid, "role invalid")
+		return nil
+	}
+
+	// group the addresses, the same address may be given more than once
+	var mapChainTypeAddr = make(map[common.ChainType][]string)
+	var argsList []string
+	var mapIndexes = make(map[string][]int)
+	for i, v := range req.BatchKeyInfo {
+		addrHex, err := v.FormatChainTypeAddress(h.DasCore.NetType(), true)
+		if err != nil {
+			log.Warn(ctx, "FormatChainTypeAddress err:", err.Error(), i)
+			resp.ErrList = append(resp.ErrList, BatchAccountListErr{Index: i, ErrMsg: "address is invalid"})
+			continue
+		}
+		key := fmt.Sprintf("%d:%s", addrHex.ChainType, strings.ToLower(addrHex.AddressHex))
+		if addrHex.DasAlgorithmId == common.DasAlgorithmIdAnyLock {
+			// a DID cell has an owner only
+			if req.Role == "manager" {
+				continue
+			}
+			key = "args:" + strings.ToLower(addrHex.AddressHex)
+			if _, ok := mapIndexes[key]; !ok {
+				argsList = append(argsList, addrHex.AddressHex)
+			}
+		} else if _, ok := mapIndexes[key]; !ok {
+			mapChainTypeAddr[addrHex.ChainType] = append(mapChainTypeAddr[addrHex.ChainType], addrHex.AddressHex)
+		}
+		mapIndexes[key] = append(mapIndexes[key], i)
+	}
+
+	var mapAccounts = make(map[string]*BatchAddressAccount)
+	var addAccount = func(keyIndexes []int, accountId, account string, registeredAt, expiredAt uint64, source string) {
+		acc, ok := mapAccounts[accountId]
+		if !ok {
+			acc = &BatchAddressAccount{
+				AccountId:    accountId,
+				Account:      account,
+				DisplayName:  FormatDisplayName(account),
+				RegisteredAt: registeredAt,
+				ExpiredAt:    expiredAt,
+				Source:       source,
+				KeyIndexes:   make([]int, 0),
+			}
+			mapAccounts[accountId] = acc
+		}
+		for _, i := range keyIndexes {
+			if l := len(acc.KeyIndexes); l == 0 || acc.KeyIndexes[l-1] != i {
+				acc.KeyIndexes = append(acc.KeyIndexes, i)
+			}
+		}
+	}
+
+	// das-lock storage, sorted by chain type for a stable order of the queries
+	var chainTypes []common.ChainType
+	for k := range mapChainTypeAddr {
+		chainTypes = append(chainTypes, k)
+	}
+	sort.Slice(chainTypes, func(i, j int) bool { return chainTypes[i] < chainTypes[j] })
+	for _, chainType := range chainTypes {
+		list, err := h.DbDao.FindAccountNameListByAddresses(chainType, mapChainTypeAddr[chainType], req.Role, batchAccountListMax+1)
+		if err != nil {
+			apiResp.ApiRespErr(http_api.ApiCodeDbError, "find account list err")
+			return fmt.Errorf("FindAccountNameListByAddresses err: %s", err.Error())
+		} else if len(list) > batchAccountListMax {
+			// a list cut before the merge would not be the first ones of the merged order
+			apiResp.ApiRespErr(http_api.ApiCodeParamsInvalid, batchAccountListTooMany)
+			return nil
+		}
+		for _, v := range list {
+			address := v.Owner
+			if req.Role == "manager" {
+				address = v.Manager
+			}
+			key := fmt.Sprintf("%d:%s", chainType, strings.ToLower(address))
+			addAccount(mapIndexes[key], v.AccountId, v.Account, v.RegisteredAt, v.ExpiredAt, tables.AccountSourceAccount)
+		}
+	}
+
+	// did-cell storage
+	if len(argsList) > 0 {
+		didCells, err := h.DbDao.QueryDidCellByArgsList(argsList, batchAccountListMax+1)
+		if err != nil {
+			apiResp.ApiRespErr(http_api.ApiCodeDbError, "find did cell list err")
+			return fmt.Errorf("QueryDidCellByArgsList err: %s", err.Error())
+		} else if len(didCells) > batchAccountListMax {
+			apiResp.ApiRespErr(http_api.ApiCodeParamsInvalid, batchAccountListTooMany)
+			return nil
+		}
+		var accIds []string
+		for _, v := range didCells {
+			if _, ok := mapAccounts[v.AccountId]; !ok {
+				accIds = append(accIds, v.AccountId)
+			}
+			addAccount(mapIndexes["args:"+strings.ToLower(v.Args)], v.AccountId, v.Account, 0, v.ExpiredAt, tables.AccountSourceDid)
+		}
+		accs, err := h.DbDao.GetAccountByAccIds(accIds)
+		if err != nil {
+			apiResp.ApiRespErr(http_api.ApiCodeDbError, "find account list err")
+			return fmt.Errorf("GetAccountByAccIds err: %s", err.Error())
+		}
+		for _, v := range accs {
+			if acc, ok := mapAccounts[v.AccountId]; ok && acc.Source == tables.AccountSourceDid {
+				acc.RegisteredAt = v.RegisteredAt
+			}
+		}
+	}
+
+	for _, v := range mapAccounts {
+		sort.Ints(v.KeyIndexes)
+		resp.AccountList = append(resp.AccountList, *v)
+	}
+	sort.Slice(resp.AccountList, func(i, j int) bool {
+		return resp.AccountList[i].Account < resp.AccountList[j].Account
+	})
+	if len(resp.AccountList) > batchAccountListMax {
+		resp.AccountList = resp.AccountList[:batchAccountListMax]
+		resp.Truncated = true
+	}
+	resp.Total = len(resp.AccountList)
+
+	apiResp.ApiRespOK(resp)
+	return nil
+}
@@ -3,13 +3,9 @@ package handle
 import (
 	"context"
 	"das-account-indexer/tables"
-	"encoding/json"
 	"fmt"
 	"github.com/dotbitHQ/das-lib/common"
 	"github.com/dotbitHQ/das-lib/http_api"
-	"github.com/gin-gonic/gin"
-	"github.com/scorpiotzh/toolib"
-	"net/http"
 	"strings"
 )
 
@@ -28,48 +24,6 @@ type BatchAccountRecord struct {
 	ErrMsg    string       `json:"err_msg"`
 }
 
-func (h *HttpHandle) JsonRpcBatchAccountRecords(p json.RawMessage, apiResp *http_api.ApiResp) {
-	var req []ReqBatchAccountRecords
-	err := json.Unmarshal(p, &req)
-	if err != nil {
-		log.Error("json.Unmarshal err:", err.Error())
-		apiResp.ApiRespErr(http_api.ApiCodeParamsInvalid, "params invalid")
-		return
-	}
-	if len(req) != 1 {
-		log.Error("len(req) is :", len(req))
-		apiResp.ApiRespErr(http_api.ApiCodeParamsInvalid, "params invalid")
-		return
-	}
-
-	if err = h.doBatchAccountRecords(h.Ctx, &req[0], apiResp); err != nil {
-		log.Error("doBatchAccountRecords err:", err.Error())
-	}
-}
-
-func (h *HttpHandle) BatchAccountRecords(ctx *gin.Context) {
-	var (
-		funcName = "BatchAccountRecords"
-		req      ReqBatchAccountRecords
-		apiResp  http_api.ApiResp
-		err      error
-	)
-
-	if err := ctx.ShouldBindJSON(&req); err != nil {
-		log.Error("ShouldBindJSON err: ", err.Error(), funcName, ctx.Request.Context())
-		apiResp.ApiRespErr(http_api.ApiCodeParamsInvalid, "params invalid")
-		ctx.JSON(http.StatusOK, apiResp)
-		return
-	}
-	log.Info("ApiReq:", funcName, toolib.JsonString(req), ctx.Request.Context())
-
-	if err = h.doBatchAccountRecords(ctx.Request.Context(), &req, &apiResp); err != nil {
-		log.Error("doBatchAccountRecords err:", err.Error(), funcName, ctx.Request.Context())
-	}
-
-	ctx.JSON(http.StatusOK, apiResp)
-}
-
 func (h *HttpHandle) doBatchAccountRecords(ctx context.Context, req *ReqBatchAccountRecords, apiResp *http_api.ApiResp) error {
 	var resp RespBatchAccountRecords
 	resp.List = make([]BatchAccountRecord, 0)
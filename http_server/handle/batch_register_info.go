@@ -5,15 +5,11 @@ import (
 	"context"
 	"das-account-indexer/config"
 	"encoding/binary"
-	"encoding/json"
 	"errors"
 	"github.com/dotbitHQ/das-lib/common"
 	"github.com/dotbitHQ/das-lib/http_api"
-	"github.com/gin-gonic/gin"
 	"github.com/minio/blake2b-simd"
-	"github.com/scorpiotzh/toolib"
 	"gorm.io/gorm"
-	"net/http"
 	"strings"
 )
 
@@ -30,47 +26,6 @@ type BatchRegisterInfoRecord struct {
 	CanRegister bool   `json:"can_register"`
 }
 
-func (h *HttpHandle) BatchRegisterInfo(ctx *gin.Context) {
-	var (
-		funcName = "BatchRegisterInfo"
-		req      ReqBatchRegisterInfo
-		apiResp  http_api.ApiResp
-		err      error
-	)
-
-	if err := ctx.ShouldBindJSON(&req); err != nil {
-		log.Error("ShouldBindJSON err: ", err.Error(), funcName, ctx.Request.Context())
-		apiResp.ApiRespErr(http_api.ApiCodeParamsInvalid, "params invalid")
-		ctx.JSON(http.StatusOK, apiResp)
-		return
-	}
-	log.Info("ApiReq:", funcName, toolib.JsonString(req), ctx.Request.Context())
-
-	if err = h.doBatchRegisterInfo(ctx.Request.Context(), &req, &apiResp); err != nil {
-		log.Error("doBatchRegisterInfo err:", err.Error(), funcName, ctx.Request.Context())
-	}
-	ctx.JSON(http.StatusOK, apiResp)
-}
-
-func (h *HttpHandle) JsonRpcBatchRegisterInfo(p json.RawMessage, apiResp *http_api.ApiResp) {
-	var req []ReqBatchRegisterInfo
-	err := json.Unmarshal(p, &req)
-	if err != nil {
-		log.Error("json.Unmarshal err:", err.Error())
-		apiResp.ApiRespErr(http_api.ApiCodeParamsInvalid, "params invalid")
-		return
-	}
-	if len(req) != 1 {
-		log.Error("len(req) is :", len(req))
-		apiResp.ApiRespErr(http_api.ApiCodeParamsInvalid, "params invalid")
-		return
-	}
-
-	if err = h.doBatchRegisterInfo(h.Ctx, &req[0], apiResp); err != nil {
-		log.Error("doBatchReverseRecord err:", err.Error())
-	}
-}
-
 func (h *HttpHandle) doBatchRegisterInfo(ctx context.Context, req *ReqBatchRegisterInfo, apiResp *http_api.ApiResp) error {
 	accIds := make([]string, 0, len(req.BatchAccount))
 	for _, v := range req.BatchAccount {
@@ -1,15 +1,12 @@
 package handle
 
 import (
+	"context"
 	"das-account-indexer/tables"
-	"encoding/json"
 	"fmt"
 	"github.com/dotbitHQ/das-lib/common"
 	"github.com/dotbitHQ/das-lib/core"
 	"github.com/dotbitHQ/das-lib/http_api"
-	"github.com/gin-gonic/gin"
-	"github.com/scorpiotzh/toolib"
-	"net/http"
 	"strings"
 )
 
@@ -28,49 +25,8 @@ type BatchReverseRecord struct {
 	ErrMsg       string `json:"err_msg"`
 }
 
-func (h *HttpHandle) JsonRpcBatchReverseRecord(p json.RawMessage, apiResp *http_api.ApiResp) {
-	var req []ReqBatchReverseRecord
-	err := json.Unmarshal(p, &req)
-	if err != nil {
-		log.Error("json.Unmarshal err:", err.Error())
-		apiResp.ApiRespErr(http_api.ApiCodeParamsInvalid, "params invalid")
-		return
-	}
-	if len(req) != 1 {
-		log.Error("len(req) is :", len(req))
-		apiResp.ApiRespErr(http_api.ApiCodeParamsInvalid, "params invalid")
-		return
-	}
-
-	if err = h.doBatchReverseRecord(&req[0], apiResp); err != nil {
-		log.Error("doBatchReverseRecord err:", err.Error())
-	}
-}
-
-func (h *HttpHandle) BatchReverseRecord(ctx *gin.Context) {
-	var (
-		funcName = "BatchReverseRecord"
-		req      ReqBatchReverseRecord
-		apiResp  http_api.ApiResp
-		err      error
-	)
-
-	if err := ctx.ShouldBindJSON(&req); err != nil {
-		log.Error("ShouldBindJSON err: ", err.Error(), funcName)
-		apiResp.ApiRespErr(http_api.ApiCodeParamsInvalid, "params invalid")
-		ctx.JSON(http.StatusOK, apiResp)
-		return
-	}
-	log.Info("ApiReq:", funcName, toolib.JsonString(req))
-
-	if err = h.doBatchReverseRecord(&req, &apiResp); err != nil {
-		log.Error("doBatchReverseRecord err:", err.Error(), funcName)
-	}
-
-	ctx.JSON(http.StatusOK, apiResp)
-}
-
-func (h *HttpHandle) doBatchReverseRecord(req *ReqBatchReverseRecord, apiResp *http_api.ApiResp) error {
+// doBatchReverseRecord is the batch of das_batchReverseRecord, kept for json-rpc clients, REST serves doBatchReverseRecordV2
+func (h *HttpHandle) doBatchReverseRecord(ctx context.Context, req *ReqBatchReverseRecord, apiResp *http_api.ApiResp) error {
 	var resp RespBatchReverseRecord
 	resp.List = make([]BatchReverseRecord, 0)
 
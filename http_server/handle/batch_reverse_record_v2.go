@@ -4,14 +4,10 @@ import (
 	"context"
 	"das-account-indexer/config"
 	"das-account-indexer/tables"
-	"encoding/json"
 	"fmt"
 	"github.com/dotbitHQ/das-lib/common"
 	"github.com/dotbitHQ/das-lib/core"
 	"github.com/dotbitHQ/das-lib/http_api"
-	"github.com/gin-gonic/gin"
-	"github.com/scorpiotzh/toolib"
-	"net/http"
 	"strings"
 )
 
@@ -30,48 +26,6 @@ type BatchReverseRecordV2 struct {
 	ErrMsg       string `json:"err_msg"`
 }
 
-func (h *HttpHandle) JsonRpcBatchReverseRecordV2(p json.RawMessage, apiResp *http_api.ApiResp) {
-	var req []ReqBatchReverseRecordV2
-	err := json.Unmarshal(p, &req)
-	if err != nil {
-		log.Error("json.Unmarshal err:", err.Error())
-		apiResp.ApiRespErr(http_api.ApiCodeParamsInvalid, "params invalid")
-		return
-	}
-	if len(req) != 1 {
-		log.Error("len(req) is :", len(req))
-		apiResp.ApiRespErr(http_api.ApiCodeParamsInvalid, "params invalid")
-		return
-	}
-
-	if err = h.doBatchReverseRecordV2(h.Ctx, &req[0], apiResp); err != nil {
-		log.Error("doBatchReverseRecordV2 err:", err.Error())
-	}
-}
-
-func (h *HttpHandle) BatchReverseRecordV2(ctx *gin.Context) {
-	var (
-		funcName = "BatchReverseRecordV2"
-		req      ReqBatchReverseRecordV2
-		apiResp  http_api.ApiResp
-		err      error
-	)
-
-	if err := ctx.ShouldBindJSON(&req); err != nil {
-		log.Error("ShouldBindJSON err: ", err.Error(), funcName, ctx.Request.Context())
-		apiResp.ApiRespErr(http_api.ApiCodeParamsInvalid, "params invalid")
-		ctx.JSON(http.StatusOK, apiResp)
-		return
-	}
-	log.Info("ApiReq:", funcName, toolib.JsonString(req), ctx.Request.Context())
-
-	if err = h.doBatchReverseRecordV2(ctx.Request.Context(), &req, &apiResp); err != nil {
-		log.Error("doBatchReverseRecordV2 err:", err.Error(), funcName, ctx.Request.Context())
-	}
-
-	ctx.JSON(http.StatusOK, apiResp)
-}
-
 func (h *HttpHandle) doBatchReverseRecordV2(ctx context.Context, req *ReqBatchReverseRecordV2, apiResp *http_api.ApiResp) error {
 	var resp RespBatchReverseRecordV2
 
@@ -180,7 +134,7 @@ func (h *HttpHandle) checkReverseV2(chainType common.ChainType, addressHex, reqK
 		return
 	} else if accountInfo.Status == tables.AccountStatusOnUpgrade {
 		// did cell
-		didAnyLock, err := h.getAnyLockAddressHex(accountId)
+		didAnyLock, err := h.getAnyLockAddressHex(accountId, 0)
 		if err != nil {
 			log.Warn("getAnyLockAddressHex err: %s", err.Error())
 		} else {
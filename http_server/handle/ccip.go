@@ -0,0 +1,347 @@
+package handle
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"das-account-indexer/config"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"github.com/btcsuite/btcd/btcutil/base58"
+	"github.com/dotbitHQ/das-lib/common"
+	"github.com/dotbitHQ/das-lib/http_api"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/gin-gonic/gin"
+	"github.com/scorpiotzh/toolib"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// EIP-3668 gateway for the ENS OffchainResolver, the resolver reverts with OffchainLookup
+// and clients fetch the signed answer of resolve(bytes name, bytes data) from here
+
+type ReqCcipRead struct {
+	Sender string `json:"sender"`
+	Data   string `json:"data"`
+}
+
+type RespCcipRead struct {
+	Data string `json:"data"`
+}
+
+const defaultCcipTtl = 300
+
+var (
+	ccipSelectorResolve     = [4]byte{0x90, 0x61, 0xb9, 0x23} // resolve(bytes,bytes)
+	ccipSelectorAddr        = [4]byte{0x3b, 0x3b, 0x57, 0xde} // addr(bytes32)
+	ccipSelectorAddrCoin    = [4]byte{0xf1, 0xcb, 0x7e, 0x06} // addr(bytes32,uint256)
+	ccipSelectorText        = [4]byte{0x59, 0xd1, 0xd4, 0x3c} // text(bytes32,string)
+	ccipSelectorContenthash = [4]byte{0xbc, 0x1c, 0x58, 0xd1} // contenthash(bytes32)
+
+	ccipTypeBytes, _   = abi.NewType("bytes", "", nil)
+	ccipTypeBytes32, _ = abi.NewType("bytes32", "", nil)
+	ccipTypeString, _  = abi.NewType("string", "", nil)
+	ccipTypeUint256, _ = abi.NewType("uint256", "", nil)
+	ccipTypeUint64, _  = abi.NewType("uint64", "", nil)
+	ccipTypeAddress, _ = abi.NewType("address", "", nil)
+)
+
+// ENSIP-11 coin types of EVM chains are 0x80000000|chainId, they map to the SLIP-44 coin types of the records
+var ccipEvmCoinTypes = map[uint64]common.CoinType{
+	0x80000000 | 56:  common.CoinTypeBSC,
+	0x80000000 | 137: common.CoinTypeMatic,
+}
+
+type ccipError struct {
+	status  int
+	message string
+}
+
+func (e *ccipError) Error() string {
+	return e.message
+}
+
+func (h *HttpHandle) CcipRead(ctx *gin.Context) {
+	var (
+		funcName = "CcipRead"
+		clientIp = GetClientIp(ctx)
+		req      = ReqCcipRead{Sender: ctx.Param("sender"), Data: strings.TrimSuffix(ctx.Param("data"), ".json")}
+	)
+	if ctx.Request.Method == http.MethodPost {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			log.Error("ShouldBindJSON err: ", err.Error(), funcName, clientIp, ctx.Request.Context())
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": "params invalid"})
+			return
+		}
+	}
+	log.Info("ApiReq:", funcName, clientIp, toolib.JsonString(req), ctx.Request.Context())
+
+	resp, err := h.doCcipRead(ctx.Request.Context(), &req)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if e, ok := err.(*ccipError); ok {
+			status = e.status
+		}
+		log.Error("doCcipRead err:", err.Error(), funcName, clientIp, ctx.Request.Context())
+		ctx.JSON(status, gin.H{"message": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, resp)
+}
+
+func (h *HttpHandle) doCcipRead(ctx context.Context, req *ReqCcipRead) (*RespCcipRead, error) {
+	if h.CcipKey == nil {
+		return nil, &ccipError{http.StatusNotFound, "gateway disabled"}
+	}
+	if !ethcommon.IsHexAddress(req.Sender) {
+		return nil, &ccipError{http.StatusBadRequest, "sender invalid"}
+	}
+	sender := ethcommon.HexToAddress(req.Sender)
+	callData, err := hexutil.Decode(req.Data)
+	if err != nil || len(callData) < 4 || !bytes.Equal(callData[:4], ccipSelectorResolve[:]) {
+		return nil, &ccipError{http.StatusBadRequest, "data should be a resolve(bytes,bytes) call"}
+	}
+	args, err := abi.Arguments{{Type: ccipTypeBytes}, {Type: ccipTypeBytes}}.Unpack(callData[4:])
+	if err != nil {
+		return nil, &ccipError{http.StatusBadRequest, "data invalid"}
+	}
+	name, err := decodeDnsName(args[0].([]byte))
+	if err != nil {
+		return nil, &ccipError{http.StatusBadRequest, err.Error()}
+	}
+	account := ccipAccount(name)
+
+	result, err := h.ccipResult(ctx, account, args[1].([]byte))
+	if err != nil {
+		return nil, err
+	}
+
+	ttl := config.Cfg.Ccip.Ttl
+	if ttl == 0 {
+		ttl = defaultCcipTtl
+	}
+	res, err := ccipSign(h.CcipKey, sender, uint64(time.Now().Unix())+ttl, callData, result)
+	if err != nil {
+		return nil, err
+	}
+	return &RespCcipRead{Data: hexutil.Encode(res)}, nil
+}
+
+// ccipSign gives the (bytes result, uint64 expires, bytes sig) that OffchainResolver.resolveWithProof decodes
+func ccipSign(key *ecdsa.PrivateKey, sender ethcommon.Address, expires uint64, request, result []byte) ([]byte, error) {
+	sig, err := crypto.Sign(ccipSignatureHash(sender, expires, request, result), key)
+	if err != nil {
+		return nil, fmt.Errorf("crypto.Sign err: %s", err.Error())
+	}
+	sig[64] += 27
+
+	res, err := abi.Arguments{{Type: ccipTypeBytes}, {Type: ccipTypeUint64}, {Type: ccipTypeBytes}}.Pack(result, expires, sig)
+	if err != nil {
+		return nil, fmt.Errorf("abi Pack err: %s", err.Error())
+	}
+	return res, nil
+}
+
+// ccipResult answers the inner resolver call, a name that does not resolve gets the zero value
+// of the return type, as an on-chain resolver would
+func (h *HttpHandle) ccipResult(ctx context.Context, account string, data []byte) ([]byte, error) {
+	if len(data) < 4 {
+		return nil, &ccipError{http.StatusBadRequest, "resolver call invalid"}
+	}
+	var selector [4]byte
+	copy(selector[:], data[:4])
+
+	var req = ReqResolve{Account: account}
+	switch selector {
+	case ccipSelectorAddr:
+		req.CoinType = common.CoinTypeEth
+	case ccipSelectorAddrCoin:
+		args, err := abi.Arguments{{Type: ccipTypeBytes32}, {Type: ccipTypeUint256}}.Unpack(data[4:])
+		if err != nil {
+			return nil, &ccipError{http.StatusBadRequest, "addr call invalid"}
+		}
+		req.CoinType = ccipCoinType(args[1].(*big.Int))
+	case ccipSelectorText:
+		args, err := abi.Arguments{{Type: ccipTypeBytes32}, {Type: ccipTypeString}}.Unpack(data[4:])
+		if err != nil {
+			return nil, &ccipError{http.StatusBadRequest, "text call invalid"}
+		}
+		req.Keys = []string{ccipTextKey(args[1].(string))}
+	case ccipSelectorContenthash:
+		req.Keys = []string{"dweb.ipfs", "dweb.ipns"}
+	default:
+		return nil, &ccipError{http.StatusBadRequest, "resolver function not supported"}
+	}
+
+	var apiResp http_api.ApiResp
+	if err := h.doResolve(ctx, &req, &apiResp); err != nil {
+		return nil, fmt.Errorf("doResolve err: %s", err.Error())
+	}
+	var resp RespResolve
+	switch apiResp.ErrNo {
+	case http_api.ApiCodeSuccess:
+		resp, _ = apiResp.Data.(RespResolve)
+	case http_api.ApiCodeDbError, http_api.ApiCodeError500:
+		return nil, fmt.Errorf("doResolve: %s", apiResp.ErrMsg)
+	}
+
+	switch selector {
+	case ccipSelectorAddr:
+		var addr ethcommon.Address
+		if len(resp.Addresses) > 0 && ethcommon.IsHexAddress(resp.Addresses[0].Address) {
+			addr = ethcommon.HexToAddress(resp.Addresses[0].Address)
+		}
+		return abi.Arguments{{Type: ccipTypeAddress}}.Pack(addr)
+	case ccipSelectorAddrCoin:
+		var addr []byte
+		if len(resp.Addresses) > 0 {
+			addr = ccipAddressBytes(req.CoinType, resp.Addresses[0].Address)
+		}
+		return abi.Arguments{{Type: ccipTypeBytes}}.Pack(addr)
+	case ccipSelectorText:
+		var text string
+		if len(resp.Texts) > 0 {
+			text = resp.Texts[0].Value
+		}
+		return abi.Arguments{{Type: ccipTypeString}}.Pack(text)
+	default:
+		var hash []byte
+		for _, v := range resp.Texts {
+			if hash = encodeContenthash(v.Key, v.Value); hash != nil {
+				break
+			}
+		}
+		return abi.Arguments{{Type: ccipTypeBytes}}.Pack(hash)
+	}
+}
+
+// ccipSignatureHash is the digest SignatureVerifier.makeSignatureHash of the OffchainResolver checks
+func ccipSignatureHash(target ethcommon.Address, expires uint64, request, result []byte) []byte {
+	var expiresBys [8]byte
+	binary.BigEndian.PutUint64(expiresBys[:], expires)
+	return crypto.Keccak256(
+		[]byte{0x19, 0x00},
+		target.Bytes(),
+		expiresBys[:],
+		crypto.Keccak256(request),
+		crypto.Keccak256(result),
+	)
+}
+
+// decodeDnsName reads a name in DNS wire format, as ENS passes it to resolve
+func decodeDnsName(bys []byte) (string, error) {
+	var labels []string
+	for i := 0; i < len(bys); {
+		n := int(bys[i])
+		if n == 0 {
+			return strings.Join(labels, "."), nil
+		}
+		if i+1+n > len(bys) {
+			break
+		}
+		labels = append(labels, string(bys[i+1:i+1+n]))
+		i += 1 + n
+	}
+	return "", fmt.Errorf("name invalid")
+}
+
+// ccipAccount maps the ENS name to the account, names under the configured parent are .bit accounts
+func ccipAccount(name string) string {
+	name = strings.ToLower(name)
+	if suffix := strings.Trim(strings.ToLower(config.Cfg.Ccip.NameSuffix), "."); suffix != "" {
+		if strings.HasSuffix(name, "."+suffix) {
+			return strings.TrimSuffix(name, "."+suffix) + common.DasAccountSuffix
+		}
+	}
+	return name
+}
+
+func ccipCoinType(coinType *big.Int) common.CoinType {
+	if coinType.IsUint64() {
+		if v, ok := ccipEvmCoinTypes[coinType.Uint64()]; ok {
+			return v
+		}
+	}
+	return common.CoinType(coinType.String())
+}
+
+// ccipTextKey maps ENSIP-5 keys to the record keys of .bit, full record keys are kept as they are
+func ccipTextKey(key string) string {
+	switch key {
+	case "url":
+		return "profile.website"
+	case "com.twitter", "com.github", "com.discord", "com.reddit", "com.linkedin":
+		return "profile." + strings.TrimPrefix(key, "com.")
+	case "org.telegram":
+		return "profile.telegram"
+	}
+	for _, prefix := range []string{"profile.", "address.", "dweb.", "custom_key."} {
+		if strings.HasPrefix(key, prefix) {
+			return key
+		}
+	}
+	return "profile." + key
+}
+
+// ccipAddressBytes returns the ENSIP-9 binary form of an address, nil for coins it is not known for
+func ccipAddressBytes(coinType common.CoinType, addr string) []byte {
+	switch coinType {
+	case common.CoinTypeEth, common.CoinTypeBSC, common.CoinTypeMatic, common.CoinTypeBNB:
+		if ethcommon.IsHexAddress(addr) {
+			return ethcommon.HexToAddress(addr).Bytes()
+		}
+	case common.CoinTypeTrx:
+		if res, err := common.TronBase58ToHex(addr); err == nil {
+			if bys, err := hexutil.Decode("0x" + strings.TrimPrefix(res, "0x")); err == nil {
+				return bys
+			}
+		}
+	}
+	return nil
+}
+
+// encodeContenthash follows ENSIP-7 for ipfs and ipns records holding a base58 or base32 cid
+func encodeContenthash(key, value string) []byte {
+	var codec []byte
+	var protocol string
+	switch key {
+	case "dweb.ipfs":
+		codec, protocol = []byte{0xe3, 0x01}, "ipfs://"
+	case "dweb.ipns":
+		codec, protocol = []byte{0xe5, 0x01}, "ipns://"
+	default:
+		return nil
+	}
+	value = strings.TrimPrefix(strings.TrimSpace(value), protocol)
+	if value == "" {
+		return nil
+	}
+
+	var cid []byte
+	if strings.HasPrefix(value, "Qm") {
+		mh := base58.Decode(value)
+		if len(mh) == 0 {
+			return nil
+		}
+		contentType := byte(0x70) // dag-pb
+		if key == "dweb.ipns" {
+			contentType = 0x72 // libp2p-key
+		}
+		cid = append([]byte{0x01, contentType}, mh...)
+	} else if strings.HasPrefix(value, "b") {
+		bys, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(strings.ToUpper(value[1:]))
+		if err != nil {
+			return nil
+		}
+		cid = bys
+	} else {
+		return nil
+	}
+	return append(codec, cid...)
+}
@@ -0,0 +1,75 @@
+package handle
+
+import (
+	"bytes"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"testing"
+)
+
+// the digest of SignatureVerifier.makeSignatureHash:
+// keccak256(abi.encodePacked(hex"1900", target, expires, keccak256(request), keccak256(result)))
+func TestCcipSignatureHash(t *testing.T) {
+	sender := ethcommon.HexToAddress("0xc1735677a60884abbcf72295e88d47764beda282")
+	request := hexutil.MustDecode("0x9061b92300000000")
+	result := ethcommon.LeftPadBytes([]byte{1}, 32)
+	res := ccipSignatureHash(sender, 1700000000, request, result)
+	if hexutil.Encode(res) != "0xef4fb15c9b61f9cdd2a41bd0e6e868472c9c624fe3e6639d520a705985caa3df" {
+		t.Fatal("hash:", hexutil.Encode(res))
+	}
+}
+
+// resolveWithProof decodes the response and recovers the signer with ECDSA.recover, v being 27 or 28
+func TestCcipSign(t *testing.T) {
+	key, err := crypto.HexToECDSA("4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sender := ethcommon.HexToAddress("0xc1735677a60884abbcf72295e88d47764beda282")
+	request := hexutil.MustDecode("0x9061b92300000000")
+	result := ethcommon.LeftPadBytes([]byte{1}, 32)
+	res, err := ccipSign(key, sender, 1700000000, request, result)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	args, err := abi.Arguments{{Type: ccipTypeBytes}, {Type: ccipTypeUint64}, {Type: ccipTypeBytes}}.Unpack(res)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig := args[2].([]byte)
+	if !bytes.Equal(args[0].([]byte), result) || args[1].(uint64) != 1700000000 || len(sig) != 65 {
+		t.Fatal("response:", args)
+	} else if sig[64] != 27 && sig[64] != 28 {
+		t.Fatal("v:", sig[64])
+	}
+	sig = append(append([]byte{}, sig[:64]...), sig[64]-27)
+	pub, err := crypto.SigToPub(ccipSignatureHash(sender, 1700000000, request, result), sig)
+	if err != nil {
+		t.Fatal(err)
+	} else if crypto.PubkeyToAddress(*pub) != crypto.PubkeyToAddress(key.PublicKey) {
+		t.Fatal("signer:", crypto.PubkeyToAddress(*pub).Hex())
+	}
+}
+
+func TestDecodeDnsName(t *testing.T) {
+	for _, v := range []struct {
+		in   string
+		name string
+		ok   bool
+	}{
+		{"\x05alice\x03bit\x00", "alice.bit", true},
+		{"\x03sub\x05alice\x03bit\x03eth\x00", "sub.alice.bit.eth", true},
+		{"\x00", "", true},
+		{"\x05alice\x03bit", "", false}, // no root label
+		{"\x05ali", "", false},          // label longer than the name
+		{"", "", false},
+	} {
+		name, err := decodeDnsName([]byte(v.in))
+		if (err == nil) != v.ok || name != v.name {
+			t.Fatalf("%q: %q %v", v.in, name, err)
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package handle
+
+import (
+	"context"
+	"das-account-indexer/tables"
+	"fmt"
+	"github.com/dotbitHQ/das-lib/common"
+	"github.com/dotbitHQ/das-lib/core"
+	"github.com/dotbitHQ/das-lib/molecule"
+	"github.com/dotbitHQ/das-lib/smt"
+	"github.com/dotbitHQ/das-lib/witness"
+	"github.com/nervosnetwork/ckb-sdk-go/crypto/blake2b"
+	"github.com/nervosnetwork/ckb-sdk-go/types"
+)
+
+// proof material asked for with with_proof, what a light client needs to check an answer against ckb headers:
+// the transaction that created the cell, its merkle proof in the block, and the cell itself
+
+type CellProof struct {
+	BlockNumber uint64                  `json:"block_number"`
+	BlockHash   string                  `json:"block_hash"`
+	TxHash      string                  `json:"tx_hash"`
+	WitnessHash string                  `json:"witness_hash"` // blake2b of the transaction with its witnesses
+	TxProof     *types.TransactionProof `json:"tx_proof"`     // as get_transaction_proof gives it
+	Index       uint                    `json:"index"`
+	Output      *ProofCellOutput        `json:"output"`
+	OutputData  string                  `json:"output_data"`
+	Smt         *ReverseSmtProof        `json:"smt,omitempty"` // only for smt reverse records, the cell is then the root cell
+}
+
+type ProofCellOutput struct {
+	Capacity uint64       `json:"capacity"`
+	Lock     *ProofScript `json:"lock"`
+	Type     *ProofScript `json:"type"`
+}
+
+type ProofScript struct {
+	CodeHash string               `json:"code_hash"`
+	HashType types.ScriptHashType `json:"hash_type"`
+	Args     string               `json:"args"`
+}
+
+func toProofScript(script *types.Script) *ProofScript {
+	if script == nil {
+		return nil
+	}
+	return &ProofScript{CodeHash: script.CodeHash.Hex(), HashType: script.HashType, Args: common.Bytes2Hex(script.Args)}
+}
+
+type ReverseSmtProof struct {
+	RecordIndex uint   `json:"record_index"` // of the record in the reverse smt witnesses of the transaction
+	Key         string `json:"key"`          // blake2b of the address
+	Value       string `json:"value"`        // blake2b of the nonce, u32 little endian, and the account
+	Root        string `json:"root"`         // once the record is applied
+	Proof       string `json:"proof"`        // of the key in root
+}
+
+func txWitnessHash(tx *types.Transaction) (string, error) {
+	raw, err := tx.Serialize()
+	if err != nil {
+		return "", err
+	}
+	witnesses := make([][]byte, 0, len(tx.Witnesses))
+	for _, v := range tx.Witnesses {
+		witnesses = append(witnesses, types.SerializeBytes(v))
+	}
+	hash, err := blake2b.Blake256(types.SerializeTable([][]byte{raw, types.SerializeDynVec(witnesses)}))
+	if err != nil {
+		return "", err
+	}
+	return common.Bytes2Hex(hash), nil
+}
+
+// getTxProof gives the proof of a committed transaction, without the cell
+func (h *HttpHandle) getTxProof(ctx context.Context, txHash types.Hash) (*CellProof, *types.Transaction, error) {
+	res, err := h.DasCore.Client().GetTransaction(ctx, txHash)
+	if err != nil {
+		return nil, nil, fmt.Errorf("GetTransaction err: %s", err.Error())
+	} else if res.TxStatus.Status != types.TransactionStatusCommitted || res.TxStatus.BlockHash == nil {
+		return nil, nil, fmt.Errorf("tx not committed: %s", txHash.Hex())
+	}
+	header, err := h.DasCore.Client().GetHeader(ctx, *res.TxStatus.BlockHash)
+	if err != nil {
+		return nil, nil, fmt.Errorf("GetHeader err: %s", err.Error())
+	}
+	txProof, err := h.DasCore.Client().GetTransactionProof(ctx, []string{txHash.Hex()}, res.TxStatus.BlockHash)
+	if err != nil {
+		return nil, nil, fmt.Errorf("GetTransactionProof err: %s", err.Error())
+	}
+	witnessHash, err := txWitnessHash(res.Transaction)
+	if err != nil {
+		return nil, nil, fmt.Errorf("txWitnessHash err: %s", err.Error())
+	}
+	return &CellProof{
+		BlockNumber: header.Number,
+		BlockHash:   header.Hash.Hex(),
+		TxHash:      txHash.Hex(),
+		WitnessHash: witnessHash,
+		TxProof:     txProof,
+	}, res.Transaction, nil
+}
+
+func (p *CellProof) setCell(tx *types.Transaction, index uint) error {
+	if index >= uint(len(tx.Outputs)) || index >= uint(len(tx.OutputsData)) {
+		return fmt.Errorf("output not exist: %s-%d", p.TxHash, index)
+	}
+	p.Index = index
+	p.Output = &ProofCellOutput{
+		Capacity: tx.Outputs[index].Capacity,
+		Lock:     toProofScript(tx.Outputs[index].Lock),
+		Type:     toProofScript(tx.Outputs[index].Type),
+	}
+	p.OutputData = common.Bytes2Hex(tx.OutputsData[index])
+	return nil
+}
+
+func (h *HttpHandle) getCellProof(ctx context.Context, outpoint string) (*CellProof, error) {
+	op := common.String2OutPointStruct(outpoint)
+	proof, tx, err := h.getTxProof(ctx, op.TxHash)
+	if err != nil {
+		return nil, err
+	}
+	if err = proof.setCell(tx, op.Index); err != nil {
+		return nil, err
+	}
+	return proof, nil
+}
+
+// getReverseProof gives the reverse record cell, or for smt reverse records the root cell with the key
+// and value of the record, the outpoint of which points at its index in the witnesses
+func (h *HttpHandle) getReverseProof(ctx context.Context, reverse *tables.TableReverseInfo) (*CellProof, error) {
+	if reverse.ReverseType != tables.ReverseTypeSmt {
+		return h.getCellProof(ctx, reverse.Outpoint)
+	}
+	op := common.String2OutPointStruct(reverse.Outpoint)
+	proof, tx, err := h.getTxProof(ctx, op.TxHash)
+	if err != nil {
+		return nil, err
+	}
+
+	contract, err := core.GetDasContractInfo(common.DasContractNameReverseRecordRootCellType)
+	if err != nil {
+		return nil, fmt.Errorf("GetDasContractInfo err: %s", err.Error())
+	}
+	for i, v := range tx.Outputs {
+		if v.Type != nil && contract.IsSameTypeId(v.Type.CodeHash) {
+			if err = proof.setCell(tx, uint(i)); err != nil {
+				return nil, err
+			}
+			break
+		}
+	}
+	if proof.Output == nil {
+		return nil, fmt.Errorf("reverse record root cell not exist: %s", proof.TxHash)
+	}
+
+	var records []*witness.ReverseSmtRecord
+	if err = witness.ParseFromTx(tx, common.ActionDataTypeReverseSmt, &records); err != nil {
+		return nil, fmt.Errorf("ParseFromTx err: %s", err.Error())
+	} else if op.Index >= uint(len(records)) {
+		return nil, fmt.Errorf("reverse smt record not exist: %s", reverse.Outpoint)
+	}
+	if proof.Smt, err = reverseSmtProof(op.Index, records[op.Index]); err != nil {
+		return nil, err
+	}
+	return proof, nil
+}
+
+// reverseSmtProof gives the key and value of a reverse smt record, checked against the root it leads to,
+// so that a proof a client cannot verify is never handed out
+func reverseSmtProof(index uint, record *witness.ReverseSmtRecord) (*ReverseSmtProof, error) {
+	key, err := blake2b.Blake256(record.Address)
+	if err != nil {
+		return nil, fmt.Errorf("blake2b key err: %s", err.Error())
+	}
+	nonce := molecule.GoU32ToMoleculeU32(record.PrevNonce + 1)
+	value, err := blake2b.Blake256(append(nonce.RawData(), []byte(record.NextAccount)...))
+	if err != nil {
+		return nil, fmt.Errorf("blake2b value err: %s", err.Error())
+	}
+	compiled := smt.CompiledMerkleProof(record.Proof)
+	if ok, err := smt.Verify(record.NextRoot, &compiled, []smt.H256{key}, []smt.H256{value}); err != nil {
+		return nil, fmt.Errorf("smt.Verify err: %s", err.Error())
+	} else if !ok {
+		return nil, fmt.Errorf("reverse smt record not in its root: %d", index)
+	}
+	return &ReverseSmtProof{
+		RecordIndex: index,
+		Key:         common.Bytes2Hex(key),
+		Value:       common.Bytes2Hex(value),
+		Root:        common.Bytes2Hex(record.NextRoot),
+		Proof:       common.Bytes2Hex(record.Proof),
+	}, nil
+}
@@ -0,0 +1,60 @@
+package handle
+
+import (
+	"github.com/dotbitHQ/das-lib/common"
+	"github.com/dotbitHQ/das-lib/witness"
+	"github.com/nervosnetwork/ckb-sdk-go/types"
+	"testing"
+)
+
+// the first record of a reverse smt, from the das-lib witness tests, its proof takes the empty tree to NextRoot
+const testReverseSmtWitness = "0x6461730a0000000400000001000000060000007570646174654100000006b3abdf1a885d2a4741d39250a1080d66e3ba47add98c091574b1feb886a68e20e587add97c600064f0cace958671ebabe83c351f5d6265f1808d16e2ec653601010000000314000000deefc10a42cd84c072f2b0e2fa99061a74a0698c030000004c4f00000000000000000020000000b4bdcdec0653e52b55db4567a303cf8df35392e9aa687667808ca3cac3cfa5e00f000000726576657273652d736d742e626974"
+
+func testReverseSmtRecord(t *testing.T) *witness.ReverseSmtRecord {
+	tx := &types.Transaction{Witnesses: [][]byte{common.Hex2Bytes(testReverseSmtWitness)}}
+	var records []*witness.ReverseSmtRecord
+	if err := witness.ParseFromTx(tx, common.ActionDataTypeReverseSmt, &records); err != nil {
+		t.Fatal(err)
+	} else if len(records) != 1 {
+		t.Fatal("records:", len(records))
+	}
+	return records[0]
+}
+
+func TestReverseSmtProof(t *testing.T) {
+	record := testReverseSmtRecord(t)
+	if record.NextAccount != "reverse-smt.bit" || record.PrevNonce != 0 {
+		t.Fatal("record:", record.NextAccount, record.PrevNonce)
+	}
+	proof, err := reverseSmtProof(0, record)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range []struct {
+		name, got, want string
+	}{
+		{"key", proof.Key, "0x8681912e59278a637dbbd9db6ab62bb3b5e32aecf7a76772347d04742d0f4fc0"},
+		{"value", proof.Value, "0x57f2e68d6c982d02c0495307b820107448ae7ab3de841ec072b7250c90efd55e"},
+		{"root", proof.Root, "0xb4bdcdec0653e52b55db4567a303cf8df35392e9aa687667808ca3cac3cfa5e0"},
+		{"proof", proof.Proof, "0x4c4f00"},
+	} {
+		if v.got != v.want {
+			t.Fatal(v.name, v.got)
+		}
+	}
+
+	// a value the root does not hold is refused instead of handed out
+	for _, tamper := range []func(r *witness.ReverseSmtRecord){
+		func(r *witness.ReverseSmtRecord) { r.NextAccount = "reverse-smt01.bit" },
+		func(r *witness.ReverseSmtRecord) { r.PrevNonce = 1 },
+		func(r *witness.ReverseSmtRecord) {
+			r.Address = common.Hex2Bytes("0xdeefc10a42cd84c072f2b0e2fa99061a74a0698d")
+		},
+	} {
+		record = testReverseSmtRecord(t)
+		tamper(record)
+		if _, err = reverseSmtProof(0, record); err == nil {
+			t.Fatal("tampered record verified:", record.NextAccount, record.PrevNonce, common.Bytes2Hex(record.Address))
+		}
+	}
+}
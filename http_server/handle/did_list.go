@@ -3,15 +3,11 @@ package handle
 import (
 	"context"
 	"das-account-indexer/tables"
-	"encoding/json"
 	"fmt"
 	"github.com/dotbitHQ/das-lib/common"
 	"github.com/dotbitHQ/das-lib/core"
 	"github.com/dotbitHQ/das-lib/http_api"
-	"github.com/gin-gonic/gin"
 	"github.com/nervosnetwork/ckb-sdk-go/address"
-	"github.com/scorpiotzh/toolib"
-	"net/http"
 )
 
 type ReqDidList struct {
@@ -21,8 +17,9 @@ type ReqDidList struct {
 }
 
 type RespDidList struct {
-	Total int64     `json:"total"`
-	List  []DidData `json:"did_list"`
+	Total      int64     `json:"total"`
+	List       []DidData `json:"did_list"`
+	NextCursor string    `json:"next_cursor"`
 }
 
 type DidData struct {
@@ -32,49 +29,6 @@ type DidData struct {
 	ExpiredAt uint64 `json:"expired_at"`
 }
 
-func (h *HttpHandle) JsonRpcDidList(ctx *gin.Context, p json.RawMessage, apiResp *http_api.ApiResp) {
-	var req []ReqDidList
-	err := json.Unmarshal(p, &req)
-	if err != nil {
-		log.Error("json.Unmarshal err:", err.Error())
-		apiResp.ApiRespErr(http_api.ApiCodeParamsInvalid, "params invalid")
-		return
-	}
-	if len(req) != 1 {
-		log.Error("len(req) is :", len(req))
-		apiResp.ApiRespErr(http_api.ApiCodeParamsInvalid, "params invalid")
-		return
-	}
-
-	if err = h.doDidList(ctx, &req[0], apiResp); err != nil {
-		log.Error("doDidList err:", err.Error())
-	}
-}
-
-func (h *HttpHandle) DidList(ctx *gin.Context) {
-	var (
-		funcName = "DidList"
-		clientIp = GetClientIp(ctx)
-		req      ReqDidList
-		apiResp  http_api.ApiResp
-		err      error
-	)
-
-	if err := ctx.ShouldBindJSON(&req); err != nil {
-		log.Error("ShouldBindJSON err: ", err.Error(), funcName, clientIp, ctx.Request.Context())
-		apiResp.ApiRespErr(http_api.ApiCodeParamsInvalid, "params invalid")
-		ctx.JSON(http.StatusOK, apiResp)
-		return
-	}
-	log.Info("ApiReq:", funcName, clientIp, toolib.JsonString(req), ctx.Request.Context())
-
-	if err = h.doDidList(ctx.Request.Context(), &req, &apiResp); err != nil {
-		log.Error(ctx.Request.Context(), "doDidList err:", err.Error(), funcName, clientIp)
-	}
-
-	ctx.JSON(http.StatusOK, apiResp)
-}
-
 func (h *HttpHandle) doDidList(ctx context.Context, req *ReqDidList, apiResp *http_api.ApiResp) error {
 	var resp RespDidList
 	data := make([]DidData, 0)
@@ -100,7 +54,12 @@ func (h *HttpHandle) doDidList(ctx context.Context, req *ReqDidList, apiResp *ht
 		args = common.Bytes2Hex(addrHex.ParsedAddress.Script.Args)
 	}
 
-	res, err := h.DbDao.QueryDidCell(args, req.DidType, req.GetLimit(), req.GetOffset())
+	cursor, err := req.GetCursor()
+	if err != nil {
+		apiResp.ApiRespErr(http_api.ApiCodeParamsInvalid, "cursor invalid")
+		return nil
+	}
+	res, err := h.DbDao.QueryDidCell(args, req.DidType, cursor, req.GetLimit(), req.GetOffset())
 	if err != nil {
 		apiResp.ApiRespErr(http_api.ApiCodeDbError, "search did cell list err")
 		return fmt.Errorf("SearchAccountList err: %s", err.Error())
@@ -115,6 +74,9 @@ func (h *HttpHandle) doDidList(ctx context.Context, req *ReqDidList, apiResp *ht
 		data = append(data, temp)
 	}
 	resp.List = data
+	if l := len(res); l > 0 {
+		resp.NextCursor = req.NextCursor(l, res[l-1].Account, res[l-1].Id)
+	}
 
 	total, err := h.DbDao.QueryDidCellTotal(args, req.DidType)
 	if err != nil {
@@ -0,0 +1,297 @@
+package handle
+
+import (
+	"context"
+	"das-account-indexer/config"
+	"das-account-indexer/tables"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/dotbitHQ/das-lib/common"
+	"github.com/dotbitHQ/das-lib/core"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Universal Resolver driver for did:bit, GET /1.0/identifiers/did:bit:alice.bit returns the DID Core
+// document of alice.bit, built from the owner and manager locks and the records of the account
+
+const (
+	didMethodPrefix          = "did:bit:"
+	didContentTypeDocument   = "application/did+ld+json"
+	didContentTypeResolution = `application/ld+json;profile="https://w3id.org/did-resolution"`
+)
+
+type DidResolution struct {
+	Context               string                 `json:"@context"`
+	DidDocument           *DidDocument           `json:"didDocument"`
+	DidResolutionMetadata map[string]interface{} `json:"didResolutionMetadata"`
+	DidDocumentMetadata   map[string]interface{} `json:"didDocumentMetadata"`
+}
+
+type DidDocument struct {
+	Context            []string                `json:"@context"`
+	Id                 string                  `json:"id"`
+	Controller         string                  `json:"controller,omitempty"`
+	VerificationMethod []DidVerificationMethod `json:"verificationMethod"`
+	Authentication     []string                `json:"authentication"`
+	AssertionMethod    []string                `json:"assertionMethod"`
+	Service            []DidService            `json:"service"`
+}
+
+type DidVerificationMethod struct {
+	Id                  string            `json:"id"`
+	Type                string            `json:"type"`
+	Controller          string            `json:"controller"`
+	BlockchainAccountId string            `json:"blockchainAccountId,omitempty"`
+	PublicKeyJwk        map[string]string `json:"publicKeyJwk,omitempty"`
+}
+
+type DidService struct {
+	Id              string `json:"id"`
+	Type            string `json:"type"`
+	ServiceEndpoint string `json:"serviceEndpoint"`
+}
+
+type didError struct {
+	status int
+	code   string // error of the did resolution metadata
+}
+
+func (e *didError) Error() string {
+	return e.code
+}
+
+func (h *HttpHandle) DidResolve(ctx *gin.Context) {
+	var (
+		funcName = "DidResolve"
+		clientIp = GetClientIp(ctx)
+		did      = ctx.Param("did")
+	)
+	log.Info("ApiReq:", funcName, clientIp, did, ctx.Request.Context())
+
+	res, err := h.doDidResolve(ctx.Request.Context(), did)
+	status := http.StatusOK
+	if err != nil {
+		status = http.StatusInternalServerError
+		code := "internalError"
+		if e, ok := err.(*didError); ok {
+			status, code = e.status, e.code
+		} else {
+			log.Error("doDidResolve err:", err.Error(), funcName, clientIp, ctx.Request.Context())
+		}
+		res = &DidResolution{DidResolutionMetadata: map[string]interface{}{"error": code}, DidDocumentMetadata: map[string]interface{}{}}
+	} else if deactivated, _ := res.DidDocumentMetadata["deactivated"].(bool); deactivated {
+		status = http.StatusGone
+	}
+	res.Context = "https://w3id.org/did-resolution/v1"
+
+	if status == http.StatusOK && strings.Contains(ctx.GetHeader("Accept"), didContentTypeDocument) {
+		ctx.Render(status, didJson{contentType: didContentTypeDocument, data: res.DidDocument})
+		return
+	}
+	ctx.Render(status, didJson{contentType: didContentTypeResolution, data: res})
+}
+
+func (h *HttpHandle) doDidResolve(ctx context.Context, did string) (*DidResolution, error) {
+	if !strings.HasPrefix(did, "did:") {
+		return nil, &didError{http.StatusBadRequest, "invalidDid"}
+	} else if !strings.HasPrefix(did, didMethodPrefix) {
+		return nil, &didError{http.StatusNotImplemented, "methodNotSupported"}
+	}
+	account := strings.ToLower(strings.TrimPrefix(did, didMethodPrefix))
+	if !strings.HasSuffix(account, common.DasAccountSuffix) {
+		account += common.DasAccountSuffix
+	}
+	if account == common.DasAccountSuffix || strings.ContainsAny(account, " _/?#") {
+		return nil, &didError{http.StatusBadRequest, "invalidDid"}
+	}
+	did = didMethodPrefix + account
+
+	accountId := common.Bytes2Hex(common.GetAccountIdByAccount(account))
+	acc, err := h.DbDao.FindAccountInfoByAccountId(accountId)
+	if err != nil {
+		return nil, fmt.Errorf("FindAccountInfoByAccountId err: %s", err.Error())
+	} else if acc.Id == 0 {
+		return nil, &didError{http.StatusNotFound, "notFound"}
+	}
+	list, err := h.DbDao.FindAccountRecordsByAccountId(accountId)
+	if err != nil {
+		return nil, fmt.Errorf("FindAccountRecordsByAccountId err: %s", err.Error())
+	}
+
+	doc := DidDocument{
+		Context:            []string{"https://www.w3.org/ns/did/v1", "https://w3id.org/security/suites/secp256k1recovery-2020/v2", "https://w3id.org/security/suites/jws-2020/v1"},
+		Id:                 did,
+		VerificationMethod: make([]DidVerificationMethod, 0),
+		Authentication:     make([]string, 0),
+		AssertionMethod:    make([]string, 0),
+		Service:            didServices(did, list),
+	}
+	if acc.Status == tables.AccountStatusOnUpgrade {
+		// the account has been upgraded to a DID cell, which is controlled by its lock alone
+		_, _, owner, err := h.resolveOwner(&acc)
+		if err != nil {
+			return nil, fmt.Errorf("resolveOwner err: %s", err.Error())
+		} else if owner != "" {
+			vm := didVerificationMethod(did, "owner", common.DasAlgorithmIdAnyLock, common.ChainTypeCkb, acc.Owner, owner)
+			doc.VerificationMethod = append(doc.VerificationMethod, vm)
+			doc.Authentication = append(doc.Authentication, vm.Id)
+			doc.AssertionMethod = append(doc.AssertionMethod, vm.Id)
+		}
+	} else {
+		for _, v := range []struct {
+			fragment    string
+			algorithmId common.DasAlgorithmId
+			subAid      common.DasSubAlgorithmId
+			chainType   common.ChainType
+			hex         string
+		}{
+			{"owner", acc.OwnerAlgorithmId, acc.OwnerSubAid, acc.OwnerChainType, acc.Owner},
+			{"manager", acc.ManagerAlgorithmId, acc.ManagerSubAid, acc.ManagerChainType, acc.Manager},
+		} {
+			addr, err := h.DasCore.Daf().HexToNormal(core.DasAddressHex{
+				DasAlgorithmId:    v.algorithmId,
+				DasSubAlgorithmId: v.subAid,
+				AddressHex:        v.hex,
+				ChainType:         v.chainType,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("HexToNormal err: %s", err.Error())
+			}
+			vm := didVerificationMethod(did, v.fragment, v.algorithmId, v.chainType, v.hex, addr.AddressNormal)
+			doc.VerificationMethod = append(doc.VerificationMethod, vm)
+			doc.AssertionMethod = append(doc.AssertionMethod, vm.Id)
+			// the owner controls the account, the manager only edits its records
+			if v.fragment == "owner" {
+				doc.Authentication = append(doc.Authentication, vm.Id)
+			}
+		}
+	}
+	// the owner controls the account, a sub-account included, its parent has no say over it
+	if len(doc.VerificationMethod) > 0 && doc.VerificationMethod[0].BlockchainAccountId != "" {
+		doc.Controller = "did:pkh:" + doc.VerificationMethod[0].BlockchainAccountId
+	}
+
+	deactivated := acc.ExpiredAt <= uint64(time.Now().Unix())
+	return &DidResolution{
+		DidDocument: &doc,
+		DidResolutionMetadata: map[string]interface{}{
+			"contentType": didContentTypeDocument,
+			"retrieved":   time.Now().UTC().Format(time.RFC3339),
+		},
+		DidDocumentMetadata: map[string]interface{}{
+			"created":     time.Unix(int64(acc.RegisteredAt), 0).UTC().Format(time.RFC3339),
+			"updated":     time.UnixMilli(int64(acc.BlockTimestamp)).UTC().Format(time.RFC3339),
+			"deactivated": deactivated,
+			"expiredAt":   time.Unix(int64(acc.ExpiredAt), 0).UTC().Format(time.RFC3339),
+			"blockNumber": acc.BlockNumber,
+			"outpoint":    acc.Outpoint,
+			"crossChain":  acc.Status == tables.AccountStatusOnLock,
+		},
+	}, nil
+}
+
+// didVerificationMethod describes a lock by the key it is verified with, locks of an address hash
+// are given as a CAIP-10 account id since the key itself is only known once it signs
+func didVerificationMethod(did, fragment string, algorithmId common.DasAlgorithmId, chainType common.ChainType, hex, addr string) DidVerificationMethod {
+	vm := DidVerificationMethod{Id: did + "#" + fragment, Type: "EcdsaSecp256k1RecoveryMethod2020", Controller: did}
+	if algorithmId == common.DasAlgorithmIdEd25519 {
+		vm.Type = "JsonWebKey2020"
+		vm.PublicKeyJwk = map[string]string{"kty": "OKP", "crv": "Ed25519", "x": base64.RawURLEncoding.EncodeToString(common.Hex2Bytes(hex))}
+		return vm
+	}
+	switch chainType {
+	case common.ChainTypeEth, common.ChainTypeTron, common.ChainTypeDogeCoin, common.ChainTypeBitcoin:
+	default:
+		// ckb, multisig, webauthn and any lock are verified by their lock script
+		if algorithmId != common.DasAlgorithmIdCkb && algorithmId != common.DasAlgorithmIdCkbSingle {
+			vm.Type = "BlockchainVerificationMethod2021"
+		}
+		chainType = common.ChainTypeCkb
+	}
+	vm.BlockchainAccountId = fmt.Sprintf("%s:%s", didChainId(chainType), addr)
+	return vm
+}
+
+// didChainId is the CAIP-2 id of the chain a lock of chainType is on, for the configured net
+func didChainId(chainType common.ChainType) string {
+	mainNet := config.Cfg.Server.Net == common.DasNetTypeMainNet
+	switch chainType {
+	case common.ChainTypeEth:
+		if mainNet {
+			return "eip155:" + string(common.ChainIdEthMainNet)
+		}
+		return "eip155:" + string(common.ChainIdEthTestNet)
+	case common.ChainTypeTron:
+		if mainNet {
+			return "tron:0x2b6653dc"
+		}
+		return "tron:0xcd8690dc" // nile
+	case common.ChainTypeDogeCoin:
+		if mainNet {
+			return "bip122:1a91e3dace36e2be3bf030a65679fe82"
+		}
+		return "bip122:bb0a78264637406b6360aad926284d54"
+	case common.ChainTypeBitcoin:
+		if mainNet {
+			return "bip122:000000000019d6689c085ae165831e93"
+		}
+		return "bip122:000000000933ea01ad0ee984209779ba"
+	}
+	if mainNet {
+		return "ckb:mainnet"
+	}
+	return "ckb:testnet"
+}
+
+// didServices lists the website, dweb, profile and address records as services
+func didServices(did string, list []tables.TableRecordsInfo) []DidService {
+	var res = make([]DidService, 0)
+	var ids = make(map[string]int)
+	for _, v := range list {
+		value := strings.TrimSpace(v.Value)
+		if value == "" {
+			continue
+		}
+		var serviceType string
+		switch {
+		case v.Type == "profile" && v.Key == "website":
+			serviceType = "LinkedDomains"
+		case v.Type == "profile":
+			serviceType = "SocialProfile"
+		case v.Type == "dweb":
+			serviceType = "DwebContent"
+			if !strings.Contains(value, "://") {
+				value = fmt.Sprintf("%s://%s", v.Key, value)
+			}
+		case v.Type == "address":
+			serviceType = "BlockchainAddress"
+		default:
+			continue
+		}
+		id := fmt.Sprintf("%s#%s.%s", did, v.Type, v.Key)
+		if ids[id]++; ids[id] > 1 {
+			id = fmt.Sprintf("%s-%d", id, ids[id])
+		}
+		res = append(res, DidService{Id: id, Type: serviceType, ServiceEndpoint: value})
+	}
+	return res
+}
+
+// didJson renders json under the did content types, which gin has no renderer for
+type didJson struct {
+	contentType string
+	data        interface{}
+}
+
+func (r didJson) Render(w http.ResponseWriter) error {
+	r.WriteContentType(w)
+	return json.NewEncoder(w).Encode(r.data)
+}
+
+func (r didJson) WriteContentType(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", r.contentType)
+}
@@ -0,0 +1,128 @@
+package handle
+
+import (
+	"context"
+	"das-account-indexer/tables"
+	"fmt"
+	"github.com/dotbitHQ/das-lib/common"
+	"github.com/dotbitHQ/das-lib/core"
+	"github.com/dotbitHQ/das-lib/http_api"
+	"time"
+)
+
+const expiringAccountDays = 30 // default days ahead
+
+type ReqExpiringAccountList struct {
+	core.ChainTypeAddress
+	Role string `json:"role"` // owner,manager
+	Days int    `json:"days"` // expiring within, up to 365
+	Pagination
+}
+
+type RespExpiringAccountList struct {
+	Total       int64             `json:"total"`
+	AccountList []ExpiringAccount `json:"account_list"`
+	NextCursor  string            `json:"next_cursor"`
+}
+
+type ExpiringAccount struct {
+	AccountId   string `json:"account_id"`
+	Account     string `json:"account"`
+	DisplayName string `json:"display_name"`
+	ExpiredAt   uint64 `json:"expired_at"`
+	DaysLeft    int64  `json:"days_left"` // negative in the grace period
+	InGrace     bool   `json:"in_grace"`
+	Source      string `json:"source"` // account, or did_cell for a ckb address
+}
+
+func newExpiringAccount(now int64, accountId, account string, expiredAt uint64, source string) ExpiringAccount {
+	left := int64(expiredAt) - now
+	days := left / secondsPerDay
+	if left > 0 && left%secondsPerDay != 0 {
+		days++
+	}
+	return ExpiringAccount{
+		AccountId:   accountId,
+		Account:     account,
+		DisplayName: FormatDisplayName(account),
+		ExpiredAt:   expiredAt,
+		DaysLeft:    days,
+		InGrace:     left <= 0,
+		Source:      source,
+	}
+}
+
+// doExpiringAccountList lists the accounts of an address expiring within days, or already in the grace period
+func (h *HttpHandle) doExpiringAccountList(ctx context.Context, req *ReqExpiringAccountList, apiResp *http_api.ApiResp) error {
+	var resp RespExpiringAccountList
+	resp.AccountList = make([]ExpiringAccount, 0)
+
+	days := req.Days
+	if days == 0 {
+		days = expiringAccountDays
+	} else if days < 0 || days > 365 {
+		apiResp.ApiRespErr(http_api.ApiCodeParamsInvalid, "days should be 1 to 365")
+		return nil
+	}
+	if req.Role != "" && req.Role != "owner" && req.Role != "manager" {
+		apiResp.ApiRespErr(http_api.ApiCodeParamsInvalid, "role invalid")
+		return nil
+	}
+	addrHex, err := req.FormatChainTypeAddress(h.DasCore.NetType(), true)
+	if err != nil {
+		apiResp.ApiRespErr(http_api.ApiCodeParamsInvalid, "address invalid")
+		return fmt.Errorf("FormatChainTypeAddress err: %s", err.Error())
+	}
+	log.Info(ctx, "doExpiringAccountList:", addrHex.ChainType, addrHex.AddressHex, req.Role, days)
+	cursor, err := req.GetCursor()
+	if err != nil {
+		apiResp.ApiRespErr(http_api.ApiCodeParamsInvalid, "cursor invalid")
+		return nil
+	}
+	now := time.Now().Unix()
+	expiredBefore := uint64(now) + uint64(days)*secondsPerDay
+
+	if addrHex.DasAlgorithmId == common.DasAlgorithmIdAnyLock {
+		// a DID cell has an owner only
+		if req.Role == "manager" {
+			apiResp.ApiRespOK(resp)
+			return nil
+		}
+		list, err := h.DbDao.QueryExpiringDidCell(addrHex.AddressHex, expiredBefore, cursor, req.GetLimit(), req.GetOffset())
+		if err != nil {
+			apiResp.ApiRespErr(http_api.ApiCodeDbError, "find did cell list err")
+			return fmt.Errorf("QueryExpiringDidCell err: %s", err.Error())
+		}
+		for _, v := range list {
+			resp.AccountList = append(resp.AccountList, newExpiringAccount(now, v.AccountId, v.Account, v.ExpiredAt, tables.AccountSourceDid))
+		}
+		if l := len(list); l > 0 {
+			resp.NextCursor = req.NextCursor(l, list[l-1].Account, list[l-1].Id)
+		}
+		resp.Total, err = h.DbDao.QueryExpiringDidCellTotal(addrHex.AddressHex, expiredBefore)
+		if err != nil {
+			apiResp.ApiRespErr(http_api.ApiCodeDbError, "search did cell total err")
+			return fmt.Errorf("QueryExpiringDidCellTotal err: %s", err.Error())
+		}
+	} else {
+		list, err := h.DbDao.FindExpiringAccountList(addrHex.ChainType, addrHex.AddressHex, req.Role, expiredBefore, cursor, req.GetLimit(), req.GetOffset())
+		if err != nil {
+			apiResp.ApiRespErr(http_api.ApiCodeDbError, "find account list err")
+			return fmt.Errorf("FindExpiringAccountList err: %s", err.Error())
+		}
+		for _, v := range list {
+			resp.AccountList = append(resp.AccountList, newExpiringAccount(now, v.AccountId, v.Account, v.ExpiredAt, tables.AccountSourceAccount))
+		}
+		if l := len(list); l > 0 {
+			resp.NextCursor = req.NextCursor(l, list[l-1].Account, list[l-1].Id)
+		}
+		resp.Total, err = h.DbDao.FindTotalExpiringAccountList(addrHex.ChainType, addrHex.AddressHex, req.Role, expiredBefore)
+		if err != nil {
+			apiResp.ApiRespErr(http_api.ApiCodeDbError, "search account total err")
+			return fmt.Errorf("FindTotalExpiringAccountList err: %s", err.Error())
+		}
+	}
+
+	apiResp.ApiRespOK(resp)
+	return nil
+}
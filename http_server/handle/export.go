@@ -0,0 +1,136 @@
+package handle
+
+import (
+	"crypto/subtle"
+	"das-account-indexer/config"
+	"das-account-indexer/dao"
+	"das-account-indexer/export"
+	"fmt"
+	"github.com/dotbitHQ/das-lib/common"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+var exportContentTypes = map[string]string{
+	export.FormatNdjson: "application/x-ndjson",
+	export.FormatCsv:    "text/csv; charset=utf-8",
+}
+
+func (h *HttpHandle) Export(ctx *gin.Context) {
+	var (
+		funcName = "Export"
+		clientIp = GetClientIp(ctx)
+		format   = ctx.DefaultQuery("format", export.FormatNdjson)
+		filter   dao.ExportFilter
+	)
+	log.Info("ApiReq:", funcName, clientIp, ctx.Request.URL.RawQuery, ctx.Request.Context())
+
+	if !exportAuth(ctx) {
+		return
+	}
+	contentType, ok := exportContentTypes[format]
+	if !ok {
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "format should be ndjson or csv"})
+		return
+	}
+	if parent := FormatSharpToDot(strings.TrimSpace(ctx.Query("parent_account"))); parent != "" {
+		filter.ParentAccountId = common.Bytes2Hex(common.GetAccountIdByAccount(parent))
+	}
+	for _, v := range []struct {
+		key   string
+		value *uint64
+	}{
+		{"expired_after", &filter.ExpiredAfter},
+		{"expired_before", &filter.ExpiredBefore},
+		{"since_block", &filter.SinceBlock},
+	} {
+		if str := ctx.Query(v.key); str != "" {
+			n, err := strconv.ParseUint(str, 10, 64)
+			if err != nil {
+				ctx.JSON(http.StatusBadRequest, gin.H{"message": v.key + " invalid"})
+				return
+			}
+			*v.value = n
+		}
+	}
+
+	ctx.Header("Content-Type", contentType)
+	ctx.Status(http.StatusOK)
+	count, err := export.Export(ctx.Request.Context(), h.DbDao, ctx.Writer, format, filter, ctx.Writer.Flush)
+	if err != nil {
+		// the status is already sent, a cut stream is all the client sees
+		log.Error("export.Export err:", err.Error(), funcName, clientIp, count, ctx.Request.Context())
+		return
+	}
+	log.Info("export done:", funcName, clientIp, count)
+}
+
+func (h *HttpHandle) Changefeed(ctx *gin.Context) {
+	var (
+		funcName  = "Changefeed"
+		clientIp  = GetClientIp(ctx)
+		fromBlock uint64
+		toBlock   uint64
+	)
+	log.Info("ApiReq:", funcName, clientIp, ctx.Request.URL.RawQuery, ctx.Request.Context())
+
+	if !exportAuth(ctx) {
+		return
+	}
+	for _, v := range []struct {
+		key   string
+		value *uint64
+	}{
+		{"from_block", &fromBlock},
+		{"to_block", &toBlock},
+	} {
+		n, err := strconv.ParseUint(ctx.Query(v.key), 10, 64)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": v.key + " invalid"})
+			return
+		}
+		*v.value = n
+	}
+	if fromBlock > toBlock {
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "from_block is after to_block"})
+		return
+	}
+	// block info is written once a block is parsed, a range past it could miss rows still being written
+	if block, err := h.DbDao.FindCurrentBlockInfo(); err != nil {
+		log.Error("FindCurrentBlockInfo err:", err.Error(), funcName, clientIp, ctx.Request.Context())
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "failed to get block info"})
+		return
+	} else if toBlock > block.BlockNumber {
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": fmt.Sprintf("to_block should be at most %d", block.BlockNumber)})
+		return
+	} else if earliest := export.ChangefeedEarliestBlock(block.BlockNumber); fromBlock < earliest {
+		ctx.JSON(http.StatusGone, gin.H{"message": fmt.Sprintf("from_block is older than the retention window, earliest block: %d", earliest)})
+		return
+	}
+
+	ctx.Header("Content-Type", exportContentTypes[export.FormatNdjson])
+	ctx.Status(http.StatusOK)
+	count, err := export.Changefeed(ctx.Request.Context(), h.DbDao, ctx.Writer, fromBlock, toBlock, ctx.Writer.Flush)
+	if err != nil {
+		log.Error("export.Changefeed err:", err.Error(), funcName, clientIp, count, ctx.Request.Context())
+		return
+	}
+	log.Info("changefeed done:", funcName, clientIp, fromBlock, toBlock, count)
+}
+
+// exportAuth checks the bearer token shared by the export and changefeed apis, and answers the request when it fails
+func exportAuth(ctx *gin.Context) bool {
+	token := config.Cfg.Export.Token
+	if token == "" {
+		ctx.JSON(http.StatusNotFound, gin.H{"message": "export disabled"})
+		return false
+	}
+	auth := strings.TrimPrefix(ctx.GetHeader("Authorization"), "Bearer ")
+	if subtle.ConstantTimeCompare([]byte(auth), []byte(token)) != 1 {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"message": "unauthorized"})
+		return false
+	}
+	return true
+}
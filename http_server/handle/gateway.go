@@ -0,0 +1,243 @@
+package handle
+
+import (
+	"das-account-indexer/cache"
+	"das-account-indexer/config"
+	"das-account-indexer/tables"
+	"encoding/json"
+	"fmt"
+	"github.com/dotbitHQ/das-lib/common"
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/idna"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// web3 gateway, alice.bit.<domain> serves the dweb content of alice.bit from the configured upstream gateways
+
+const defaultGatewayTtl = 300
+
+// dweb record keys in the order they are served, with the scheme their values may be prefixed by
+var gatewayProtocols = []struct {
+	key    string
+	scheme string
+}{
+	{"ipfs", "ipfs://"},
+	{"ipns", "ipns://"},
+	{"arweave", "ar://"},
+	{"resilio", "resilio://"},
+}
+
+// values of the dweb records, anything else could move the path on the upstream
+var (
+	// cid v0, or v1 in base32, base58btc, base36 or base16
+	gatewayCid = regexp.MustCompile(`^(Qm[1-9A-HJ-NP-Za-km-z]{44}|b[a-z2-7]{50,}|z[1-9A-HJ-NP-Za-km-z]{40,}|k[0-9a-z]{40,}|f[0-9a-f]{50,})$`)
+	// ipns names may also be dnslink domains
+	gatewayDnsLink = regexp.MustCompile(`^([a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?\.)+[a-z]{2,63}$`)
+	// arweave transaction id, base64url of 32 bytes
+	gatewayTxId = regexp.MustCompile(`^[A-Za-z0-9_-]{43}$`)
+	// the rest of the resilio link fragment
+	gatewayResilio = regexp.MustCompile(`^[A-Za-z0-9%._~&=+:-]+$`)
+)
+
+func gatewayValueValid(key, value string) bool {
+	switch key {
+	case "ipfs":
+		return gatewayCid.MatchString(value)
+	case "ipns":
+		return gatewayCid.MatchString(value) || gatewayDnsLink.MatchString(strings.ToLower(value))
+	case "arweave":
+		return gatewayTxId.MatchString(value)
+	case "resilio":
+		return gatewayResilio.MatchString(value)
+	}
+	return false
+}
+
+// gatewayTarget is what an account resolves to, cached for the ttl of the record it comes from
+type gatewayTarget struct {
+	Status    int               `json:"status"`
+	Message   string            `json:"message"`
+	Url       string            `json:"url"`      // the content root on the upstream gateway
+	Redirect  bool              `json:"redirect"` // resilio links are opened by the client, never proxied
+	Addresses []ResolveTextItem `json:"addresses"`
+	Ttl       uint32            `json:"ttl"`
+}
+
+func (h *HttpHandle) Gateway(ctx *gin.Context) {
+	var (
+		funcName = "Gateway"
+		clientIp = GetClientIp(ctx)
+	)
+	account, ok := hostAccount(ctx.Request.Host, config.Cfg.Gateway.Domain)
+	if !ok {
+		ctx.String(http.StatusNotFound, "unknown host")
+		return
+	}
+	log.Info("ApiReq:", funcName, clientIp, account, ctx.Request.URL.String(), ctx.Request.Context())
+
+	target, err := h.gatewayTarget(account)
+	if err != nil {
+		log.Error("gatewayTarget err:", err.Error(), funcName, clientIp, ctx.Request.Context())
+		ctx.String(http.StatusBadGateway, "failed to resolve account")
+		return
+	}
+	ctx.Header("Cache-Control", fmt.Sprintf("public, max-age=%d", target.Ttl))
+	if target.Status != http.StatusOK {
+		ctx.String(target.Status, target.Message)
+		return
+	} else if target.Url == "" {
+		ctx.JSON(http.StatusOK, gin.H{"account": account, "addresses": target.Addresses})
+		return
+	}
+
+	if target.Redirect {
+		ctx.Redirect(http.StatusFound, target.Url)
+		return
+	}
+	upstream, err := url.Parse(target.Url)
+	if err != nil {
+		log.Error("url.Parse err:", err.Error(), funcName, target.Url, ctx.Request.Context())
+		ctx.String(http.StatusBadGateway, "upstream invalid")
+		return
+	}
+	if config.Cfg.Gateway.Redirect {
+		upstream.Path = strings.TrimSuffix(upstream.Path, "/") + ctx.Request.URL.Path
+		upstream.RawQuery = ctx.Request.URL.RawQuery
+		ctx.Redirect(http.StatusFound, upstream.String())
+		return
+	}
+
+	proxy := httputil.NewSingleHostReverseProxy(upstream)
+	director := proxy.Director
+	proxy.Director = func(req *http.Request) {
+		director(req)
+		req.Host = upstream.Host
+	}
+	proxy.ErrorHandler = func(w http.ResponseWriter, req *http.Request, err error) {
+		log.Error("proxy err:", err.Error(), funcName, target.Url, req.Context())
+		w.WriteHeader(http.StatusBadGateway)
+	}
+	proxy.ServeHTTP(ctx.Writer, ctx.Request)
+}
+
+// hostAccount returns the account a host such as alice.bit.<domain> is for
+func hostAccount(host, domain string) (string, bool) {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	domain = strings.ToLower(strings.Trim(domain, "."))
+	if domain == "" || !strings.HasSuffix(host, "."+domain) {
+		return "", false
+	}
+	account, err := idna.ToUnicode(strings.TrimSuffix(host, "."+domain))
+	if err != nil || !strings.HasSuffix(account, common.DasAccountSuffix) {
+		return "", false
+	}
+	return account, true
+}
+
+func (h *HttpHandle) gatewayTarget(account string) (*gatewayTarget, error) {
+	key := "gateway:" + account
+	var target gatewayTarget
+	if str, err := h.Cache.Get(key); err == nil {
+		if err = json.Unmarshal([]byte(str), &target); err == nil {
+			return &target, nil
+		}
+	} else if err != cache.ErrNil {
+		log.Warn("gateway cache Get err:", err.Error())
+	}
+
+	target.Status, target.Ttl = http.StatusOK, config.Cfg.Gateway.Ttl
+	if target.Ttl == 0 {
+		target.Ttl = defaultGatewayTtl
+	}
+	accountId := common.Bytes2Hex(common.GetAccountIdByAccount(account))
+	accountInfo, err := h.DbDao.FindAccountInfoByAccountId(accountId)
+	if err != nil {
+		return nil, fmt.Errorf("FindAccountInfoByAccountId err: %s", err.Error())
+	} else if gatewayAccountStatus(&target, &accountInfo) {
+		list, err := h.DbDao.FindAccountRecordsByAccountId(accountId)
+		if err != nil {
+			return nil, fmt.Errorf("FindAccountRecordsByAccountId err: %s", err.Error())
+		}
+		gatewayContent(&target, list)
+	}
+
+	if bys, err := json.Marshal(&target); err == nil {
+		if err = h.Cache.Set(key, string(bys), time.Duration(target.Ttl)*time.Second); err != nil {
+			log.Warn("gateway cache Set err:", err.Error())
+		}
+	}
+	return &target, nil
+}
+
+// gatewayAccountStatus sets the status of an account that cannot be served, false for one
+func gatewayAccountStatus(target *gatewayTarget, accountInfo *tables.TableAccountInfo) bool {
+	if accountInfo.Id == 0 {
+		target.Status, target.Message = http.StatusNotFound, "account not exist"
+	} else if accountInfo.Status == tables.AccountStatusOnLock {
+		target.Status, target.Message = http.StatusLocked, "account cross-chain"
+	} else if accountInfo.ExpiredAt <= uint64(time.Now().Unix()) {
+		target.Status, target.Message = http.StatusGone, "account expired"
+	} else {
+		return true
+	}
+	return false
+}
+
+// gatewayContent picks the first dweb record with an upstream configured, an account without one
+// is served its address records
+func gatewayContent(target *gatewayTarget, list []tables.TableRecordsInfo) {
+	upstreams := map[string]string{
+		"ipfs":    config.Cfg.Gateway.Ipfs,
+		"ipns":    config.Cfg.Gateway.Ipfs,
+		"arweave": config.Cfg.Gateway.Arweave,
+		"resilio": config.Cfg.Gateway.Resilio,
+	}
+	for _, p := range gatewayProtocols {
+		upstream := strings.TrimSuffix(upstreams[p.key], "/")
+		if upstream == "" {
+			continue
+		}
+		for _, v := range list {
+			value := strings.TrimPrefix(strings.TrimSpace(v.Value), p.scheme)
+			if v.Type != "dweb" || v.Key != p.key || value == "" {
+				continue
+			}
+			if !gatewayValueValid(p.key, value) {
+				target.Status, target.Message = http.StatusBadRequest, fmt.Sprintf("dweb.%s record invalid", p.key)
+				return
+			}
+			switch p.key {
+			case "ipfs", "ipns":
+				target.Url = fmt.Sprintf("%s/%s/%s", upstream, p.key, value)
+			case "arweave":
+				target.Url = fmt.Sprintf("%s/%s", upstream, value)
+			case "resilio":
+				target.Url, target.Redirect = upstream+value, true
+			}
+			if ttl, err := strconv.ParseUint(strings.TrimSpace(v.Ttl), 10, 32); err == nil && ttl > 0 {
+				target.Ttl = uint32(ttl)
+			}
+			return
+		}
+	}
+
+	target.Addresses = make([]ResolveTextItem, 0)
+	for _, v := range list {
+		if v.Type == "address" {
+			target.Addresses = append(target.Addresses, ResolveTextItem{Key: v.Key, Value: v.Value})
+		}
+	}
+	if len(target.Addresses) == 0 {
+		target.Status, target.Message = http.StatusNotFound, "no content record"
+	}
+}
@@ -0,0 +1,179 @@
+package handle
+
+import (
+	"das-account-indexer/cache"
+	"das-account-indexer/config"
+	"das-account-indexer/tables"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+const (
+	testGatewayCid  = "QmYwAPJzv5CZsnA625s3Xf2nemtYgPpHdWEz79ojWnPbdG"
+	testGatewayTxId = "bNbA3TEQVL60xlgCcqdz4ZPHFZ711cZ3hmkpGttDt_U"
+)
+
+// testGatewayAccount puts what gatewayTarget finds for an account and its records in the cache,
+// the handler then serves it without a database
+func testGatewayAccount(t *testing.T, h *HttpHandle, account string, accountInfo tables.TableAccountInfo, records ...tables.TableRecordsInfo) {
+	target := gatewayTarget{Status: http.StatusOK, Ttl: defaultGatewayTtl}
+	if gatewayAccountStatus(&target, &accountInfo) {
+		gatewayContent(&target, records)
+	}
+	bys, err := json.Marshal(&target)
+	if err != nil {
+		t.Fatal(err)
+	} else if err = h.Cache.Set("gateway:"+account, string(bys), time.Minute); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGateway(t *testing.T) {
+	var got *http.Request
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		_, _ = w.Write([]byte("content"))
+	}))
+	defer upstream.Close()
+	upstreamUrl, _ := url.Parse(upstream.URL)
+
+	old := config.Cfg.Gateway
+	defer func() { config.Cfg.Gateway = old }()
+	config.Cfg.Gateway.Domain = "bit.cc"
+	config.Cfg.Gateway.Ipfs = upstream.URL
+	config.Cfg.Gateway.Arweave = upstream.URL + "/ar/"
+	config.Cfg.Gateway.Resilio = "https://link.resilio.com/#"
+
+	h := &HttpHandle{Cache: cache.NewTieredCache(100, nil)}
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.NoRoute(h.Gateway)
+
+	live := tables.TableAccountInfo{Id: 1, ExpiredAt: uint64(time.Now().Unix()) + 86400}
+	dweb := func(key, value string) tables.TableRecordsInfo {
+		return tables.TableRecordsInfo{Type: "dweb", Key: key, Value: value}
+	}
+	testGatewayAccount(t, h, "ipfs.bit", live, dweb("ipfs", "ipfs://"+testGatewayCid))
+	testGatewayAccount(t, h, "ipns.bit", live, dweb("ipns", "ipns://docs.example.com"))
+	testGatewayAccount(t, h, "arweave.bit", live, dweb("arweave", "ar://"+testGatewayTxId))
+	testGatewayAccount(t, h, "resilio.bit", live, dweb("resilio", "resilio://f=site&sz=0&t=1&s=ABC"))
+	testGatewayAccount(t, h, "address.bit", live, tables.TableRecordsInfo{Type: "address", Key: "60", Value: "0xc9f53b1d85356b60453f867610888d89a0b667ad"})
+	testGatewayAccount(t, h, "empty.bit", live)
+	testGatewayAccount(t, h, "missing.bit", tables.TableAccountInfo{})
+	testGatewayAccount(t, h, "locked.bit", tables.TableAccountInfo{Id: 1, Status: tables.AccountStatusOnLock, ExpiredAt: live.ExpiredAt})
+	testGatewayAccount(t, h, "expired.bit", tables.TableAccountInfo{Id: 1, ExpiredAt: uint64(time.Now().Unix()) - 86400})
+	testGatewayAccount(t, h, "bad-ipfs.bit", live, dweb("ipfs", testGatewayCid+"/../../admin"))
+	testGatewayAccount(t, h, "bad-arweave.bit", live, dweb("arweave", "x?y=1"))
+	testGatewayAccount(t, h, "bad-ipns.bit", live, dweb("ipns", "docs.example.com/../x"))
+
+	// served for real, the reverse proxy needs a ResponseWriter that is a CloseNotifier
+	gateway := httptest.NewServer(engine)
+	defer gateway.Close()
+	client := &http.Client{CheckRedirect: func(req *http.Request, via []*http.Request) error { return http.ErrUseLastResponse }}
+	serve := func(rawUrl string) *httptest.ResponseRecorder {
+		got = nil
+		req := httptest.NewRequest(http.MethodGet, rawUrl, nil)
+		req.RequestURI, req.URL.Scheme, req.URL.Host = "", "http", gateway.Listener.Addr().String()
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(rawUrl, err)
+		}
+		defer resp.Body.Close()
+		w := httptest.NewRecorder()
+		w.Code = resp.StatusCode
+		for k, v := range resp.Header {
+			w.Header()[k] = v
+		}
+		_, _ = io.Copy(w.Body, resp.Body)
+		return w
+	}
+
+	// proxied with the host of the upstream and the path below the content root
+	for _, v := range []struct {
+		url, path, query string
+	}{
+		{"http://ipfs.bit.bit.cc/docs/a.html?x=1", "/ipfs/" + testGatewayCid + "/docs/a.html", "x=1"},
+		{"http://ipns.bit.bit.cc/", "/ipns/docs.example.com/", ""},
+		{"http://arweave.bit.bit.cc/index.html", "/ar/" + testGatewayTxId + "/index.html", ""},
+	} {
+		w := serve(v.url)
+		if w.Code != http.StatusOK || w.Body.String() != "content" {
+			t.Fatal(v.url, w.Code, w.Body.String())
+		} else if got == nil || got.Host != upstreamUrl.Host || got.URL.Path != v.path || got.URL.RawQuery != v.query {
+			t.Fatal(v.url, "upstream request:", got)
+		} else if w.Header().Get("Cache-Control") != "public, max-age=300" {
+			t.Fatal(v.url, "Cache-Control:", w.Header().Get("Cache-Control"))
+		}
+	}
+
+	if w := serve("http://resilio.bit.bit.cc/"); w.Code != http.StatusFound || w.Header().Get("Location") != "https://link.resilio.com/#f=site&sz=0&t=1&s=ABC" {
+		t.Fatal("resilio:", w.Code, w.Header().Get("Location"))
+	} else if got != nil {
+		t.Fatal("resilio proxied")
+	}
+	if w := serve("http://address.bit.bit.cc/"); w.Code != http.StatusOK || w.Body.String() != `{"account":"address.bit","addresses":[{"key":"60","value":"0xc9f53b1d85356b60453f867610888d89a0b667ad"}]}` {
+		t.Fatal("addresses:", w.Code, w.Body.String())
+	}
+
+	for _, v := range []struct {
+		url  string
+		code int
+	}{
+		{"http://empty.bit.bit.cc/", http.StatusNotFound},
+		{"http://missing.bit.bit.cc/", http.StatusNotFound},
+		{"http://locked.bit.bit.cc/", http.StatusLocked},
+		{"http://expired.bit.bit.cc/", http.StatusGone},
+		{"http://bad-ipfs.bit.bit.cc/", http.StatusBadRequest},
+		{"http://bad-arweave.bit.bit.cc/", http.StatusBadRequest},
+		{"http://bad-ipns.bit.bit.cc/", http.StatusBadRequest},
+		{"http://ipfs.bit.other.cc/", http.StatusNotFound},
+	} {
+		if w := serve(v.url); w.Code != v.code {
+			t.Fatal(v.url, w.Code, w.Body.String())
+		} else if got != nil {
+			t.Fatal(v.url, "proxied")
+		}
+	}
+
+	// with redirect the client is sent to the upstream instead
+	config.Cfg.Gateway.Redirect = true
+	if w := serve("http://ipfs.bit.bit.cc/docs/a.html?x=1"); w.Code != http.StatusFound ||
+		w.Header().Get("Location") != upstream.URL+"/ipfs/"+testGatewayCid+"/docs/a.html?x=1" {
+		t.Fatal("redirect:", w.Code, w.Header().Get("Location"))
+	} else if got != nil {
+		t.Fatal("redirect proxied")
+	}
+}
+
+func TestHostAccount(t *testing.T) {
+	for _, v := range []struct {
+		host, account string
+		ok            bool
+	}{
+		{"alice.bit.bit.cc", "alice.bit", true},
+		{"alice.bit.bit.cc:8080", "alice.bit", true},
+		{"alice.bit.bit.cc.", "alice.bit", true},
+		{"Alice.Bit.BIT.cc", "alice.bit", true},
+		{"sub.alice.bit.bit.cc", "sub.alice.bit", true},
+		{"xn--tda.bit.bit.cc", "ü.bit", true},
+		{"xn--nu8h.bit.bit.cc", "📱.bit", true},
+		{"[::1]:8080", "", false},
+		{"bit.cc", "", false},
+		{"alice.bit.cc", "", false},
+		{"alice.bit.other.cc", "", false},
+		{"alice.eth.bit.cc", "", false},
+	} {
+		if account, ok := hostAccount(v.host, "bit.cc"); account != v.account || ok != v.ok {
+			t.Fatal(v.host, account, ok)
+		}
+	}
+	if _, ok := hostAccount("alice.bit.bit.cc", ""); ok {
+		t.Fatal("no domain")
+	}
+}
@@ -0,0 +1,75 @@
+package handle
+
+import (
+	"context"
+	"das-account-indexer/config"
+	"github.com/gin-gonic/gin"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+	"github.com/scorpiotzh/toolib"
+	"net/http"
+)
+
+type ReqGraphql struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+func (h *HttpHandle) Graphql(ctx *gin.Context) {
+	var (
+		funcName = "Graphql"
+		clientIp = GetClientIp(ctx)
+		req      ReqGraphql
+	)
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		log.Error("ShouldBindJSON err: ", err.Error(), funcName, clientIp, ctx.Request.Context())
+		ctx.JSON(http.StatusOK, gqlErrResult("params invalid"))
+		return
+	}
+	log.Info("ApiReq:", funcName, clientIp, toolib.JsonString(req), ctx.Request.Context())
+
+	ctx.JSON(http.StatusOK, h.doGraphql(ctx.Request.Context(), &req))
+}
+
+func (h *HttpHandle) doGraphql(ctx context.Context, req *ReqGraphql) *graphql.Result {
+	if gqlSchemaErr != nil {
+		log.Error("newGqlSchema err:", gqlSchemaErr.Error())
+		return gqlErrResult("schema unavailable")
+	}
+
+	doc, err := parser.Parse(parser.ParseParams{Source: source.NewSource(&source.Source{Body: []byte(req.Query)})})
+	if err != nil {
+		return &graphql.Result{Errors: []gqlerrors.FormattedError{gqlerrors.FormatError(err)}}
+	}
+	maxDepth, maxCost := config.Cfg.Graphql.MaxDepth, config.Cfg.Graphql.MaxCost
+	if maxDepth <= 0 {
+		maxDepth = defaultGqlMaxDepth
+	}
+	if maxCost <= 0 {
+		maxCost = defaultGqlMaxCost
+	}
+	if err = checkGqlComplexity(doc, req.Variables, maxDepth, maxCost); err != nil {
+		log.Warn(ctx, "checkGqlComplexity:", err.Error())
+		return gqlErrResult(err.Error())
+	}
+
+	res := graphql.Do(graphql.Params{
+		Schema:         gqlSchema,
+		RequestString:  req.Query,
+		VariableValues: req.Variables,
+		OperationName:  req.OperationName,
+		Context:        context.WithValue(ctx, gqlContextKey{}, newGqlContext(h)),
+	})
+	if res.HasErrors() {
+		log.Warn(ctx, "doGraphql:", toolib.JsonString(res.Errors))
+	}
+	return res
+}
+
+func gqlErrResult(msg string) *graphql.Result {
+	return &graphql.Result{Errors: []gqlerrors.FormattedError{gqlerrors.NewFormattedError(msg)}}
+}
@@ -0,0 +1,178 @@
+package handle
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+	"strconv"
+)
+
+const (
+	defaultGqlMaxDepth = 8
+	defaultGqlMaxCost  = 5000
+	// records are not paginated, a list of them is costed as this many items
+	gqlRecordsCost = 10
+)
+
+// gqlComplexity walks the selections of a parsed query the way the executor would and returns
+// its depth and an estimate of the rows it touches, lists multiply the cost of their items
+type gqlComplexity struct {
+	fragments map[string]*ast.FragmentDefinition
+	variables map[string]interface{}
+	visiting  map[string]bool
+}
+
+func newGqlComplexity(doc *ast.Document, variables map[string]interface{}) *gqlComplexity {
+	c := gqlComplexity{
+		fragments: make(map[string]*ast.FragmentDefinition),
+		variables: variables,
+		visiting:  make(map[string]bool),
+	}
+	for _, v := range doc.Definitions {
+		if f, ok := v.(*ast.FragmentDefinition); ok && f.Name != nil {
+			c.fragments[f.Name.Value] = f
+		}
+	}
+	return &c
+}
+
+func checkGqlComplexity(doc *ast.Document, variables map[string]interface{}, maxDepth, maxCost int) error {
+	c := newGqlComplexity(doc, variables)
+	for _, v := range doc.Definitions {
+		op, ok := v.(*ast.OperationDefinition)
+		if !ok {
+			continue
+		}
+		depth, cost := c.selectionSet(op.SelectionSet)
+		if depth > maxDepth {
+			return fmt.Errorf("query depth %d exceeds the limit %d", depth, maxDepth)
+		}
+		if cost > maxCost {
+			return fmt.Errorf("query cost %d exceeds the limit %d", cost, maxCost)
+		}
+	}
+	return nil
+}
+
+func (c *gqlComplexity) selectionSet(set *ast.SelectionSet) (depth, cost int) {
+	if set == nil {
+		return 0, 0
+	}
+	for _, s := range set.Selections {
+		var d, n int
+		switch v := s.(type) {
+		case *ast.Field:
+			d, n = c.selectionSet(v.SelectionSet)
+			d, n = d+1, 1+n*c.multiplier(v)
+		case *ast.InlineFragment:
+			d, n = c.selectionSet(v.SelectionSet)
+		case *ast.FragmentSpread:
+			name := v.Name.Value
+			f, ok := c.fragments[name]
+			if !ok || c.visiting[name] {
+				continue
+			}
+			c.visiting[name] = true
+			d, n = c.selectionSet(f.SelectionSet)
+			c.visiting[name] = false
+		}
+		if d > depth {
+			depth = d
+		}
+		cost += n
+	}
+	return
+}
+
+// GraphqlWeight is what a query counts against the rate limits, each top-level field weighs
+// the items it may return, so a query of 20 accounts costs as much as a batch call of 20
+func GraphqlWeight(body []byte) int {
+	var req ReqGraphql
+	if err := json.Unmarshal(body, &req); err != nil {
+		return 1
+	}
+	doc, err := parser.Parse(parser.ParseParams{Source: source.NewSource(&source.Source{Body: []byte(req.Query)})})
+	if err != nil {
+		return 1
+	}
+	c := newGqlComplexity(doc, req.Variables)
+	weight := 0
+	for _, v := range doc.Definitions {
+		op, ok := v.(*ast.OperationDefinition)
+		if !ok || (req.OperationName != "" && (op.Name == nil || op.Name.Value != req.OperationName)) {
+			continue
+		}
+		weight += c.rootWeight(op.SelectionSet)
+	}
+	if weight < 1 {
+		return 1
+	}
+	return weight
+}
+
+func (c *gqlComplexity) rootWeight(set *ast.SelectionSet) (weight int) {
+	if set == nil {
+		return 0
+	}
+	for _, s := range set.Selections {
+		switch v := s.(type) {
+		case *ast.Field:
+			if v.Name.Value != "__typename" {
+				weight += c.multiplier(v)
+			}
+		case *ast.InlineFragment:
+			weight += c.rootWeight(v.SelectionSet)
+		case *ast.FragmentSpread:
+			name := v.Name.Value
+			f, ok := c.fragments[name]
+			if !ok || c.visiting[name] {
+				continue
+			}
+			c.visiting[name] = true
+			weight += c.rootWeight(f.SelectionSet)
+			c.visiting[name] = false
+		}
+	}
+	return
+}
+
+// multiplier is the number of items a field may return
+func (c *gqlComplexity) multiplier(field *ast.Field) int {
+	if field.Name.Value == "records" {
+		return gqlRecordsCost
+	}
+	for _, arg := range field.Arguments {
+		switch arg.Name.Value {
+		case "size":
+			return Pagination{Size: c.intValue(arg.Value)}.GetLimit()
+		case "accounts":
+			if l, ok := arg.Value.(*ast.ListValue); ok {
+				return len(l.Values)
+			} else if v, ok := arg.Value.(*ast.Variable); ok {
+				if list, ok := c.variables[v.Name.Value].([]interface{}); ok {
+					return len(list)
+				}
+			}
+			return gqlMaxBatch
+		}
+	}
+	if field.Name.Value == "sub_accounts" || field.Name.Value == "accounts" || field.Name.Value == "dids" {
+		return Pagination{}.GetLimit()
+	}
+	return 1
+}
+
+func (c *gqlComplexity) intValue(value ast.Value) int {
+	switch v := value.(type) {
+	case *ast.IntValue:
+		i, _ := strconv.Atoi(v.Value)
+		return i
+	case *ast.Variable:
+		if f, ok := c.variables[v.Name.Value].(float64); ok {
+			return int(f)
+		}
+	}
+	return 0
+}
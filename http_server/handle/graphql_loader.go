@@ -0,0 +1,102 @@
+package handle
+
+import (
+	"context"
+	"das-account-indexer/tables"
+	"sync"
+)
+
+type gqlContextKey struct{}
+
+// gqlContext is created per request, so the loaders never serve keys cached by another one
+type gqlContext struct {
+	h       *HttpHandle
+	account *loader
+	records *loader
+}
+
+func newGqlContext(h *HttpHandle) *gqlContext {
+	dbDao := h.DbDao
+	return &gqlContext{
+		h: h,
+		account: newLoader(func(keys []string) (map[string]interface{}, error) {
+			list, err := dbDao.FindAccountInfoListByAccountIds(keys)
+			if err != nil {
+				return nil, err
+			}
+			res := make(map[string]interface{}, len(list))
+			for i := range list {
+				res[list[i].AccountId] = &list[i]
+			}
+			return res, nil
+		}),
+		records: newLoader(func(keys []string) (map[string]interface{}, error) {
+			list, err := dbDao.FindRecordsByAccountIds(keys)
+			if err != nil {
+				return nil, err
+			}
+			res := make(map[string]interface{}, len(keys))
+			for _, v := range list {
+				records, _ := res[v.AccountId].([]tables.TableRecordsInfo)
+				res[v.AccountId] = append(records, v)
+			}
+			return res, nil
+		}),
+	}
+}
+
+func getGqlContext(ctx context.Context) *gqlContext {
+	return ctx.Value(gqlContextKey{}).(*gqlContext)
+}
+
+// loader collects the keys asked for by sibling resolvers and fetches them in one batch
+// once the executor calls the first of the returned thunks
+type loader struct {
+	lock  sync.Mutex
+	fetch func(keys []string) (map[string]interface{}, error)
+	batch *loaderBatch
+	cache map[string]*loaderBatch
+}
+
+type loaderBatch struct {
+	once sync.Once
+	keys []string
+	res  map[string]interface{}
+	err  error
+}
+
+func newLoader(fetch func(keys []string) (map[string]interface{}, error)) *loader {
+	return &loader{
+		fetch: fetch,
+		cache: make(map[string]*loaderBatch),
+	}
+}
+
+func (l *loader) Load(key string) func() (interface{}, error) {
+	l.lock.Lock()
+	b, ok := l.cache[key]
+	if !ok {
+		if l.batch == nil {
+			l.batch = &loaderBatch{}
+		}
+		b = l.batch
+		b.keys = append(b.keys, key)
+		l.cache[key] = b
+	}
+	l.lock.Unlock()
+
+	return func() (interface{}, error) {
+		l.lock.Lock()
+		if l.batch == b {
+			l.batch = nil
+		}
+		l.lock.Unlock()
+		b.once.Do(func() {
+			b.res, b.err = l.fetch(b.keys)
+		})
+		if b.err != nil {
+			return nil, b.err
+		}
+		return b.res[key], nil
+	}
+}
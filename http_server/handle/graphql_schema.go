@@ -0,0 +1,513 @@
+package handle
+
+import (
+	"das-account-indexer/config"
+	"das-account-indexer/tables"
+	"errors"
+	"fmt"
+	"github.com/dotbitHQ/das-lib/common"
+	"github.com/dotbitHQ/das-lib/core"
+	"github.com/dotbitHQ/das-lib/http_api"
+	"github.com/graphql-go/graphql"
+	"github.com/nervosnetwork/ckb-sdk-go/address"
+	"strings"
+)
+
+const gqlMaxBatch = 100
+
+var gqlUint64 = graphql.NewScalar(graphql.ScalarConfig{
+	Name:        "Uint64",
+	Description: "unsigned 64-bit integer, e.g. unix timestamps",
+	Serialize: func(value interface{}) interface{} {
+		return value
+	},
+})
+
+var gqlRecordType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Record",
+	Fields: graphql.Fields{
+		"key":   &graphql.Field{Type: graphql.String},
+		"label": &graphql.Field{Type: graphql.String},
+		"value": &graphql.Field{Type: graphql.String},
+		"ttl":   &graphql.Field{Type: graphql.String},
+	},
+})
+
+var gqlSchema, gqlSchemaErr = newGqlSchema()
+
+func newGqlSchema() (graphql.Schema, error) {
+	keyType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Key",
+		Fields: graphql.Fields{
+			"algorithm_id": &graphql.Field{Type: graphql.Int},
+			"sub_aid":      &graphql.Field{Type: graphql.Int},
+			"key":          &graphql.Field{Type: graphql.String},
+		},
+	})
+
+	accountType := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "Account",
+		Description: "account or sub-account",
+		Fields: graphql.Fields{
+			"account_id":   gqlAccountField(graphql.String, func(acc *tables.TableAccountInfo) interface{} { return acc.AccountId }),
+			"account":      gqlAccountField(graphql.String, func(acc *tables.TableAccountInfo) interface{} { return acc.Account }),
+			"display_name": gqlAccountField(graphql.String, func(acc *tables.TableAccountInfo) interface{} { return FormatDisplayName(acc.Account) }),
+			"status":       gqlAccountField(graphql.Int, func(acc *tables.TableAccountInfo) interface{} { return int(acc.Status) }),
+			"registered_at": gqlAccountField(gqlUint64, func(acc *tables.TableAccountInfo) interface{} {
+				return acc.RegisteredAt
+			}),
+			"expired_at": gqlAccountField(gqlUint64, func(acc *tables.TableAccountInfo) interface{} {
+				return acc.ExpiredAt
+			}),
+			"enable_sub_account": gqlAccountField(graphql.Int, func(acc *tables.TableAccountInfo) interface{} {
+				return int(acc.EnableSubAccount)
+			}),
+			"owner":   &graphql.Field{Type: keyType, Resolve: gqlResolveAccountKey(true)},
+			"manager": &graphql.Field{Type: keyType, Resolve: gqlResolveAccountKey(false)},
+			"records": &graphql.Field{
+				Type: graphql.NewList(gqlRecordType),
+				Args: graphql.FieldConfigArgument{
+					"keys": &graphql.ArgumentConfig{Type: graphql.NewList(graphql.String), Description: "only return these keys, e.g. address.60, profile.twitter"},
+				},
+				Resolve: gqlResolveRecords,
+			},
+		},
+	})
+
+	accountPageType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "AccountPage",
+		Fields: graphql.Fields{
+			"total":       &graphql.Field{Type: gqlUint64},
+			"next_cursor": &graphql.Field{Type: graphql.String},
+			"list": &graphql.Field{
+				Type: graphql.NewList(accountType),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return p.Source.(map[string]interface{})["list"], nil
+				},
+			},
+		},
+	})
+
+	accountType.AddFieldConfig("parent", &graphql.Field{
+		Type: accountType,
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			acc := p.Source.(*tables.TableAccountInfo)
+			if acc.ParentAccountId == "" {
+				return nil, nil
+			}
+			return getGqlContext(p.Context).account.Load(acc.ParentAccountId), nil
+		},
+	})
+	accountType.AddFieldConfig("sub_accounts", &graphql.Field{
+		Type: accountPageType,
+		Args: graphql.FieldConfigArgument{
+			"size":   &graphql.ArgumentConfig{Type: graphql.Int},
+			"cursor": &graphql.ArgumentConfig{Type: graphql.String},
+		},
+		Resolve: gqlResolveSubAccounts,
+	})
+
+	didType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Did",
+		Fields: graphql.Fields{
+			"outpoint":     &graphql.Field{Type: graphql.String},
+			"account_id":   &graphql.Field{Type: graphql.String},
+			"account":      &graphql.Field{Type: graphql.String},
+			"expired_at":   &graphql.Field{Type: gqlUint64},
+			"account_info": &graphql.Field{Type: accountType, Resolve: gqlResolveAccountInfo},
+		},
+	})
+
+	didPageType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "DidPage",
+		Fields: graphql.Fields{
+			"total":       &graphql.Field{Type: gqlUint64},
+			"next_cursor": &graphql.Field{Type: graphql.String},
+			"list":        &graphql.Field{Type: graphql.NewList(didType)},
+		},
+	})
+
+	reverseType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Reverse",
+		Fields: graphql.Fields{
+			"account":      &graphql.Field{Type: graphql.String},
+			"display_name": &graphql.Field{Type: graphql.String},
+			"account_info": &graphql.Field{Type: accountType, Resolve: gqlResolveAccountInfo},
+		},
+	})
+
+	addressType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Address",
+		Fields: graphql.Fields{
+			"chain_type": &graphql.Field{
+				Type: graphql.Int,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return int(p.Source.(*gqlAddress).addrHex.ChainType), nil
+				},
+			},
+			"address_hex": &graphql.Field{
+				Type: graphql.String,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return p.Source.(*gqlAddress).addrHex.AddressHex, nil
+				},
+			},
+			"accounts": &graphql.Field{
+				Type: accountPageType,
+				Args: graphql.FieldConfigArgument{
+					"role":   &graphql.ArgumentConfig{Type: graphql.String, Description: "owner (default) or manager"},
+					"size":   &graphql.ArgumentConfig{Type: graphql.Int},
+					"cursor": &graphql.ArgumentConfig{Type: graphql.String},
+				},
+				Resolve: gqlResolveAddressAccounts,
+			},
+			"reverse": &graphql.Field{
+				Type:    reverseType,
+				Resolve: gqlResolveReverse,
+			},
+			"dids": &graphql.Field{
+				Type: didPageType,
+				Args: graphql.FieldConfigArgument{
+					"did_type": &graphql.ArgumentConfig{Type: graphql.Int, Description: "0-all, 1-normal, 2-recyclable"},
+					"size":     &graphql.ArgumentConfig{Type: graphql.Int},
+					"cursor":   &graphql.ArgumentConfig{Type: graphql.String},
+				},
+				Resolve: gqlResolveDids,
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{
+			Name: "Query",
+			Fields: graphql.Fields{
+				"account": &graphql.Field{
+					Type: accountType,
+					Args: graphql.FieldConfigArgument{
+						"account": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+					},
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						account := strings.ToLower(strings.TrimSpace(p.Args["account"].(string)))
+						accountId := common.Bytes2Hex(common.GetAccountIdByAccount(account))
+						return getGqlContext(p.Context).account.Load(accountId), nil
+					},
+				},
+				"accounts": &graphql.Field{
+					Type: graphql.NewList(accountType),
+					Args: graphql.FieldConfigArgument{
+						"accounts": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.String)))},
+					},
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						accounts, _ := p.Args["accounts"].([]interface{})
+						if len(accounts) > gqlMaxBatch {
+							return nil, fmt.Errorf("accounts exceeds the limit %d", gqlMaxBatch)
+						}
+						var accountIds []string
+						for _, v := range accounts {
+							account := strings.ToLower(strings.TrimSpace(v.(string)))
+							accountIds = append(accountIds, common.Bytes2Hex(common.GetAccountIdByAccount(account)))
+						}
+						return gqlLoadAll(getGqlContext(p.Context).account, accountIds), nil
+					},
+				},
+				"address": &graphql.Field{
+					Type: addressType,
+					Args: graphql.FieldConfigArgument{
+						"type":      &graphql.ArgumentConfig{Type: graphql.String, DefaultValue: "blockchain"},
+						"coin_type": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+						"key":       &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+					},
+					Resolve: gqlResolveAddress,
+				},
+			},
+		}),
+	})
+}
+
+func gqlResolveAddress(p graphql.ResolveParams) (interface{}, error) {
+	h := getGqlContext(p.Context).h
+	cta := core.ChainTypeAddress{
+		Type: p.Args["type"].(string),
+		KeyInfo: core.KeyInfo{
+			CoinType: common.CoinType(p.Args["coin_type"].(string)),
+			Key:      p.Args["key"].(string),
+		},
+	}
+	addrHex, err := cta.FormatChainTypeAddress(h.DasCore.NetType(), true)
+	if err != nil {
+		return nil, fmt.Errorf("address invalid")
+	}
+	return &gqlAddress{cta: cta, addrHex: addrHex}, nil
+}
+
+type gqlAddress struct {
+	cta     core.ChainTypeAddress
+	addrHex *core.DasAddressHex
+}
+
+func gqlAccountField(t graphql.Output, fn func(acc *tables.TableAccountInfo) interface{}) *graphql.Field {
+	return &graphql.Field{
+		Type: t,
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return fn(p.Source.(*tables.TableAccountInfo)), nil
+		},
+	}
+}
+
+// gqlLoadAll queues every key on the loader and returns a thunk resolving them in order, missing ones are left out
+func gqlLoadAll(l *loader, keys []string) func() (interface{}, error) {
+	thunks := make([]func() (interface{}, error), 0, len(keys))
+	for _, v := range keys {
+		thunks = append(thunks, l.Load(v))
+	}
+	return func() (interface{}, error) {
+		list := make([]interface{}, 0, len(thunks))
+		for _, thunk := range thunks {
+			res, err := thunk()
+			if err != nil {
+				return nil, err
+			} else if res != nil {
+				list = append(list, res)
+			}
+		}
+		return list, nil
+	}
+}
+
+func gqlResolveAccountInfo(p graphql.ResolveParams) (interface{}, error) {
+	account, _ := p.Source.(map[string]interface{})["account"].(string)
+	if account == "" {
+		return nil, nil
+	}
+	accountId := common.Bytes2Hex(common.GetAccountIdByAccount(account))
+	return getGqlContext(p.Context).account.Load(accountId), nil
+}
+
+func gqlResolveAccountKey(isOwner bool) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		acc := p.Source.(*tables.TableAccountInfo)
+		h := getGqlContext(p.Context).h
+		if acc.Status == tables.AccountStatusOnUpgrade {
+			didCell, err := h.DbDao.GetDidCellByAccountId(acc.AccountId)
+			if err != nil {
+				return nil, fmt.Errorf("GetDidCellByAccountId err: %s", err.Error())
+			} else if didCell.Id == 0 {
+				return nil, nil
+			}
+			mode := address.Mainnet
+			if config.Cfg.Server.Net != common.DasNetTypeMainNet {
+				mode = address.Testnet
+			}
+			addr, err := didCell.ToAnyLockAddr(mode)
+			if err != nil {
+				return nil, fmt.Errorf("ToAnyLockAddr err: %s", err.Error())
+			}
+			return map[string]interface{}{
+				"algorithm_id": int(common.DasAlgorithmIdAnyLock),
+				"sub_aid":      0,
+				"key":          addr,
+			}, nil
+		}
+
+		addrHex := core.DasAddressHex{
+			DasAlgorithmId:    acc.OwnerAlgorithmId,
+			DasSubAlgorithmId: acc.OwnerSubAid,
+			AddressHex:        acc.Owner,
+			ChainType:         acc.OwnerChainType,
+		}
+		if !isOwner {
+			addrHex = core.DasAddressHex{
+				DasAlgorithmId:    acc.ManagerAlgorithmId,
+				DasSubAlgorithmId: acc.ManagerSubAid,
+				AddressHex:        acc.Manager,
+				ChainType:         acc.ManagerChainType,
+			}
+		}
+		addrNormal, err := h.DasCore.Daf().HexToNormal(addrHex)
+		if err != nil {
+			return nil, fmt.Errorf("HexToNormal err: %s", err.Error())
+		}
+		return map[string]interface{}{
+			"algorithm_id": int(addrHex.DasAlgorithmId),
+			"sub_aid":      int(addrHex.DasSubAlgorithmId),
+			"key":          addrNormal.AddressNormal,
+		}, nil
+	}
+}
+
+func gqlResolveRecords(p graphql.ResolveParams) (interface{}, error) {
+	acc := p.Source.(*tables.TableAccountInfo)
+	var mapKeys map[string]struct{}
+	if keys, ok := p.Args["keys"].([]interface{}); ok && len(keys) > 0 {
+		mapKeys = make(map[string]struct{})
+		for _, v := range keys {
+			if key, ok := v.(string); ok {
+				mapKeys[common.ConvertRecordsAddressCoinType(key)] = struct{}{}
+			}
+		}
+	}
+	thunk := getGqlContext(p.Context).records.Load(acc.AccountId)
+	return func() (interface{}, error) {
+		res, err := thunk()
+		if err != nil {
+			return nil, err
+		}
+		records, _ := res.([]tables.TableRecordsInfo)
+		list := make([]interface{}, 0, len(records))
+		for _, v := range records {
+			key := common.ConvertRecordsAddressCoinType(fmt.Sprintf("%s.%s", v.Type, v.Key))
+			if _, ok := mapKeys[key]; mapKeys != nil && !ok {
+				continue
+			}
+			list = append(list, map[string]interface{}{
+				"key":   key,
+				"label": v.Label,
+				"value": v.Value,
+				"ttl":   v.Ttl,
+			})
+		}
+		return list, nil
+	}, nil
+}
+
+func gqlPagination(args map[string]interface{}) Pagination {
+	var page Pagination
+	page.Size, _ = args["size"].(int)
+	page.Cursor, _ = args["cursor"].(string)
+	return page
+}
+
+func gqlResolveSubAccounts(p graphql.ResolveParams) (interface{}, error) {
+	acc := p.Source.(*tables.TableAccountInfo)
+	res := map[string]interface{}{"total": uint64(0), "next_cursor": "", "list": []interface{}{}}
+	if acc.ParentAccountId != "" || acc.EnableSubAccount != tables.AccountEnableStatusOn {
+		return res, nil
+	}
+	h := getGqlContext(p.Context).h
+	page := gqlPagination(p.Args)
+	cursor, err := page.GetCursor()
+	if err != nil {
+		return nil, fmt.Errorf("cursor invalid")
+	}
+	list, err := h.DbDao.GetSubAccountListByParentAccountId(acc.AccountId, cursor, page.GetLimit(), 0)
+	if err != nil {
+		return nil, fmt.Errorf("GetSubAccountListByParentAccountId err: %s", err.Error())
+	}
+	total, err := h.DbDao.GetSubAccountListCountByParentAccountId(acc.AccountId)
+	if err != nil {
+		return nil, fmt.Errorf("GetSubAccountListCountByParentAccountId err: %s", err.Error())
+	}
+	items := make([]interface{}, 0, len(list))
+	for i := range list {
+		items = append(items, &list[i])
+	}
+	res["total"] = uint64(total)
+	res["list"] = items
+	if l := len(list); l > 0 {
+		res["next_cursor"] = page.NextCursor(l, list[l-1].Account, list[l-1].Id)
+	}
+	return res, nil
+}
+
+func gqlResolveAddressAccounts(p graphql.ResolveParams) (interface{}, error) {
+	addr := p.Source.(*gqlAddress)
+	gqlCtx := getGqlContext(p.Context)
+	page := gqlPagination(p.Args)
+	cursor, err := page.GetCursor()
+	if err != nil {
+		return nil, fmt.Errorf("cursor invalid")
+	}
+
+	var total int64
+	var accountIds []string
+	res := map[string]interface{}{"next_cursor": ""}
+	if addr.addrHex.DasAlgorithmId == common.DasAlgorithmIdAnyLock {
+		didCells, err := gqlCtx.h.DbDao.QueryDidCell(addr.addrHex.AddressHex, tables.DidCellStatusNormal, cursor, page.GetLimit(), 0)
+		if err != nil {
+			return nil, fmt.Errorf("QueryDidCell err: %s", err.Error())
+		}
+		if total, err = gqlCtx.h.DbDao.QueryDidCellTotal(addr.addrHex.AddressHex, tables.DidCellStatusNormal); err != nil {
+			return nil, fmt.Errorf("QueryDidCellTotal err: %s", err.Error())
+		}
+		for _, v := range didCells {
+			accountIds = append(accountIds, v.AccountId)
+		}
+		if l := len(didCells); l > 0 {
+			res["next_cursor"] = page.NextCursor(l, didCells[l-1].Account, didCells[l-1].Id)
+		}
+	} else {
+		role, _ := p.Args["role"].(string)
+		list, err := gqlCtx.h.DbDao.FindAccountNameListByAddress(addr.addrHex.ChainType, addr.addrHex.AddressHex, role, cursor, page.GetLimit(), 0)
+		if err != nil {
+			return nil, fmt.Errorf("FindAccountNameListByAddress err: %s", err.Error())
+		}
+		if total, err = gqlCtx.h.DbDao.FindTotalAccountNameListByAddress(addr.addrHex.ChainType, addr.addrHex.AddressHex, role); err != nil {
+			return nil, fmt.Errorf("FindTotalAccountNameListByAddress err: %s", err.Error())
+		}
+		for _, v := range list {
+			accountIds = append(accountIds, common.Bytes2Hex(common.GetAccountIdByAccount(v.Account)))
+		}
+		if l := len(list); l > 0 {
+			res["next_cursor"] = page.NextCursor(l, list[l-1].Account, list[l-1].Id)
+		}
+	}
+	res["total"] = uint64(total)
+	res["list"] = gqlLoadAll(gqlCtx.account, accountIds)
+	return res, nil
+}
+
+func gqlResolveReverse(p graphql.ResolveParams) (interface{}, error) {
+	addr := p.Source.(*gqlAddress)
+	var apiResp http_api.ApiResp
+	if err := getGqlContext(p.Context).h.doReverseRecordV2(p.Context, &ReqReverseRecordV2{ChainTypeAddress: addr.cta}, &apiResp); err != nil {
+		return nil, err
+	} else if apiResp.ErrNo != http_api.ApiCodeSuccess {
+		return nil, errors.New(apiResp.ErrMsg)
+	}
+	resp, ok := apiResp.Data.(RespReverseRecordV2)
+	if !ok || resp.Account == "" {
+		return nil, nil
+	}
+	return map[string]interface{}{
+		"account":      resp.Account,
+		"display_name": resp.DisplayName,
+	}, nil
+}
+
+func gqlResolveDids(p graphql.ResolveParams) (interface{}, error) {
+	addr := p.Source.(*gqlAddress)
+	res := map[string]interface{}{"total": uint64(0), "next_cursor": "", "list": []interface{}{}}
+	if addr.addrHex.DasAlgorithmId != common.DasAlgorithmIdAnyLock {
+		return res, nil
+	}
+	h := getGqlContext(p.Context).h
+	page := gqlPagination(p.Args)
+	cursor, err := page.GetCursor()
+	if err != nil {
+		return nil, fmt.Errorf("cursor invalid")
+	}
+	didType, _ := p.Args["did_type"].(int)
+	args := common.Bytes2Hex(addr.addrHex.ParsedAddress.Script.Args)
+	list, err := h.DbDao.QueryDidCell(args, tables.DidCellStatus(didType), cursor, page.GetLimit(), 0)
+	if err != nil {
+		return nil, fmt.Errorf("QueryDidCell err: %s", err.Error())
+	}
+	total, err := h.DbDao.QueryDidCellTotal(args, tables.DidCellStatus(didType))
+	if err != nil {
+		return nil, fmt.Errorf("QueryDidCellTotal err: %s", err.Error())
+	}
+	items := make([]interface{}, 0, len(list))
+	for _, v := range list {
+		items = append(items, map[string]interface{}{
+			"outpoint":   v.Outpoint,
+			"account_id": v.AccountId,
+			"account":    v.Account,
+			"expired_at": v.ExpiredAt,
+		})
+	}
+	res["total"] = uint64(total)
+	res["list"] = items
+	if l := len(list); l > 0 {
+		res["next_cursor"] = page.NextCursor(l, list[l-1].Account, list[l-1].Id)
+	}
+	return res, nil
+}
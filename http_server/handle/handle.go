@@ -2,12 +2,16 @@ package handle
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"das-account-indexer/cache"
 	"das-account-indexer/dao"
+	"das-account-indexer/sign"
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"github.com/dotbitHQ/das-lib/core"
 	"github.com/dotbitHQ/das-lib/txbuilder"
 	"github.com/gin-gonic/gin"
-	"github.com/go-redis/redis"
 	"github.com/scorpiotzh/mylog"
 )
 
@@ -17,12 +21,16 @@ var (
 
 type HttpHandle struct {
 	Ctx                    context.Context
-	Red                    *redis.Client
+	Cache                  *cache.TieredCache
 	DbDao                  *dao.DbDao
 	DasCore                *core.DasCore
 	TxBuilderBase          *txbuilder.DasTxBuilderBase
 	MapReservedAccounts    map[string]struct{}
 	MapUnAvailableAccounts map[string]struct{}
+	Signer                 *sign.Signer      // responses are signed when set
+	CcipKey                *ecdsa.PrivateKey // the ccip gateway is served when set
+
+	signBlock signBlock
 }
 
 func GetClientIp(ctx *gin.Context) string {
@@ -31,8 +39,9 @@ func GetClientIp(ctx *gin.Context) string {
 }
 
 type Pagination struct {
-	Page int `json:"page"`
-	Size int `json:"size"`
+	Page   int    `json:"page"`
+	Size   int    `json:"size"`
+	Cursor string `json:"cursor"` // next_cursor of the previous page, takes the place of page when set
 }
 
 func (p Pagination) GetLimit() int {
@@ -43,6 +52,9 @@ func (p Pagination) GetLimit() int {
 }
 
 func (p Pagination) GetOffset() int {
+	if p.Cursor != "" {
+		return 0
+	}
 	page := p.Page
 	if p.Page < 1 {
 		page = 1
@@ -50,3 +62,30 @@ func (p Pagination) GetOffset() int {
 	size := p.GetLimit()
 	return (page - 1) * size
 }
+
+func (p Pagination) GetCursor() (*dao.Cursor, error) {
+	if p.Cursor == "" {
+		return nil, nil
+	}
+	bys, err := base64.RawURLEncoding.DecodeString(p.Cursor)
+	if err != nil {
+		return nil, err
+	}
+	var cursor dao.Cursor
+	if err = json.Unmarshal(bys, &cursor); err != nil {
+		return nil, err
+	} else if cursor.Account == "" || cursor.Id == 0 {
+		// would start over from the first page
+		return nil, fmt.Errorf("cursor invalid: %s", p.Cursor)
+	}
+	return &cursor, nil
+}
+
+// NextCursor returns the cursor of the page after the one ending at account/id, or "" when count shows it was the last page
+func (p Pagination) NextCursor(count int, account string, id uint64) string {
+	if count < p.GetLimit() {
+		return ""
+	}
+	bys, _ := json.Marshal(dao.Cursor{Account: account, Id: id})
+	return base64.RawURLEncoding.EncodeToString(bys)
+}
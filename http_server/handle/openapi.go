@@ -0,0 +1,288 @@
+package handle
+
+import (
+	"embed"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// the viewer is served from the binary, with no third-party script on the api origin
+//
+//go:embed openapi_viewer
+var openApiViewer embed.FS
+
+const openApiViewerCsp = "default-src 'none'; script-src 'self'; style-src 'self'; connect-src 'self'; img-src 'self' data:; base-uri 'none'; form-action 'none'; frame-ancestors 'none'"
+
+type OpenApiSchema struct {
+	Ref                  string                    `json:"$ref,omitempty"`
+	Type                 string                    `json:"type,omitempty"`
+	Format               string                    `json:"format,omitempty"`
+	Description          string                    `json:"description,omitempty"`
+	Enum                 []interface{}             `json:"enum,omitempty"`
+	Minimum              *float64                  `json:"minimum,omitempty"`
+	MinLength            *int                      `json:"minLength,omitempty"`
+	MaxLength            *int                      `json:"maxLength,omitempty"`
+	MinItems             *int                      `json:"minItems,omitempty"`
+	MaxItems             *int                      `json:"maxItems,omitempty"`
+	Items                *OpenApiSchema            `json:"items,omitempty"`
+	Properties           map[string]*OpenApiSchema `json:"properties,omitempty"`
+	Required             []string                  `json:"required,omitempty"`
+	AdditionalProperties *OpenApiSchema            `json:"additionalProperties,omitempty"`
+}
+
+type OpenApiDoc struct {
+	OpenApi    string                                  `json:"openapi"`
+	Info       map[string]string                       `json:"info"`
+	Paths      map[string]map[string]*OpenApiOperation `json:"paths"`
+	Components struct {
+		Schemas map[string]*OpenApiSchema `json:"schemas"`
+	} `json:"components"`
+}
+
+type OpenApiOperation struct {
+	OperationId string                  `json:"operationId"`
+	Description string                  `json:"description,omitempty"`
+	RequestBody *OpenApiBody            `json:"requestBody,omitempty"`
+	Responses   map[string]*OpenApiBody `json:"responses"`
+}
+
+type OpenApiBody struct {
+	Description string                               `json:"description,omitempty"`
+	Required    bool                                 `json:"required,omitempty"`
+	Content     map[string]map[string]*OpenApiSchema `json:"content"`
+}
+
+var (
+	openApiDoc, openApiReqSchemas = newOpenApiDoc()
+	openApiJson, _                = json.Marshal(openApiDoc)
+)
+
+// newOpenApiDoc builds the document from the request and response structs of the registered methods,
+// the request schemas are also returned by method to validate incoming params against
+func newOpenApiDoc() (*OpenApiDoc, map[string]*OpenApiSchema) {
+	doc := OpenApiDoc{
+		OpenApi: "3.0.3",
+		Info:    map[string]string{"title": "das-account-indexer", "version": apiVersion},
+		Paths:   make(map[string]map[string]*OpenApiOperation),
+	}
+	g := openApiGenerator{schemas: make(map[string]*OpenApiSchema), names: make(map[reflect.Type]string)}
+	reqSchemas := make(map[string]*OpenApiSchema)
+
+	var methodNames []interface{}
+	for _, m := range Methods {
+		methodNames = append(methodNames, m.Name)
+		req := g.schemaOf(reflect.TypeOf(m.newReq()).Elem())
+		reqSchemas[m.Name] = req
+		resp := &OpenApiSchema{
+			Type: "object",
+			Properties: map[string]*OpenApiSchema{
+				"err_no":  {Type: "integer"},
+				"err_msg": {Type: "string"},
+				"data":    g.schemaOf(reflect.TypeOf(m.Resp)),
+			},
+		}
+		for _, path := range m.Paths {
+			op := OpenApiOperation{
+				OperationId: m.Name,
+				Description: fmt.Sprintf("Also served as the json-rpc method `%s`.", m.Name),
+				Responses:   map[string]*OpenApiBody{"200": openApiJsonBody("err_no is 0 on success, data is then set", resp)},
+			}
+			if !m.noParams {
+				op.RequestBody = openApiJsonBody("request", req)
+				op.RequestBody.Required = true
+			}
+			if len(m.Paths) > 1 {
+				op.OperationId = m.Name + strings.ReplaceAll(path, "/", "_")
+			}
+			doc.Paths[path] = map[string]*OpenApiOperation{"post": &op}
+		}
+	}
+
+	rpc := &OpenApiSchema{
+		Type: "object",
+		Properties: map[string]*OpenApiSchema{
+			"jsonrpc": {Type: "string", Enum: []interface{}{"2.0"}},
+			"id":      {Description: "number, string or null, omitted for notifications"},
+			"method":  {Type: "string", Enum: methodNames},
+			"params":  {Type: "array", Description: "the request of the method, as the only item", Items: &OpenApiSchema{}},
+		},
+		Required: []string{"method"},
+	}
+	doc.Paths["/"] = map[string]*OpenApiOperation{"post": {
+		OperationId: "json_rpc",
+		Description: "JSON-RPC endpoint serving every method, an array of calls is served as a batch.",
+		RequestBody: openApiJsonBody("a call, or an array of calls", rpc),
+		Responses:   map[string]*OpenApiBody{"200": openApiJsonBody("the result of the method as in its REST response", &OpenApiSchema{})},
+	}}
+	doc.Components.Schemas = g.schemas
+	return &doc, reqSchemas
+}
+
+func openApiJsonBody(description string, schema *OpenApiSchema) *OpenApiBody {
+	return &OpenApiBody{
+		Description: description,
+		Content:     map[string]map[string]*OpenApiSchema{"application/json": {"schema": schema}},
+	}
+}
+
+type openApiGenerator struct {
+	schemas map[string]*OpenApiSchema
+	names   map[reflect.Type]string
+}
+
+var (
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+)
+
+func (g *openApiGenerator) schemaOf(t reflect.Type) *OpenApiSchema {
+	if t == nil {
+		return &OpenApiSchema{}
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if reflect.PtrTo(t).Implements(jsonMarshalerType) {
+		return &OpenApiSchema{}
+	} else if reflect.PtrTo(t).Implements(textMarshalerType) {
+		return &OpenApiSchema{Type: "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &OpenApiSchema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &OpenApiSchema{Type: "integer", Format: openApiIntFormat(t)}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		zero := float64(0)
+		return &OpenApiSchema{Type: "integer", Format: openApiIntFormat(t), Minimum: &zero}
+	case reflect.Float32, reflect.Float64:
+		return &OpenApiSchema{Type: "number"}
+	case reflect.String:
+		return &OpenApiSchema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &OpenApiSchema{Type: "string", Format: "byte"}
+		}
+		return &OpenApiSchema{Type: "array", Items: g.schemaOf(t.Elem())}
+	case reflect.Map:
+		return &OpenApiSchema{Type: "object", AdditionalProperties: g.schemaOf(t.Elem())}
+	case reflect.Struct:
+		return g.structRef(t)
+	}
+	return &OpenApiSchema{}
+}
+
+func openApiIntFormat(t reflect.Type) string {
+	if t.Bits() > 32 {
+		return "int64"
+	}
+	return "int32"
+}
+
+// structRef registers t under components and returns a reference to it, named structs are shared
+// by every schema using them
+func (g *openApiGenerator) structRef(t reflect.Type) *OpenApiSchema {
+	if t.Name() == "" {
+		return g.structSchema(t)
+	}
+	if name, ok := g.names[t]; ok {
+		return &OpenApiSchema{Ref: "#/components/schemas/" + name}
+	}
+	name := t.Name()
+	if _, ok := g.schemas[name]; ok {
+		name = strings.ReplaceAll(t.PkgPath(), "/", "_") + "_" + name
+	}
+	g.names[t] = name
+	g.schemas[name] = &OpenApiSchema{}
+	*g.schemas[name] = *g.structSchema(t)
+	return &OpenApiSchema{Ref: "#/components/schemas/" + name}
+}
+
+func (g *openApiGenerator) structSchema(t reflect.Type) *OpenApiSchema {
+	s := OpenApiSchema{Type: "object", Properties: make(map[string]*OpenApiSchema)}
+	g.addFields(&s, t)
+	return &s
+}
+
+// addFields follows the field naming of encoding/json, embedded structs without a name are inlined
+func (g *openApiGenerator) addFields(s *OpenApiSchema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		ft := f.Type
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if f.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+			g.addFields(s, ft)
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fs := g.schemaOf(f.Type)
+		if strings.Contains(opts, "string") && fs.Ref == "" {
+			fs = &OpenApiSchema{Type: "string"}
+		}
+		for _, rule := range strings.Split(f.Tag.Get("binding"), ",") {
+			k, v, _ := strings.Cut(rule, "=")
+			n, err := strconv.Atoi(v)
+			switch {
+			case k == "required":
+				s.Required = append(s.Required, name)
+			case (k == "max" || k == "min") && err == nil && fs.Ref == "":
+				if fs.Type == "array" && k == "max" {
+					fs.MaxItems = &n
+				} else if fs.Type == "array" {
+					fs.MinItems = &n
+				} else if fs.Type == "string" && k == "max" {
+					fs.MaxLength = &n
+				} else if fs.Type == "string" {
+					fs.MinLength = &n
+				}
+			}
+		}
+		s.Properties[name] = fs
+	}
+}
+
+func (h *HttpHandle) OpenApi(ctx *gin.Context) {
+	ctx.Data(http.StatusOK, "application/json; charset=utf-8", openApiJson)
+}
+
+// OpenApiViewer serves the viewer page at /docs and its files at /docs/:file
+func (h *HttpHandle) OpenApiViewer(ctx *gin.Context) {
+	name, contentType := "index.html", "text/html; charset=utf-8"
+	switch ctx.Param("file") {
+	case "":
+	case "viewer.js":
+		name, contentType = "viewer.js", "text/javascript; charset=utf-8"
+	case "viewer.css":
+		name, contentType = "viewer.css", "text/css; charset=utf-8"
+	default:
+		ctx.Status(http.StatusNotFound)
+		return
+	}
+	data, err := openApiViewer.ReadFile("openapi_viewer/" + name)
+	if err != nil {
+		ctx.Status(http.StatusNotFound)
+		return
+	}
+	ctx.Header("Content-Security-Policy", openApiViewerCsp)
+	ctx.Header("X-Content-Type-Options", "nosniff")
+	ctx.Data(http.StatusOK, contentType, data)
+}
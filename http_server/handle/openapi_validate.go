@@ -0,0 +1,138 @@
+package handle
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// validateParams checks the raw params of a call against the request schema of the method
+// published in the openapi document, so clients get the offending field instead of a generic error
+func validateParams(m *Method, data []byte) error {
+	schema, ok := openApiReqSchemas[m.Name]
+	if !ok || m.noParams {
+		return nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return fmt.Errorf("invalid json")
+	}
+	return validateSchema(schema, v, "")
+}
+
+func validateSchema(schema *OpenApiSchema, v interface{}, path string) error {
+	if schema.Ref != "" {
+		ref, ok := openApiDoc.Components.Schemas[strings.TrimPrefix(schema.Ref, "#/components/schemas/")]
+		if !ok {
+			return nil
+		}
+		schema = ref
+	}
+	// encoding/json leaves the zero value for null
+	if v == nil {
+		return nil
+	}
+	name := path
+	if name == "" {
+		name = "params"
+	}
+
+	switch schema.Type {
+	case "object":
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s should be an object", name)
+		}
+		for _, k := range schema.Required {
+			if obj[k] == nil {
+				return fmt.Errorf("%s is required", joinParamPath(path, k))
+			}
+		}
+		for k, item := range obj {
+			fs, ok := schema.Properties[k]
+			if !ok {
+				fs = schema.AdditionalProperties
+			}
+			if fs == nil {
+				continue
+			}
+			if err := validateSchema(fs, item, joinParamPath(path, k)); err != nil {
+				return err
+			}
+		}
+	case "array":
+		list, ok := v.([]interface{})
+		if !ok {
+			return fmt.Errorf("%s should be an array", name)
+		}
+		if schema.MinItems != nil && len(list) < *schema.MinItems {
+			return fmt.Errorf("%s should have at least %d items", name, *schema.MinItems)
+		}
+		if schema.MaxItems != nil && len(list) > *schema.MaxItems {
+			return fmt.Errorf("%s should have at most %d items", name, *schema.MaxItems)
+		}
+		if schema.Items == nil {
+			return nil
+		}
+		for i, item := range list {
+			if err := validateSchema(schema.Items, item, fmt.Sprintf("%s[%d]", name, i)); err != nil {
+				return err
+			}
+		}
+	case "string":
+		s, ok := v.(string)
+		if !ok {
+			return fmt.Errorf("%s should be a string", name)
+		}
+		// in characters, as in the schema and in the binding rules it comes from
+		if schema.MinLength != nil && utf8.RuneCountInString(s) < *schema.MinLength {
+			return fmt.Errorf("%s should be at least %d characters", name, *schema.MinLength)
+		}
+		if schema.MaxLength != nil && utf8.RuneCountInString(s) > *schema.MaxLength {
+			return fmt.Errorf("%s should be at most %d characters", name, *schema.MaxLength)
+		}
+	case "integer", "number":
+		n, ok := v.(json.Number)
+		if !ok {
+			return fmt.Errorf("%s should be a number", name)
+		}
+		if schema.Type == "integer" {
+			if _, err := n.Int64(); err != nil && !(schema.Minimum != nil && isUintNumber(n)) {
+				return fmt.Errorf("%s should be an integer", name)
+			}
+		}
+		if f, err := n.Float64(); err == nil && schema.Minimum != nil && f < *schema.Minimum {
+			return fmt.Errorf("%s should be at least %v", name, *schema.Minimum)
+		}
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			return fmt.Errorf("%s should be a boolean", name)
+		}
+	}
+	return nil
+}
+
+// isUintNumber accepts unsigned values above the int64 range
+func isUintNumber(n json.Number) bool {
+	s := n.String()
+	if s == "" || strings.ContainsAny(s, ".eE-") {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func joinParamPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
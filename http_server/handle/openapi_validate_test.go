@@ -0,0 +1,26 @@
+package handle
+
+import "testing"
+
+// string lengths are in characters, an account of multi-byte chars is as long as it looks
+func TestValidateStringLength(t *testing.T) {
+	min, max := 2, 5
+	schema := &OpenApiSchema{Type: "string", MinLength: &min, MaxLength: &max}
+	for _, v := range []struct {
+		value string
+		ok    bool
+	}{
+		{"ab", true},
+		{"abcde", true},
+		{"😀😀😀😀😀", true},
+		{"中文", true},
+		{"a", false},
+		{"😀", false},
+		{"abcdef", false},
+		{"😀😀😀😀😀😀", false},
+	} {
+		if err := validateSchema(schema, v.value, "account"); (err == nil) != v.ok {
+			t.Fatal(v.value, err)
+		}
+	}
+}
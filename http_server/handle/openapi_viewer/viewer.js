@@ -0,0 +1,202 @@
+// viewer of openapi.json, served by the indexer itself so that no third-party script runs on the api origin
+(function () {
+    "use strict";
+    var root = window.location.href.replace(/[?#].*$/, "").replace(/\/docs\/?$/, "/");
+    var spec;
+
+    function el(tag, attrs, children) {
+        var e = document.createElement(tag);
+        Object.keys(attrs || {}).forEach(function (k) {
+            if (k === "text") {
+                e.textContent = attrs[k];
+            } else {
+                e.setAttribute(k, attrs[k]);
+            }
+        });
+        (children || []).forEach(function (c) {
+            if (c) {
+                e.appendChild(c);
+            }
+        });
+        return e;
+    }
+
+    function resolve(schema) {
+        var seen = 0;
+        while (schema && schema.$ref && seen++ < 16) {
+            schema = spec.components.schemas[schema.$ref.replace("#/components/schemas/", "")];
+        }
+        return schema || {};
+    }
+
+    function typeOf(schema) {
+        var name = schema.$ref ? schema.$ref.replace("#/components/schemas/", "") : "";
+        var s = resolve(schema);
+        if (s.type === "array") {
+            return typeOf(s.items || {}) + "[]";
+        }
+        return name || s.type || "any";
+    }
+
+    function constraints(s) {
+        var list = [];
+        if (s.enum) {
+            list.push("one of " + s.enum.join(", "));
+        }
+        if (s.minimum !== undefined) {
+            list.push(">= " + s.minimum);
+        }
+        if (s.minLength !== undefined || s.maxLength !== undefined) {
+            list.push("length " + (s.minLength || 0) + ".." + (s.maxLength === undefined ? "" : s.maxLength));
+        }
+        if (s.minItems !== undefined || s.maxItems !== undefined) {
+            list.push("items " + (s.minItems || 0) + ".." + (s.maxItems === undefined ? "" : s.maxItems));
+        }
+        return list.join(", ");
+    }
+
+    // fields of an object schema, nested objects indented below their field
+    function fields(schema, prefix, rows, depth) {
+        var s = resolve(schema);
+        if (s.type === "array") {
+            s = resolve(s.items || {});
+        }
+        if (!s.properties || depth > 6) {
+            return rows;
+        }
+        var required = s.required || [];
+        Object.keys(s.properties).sort().forEach(function (k) {
+            var p = s.properties[k];
+            var r = resolve(p);
+            rows.push(el("tr", {}, [
+                el("td", {class: "name", text: prefix + k}),
+                el("td", {class: "type", text: typeOf(p)}),
+                el("td", {class: "req", text: required.indexOf(k) >= 0 ? "required" : ""}),
+                el("td", {text: [r.description || p.description || "", constraints(r)].filter(Boolean).join(" · ")})
+            ]));
+            fields(p, prefix + k + (r.type === "array" ? "[]." : "."), rows, depth + 1);
+        });
+        return rows;
+    }
+
+    function fieldTable(schema) {
+        var rows = fields(schema, "", [], 0);
+        if (rows.length === 0) {
+            return el("p", {text: typeOf(schema)});
+        }
+        return el("table", {}, [el("tr", {}, [
+            el("th", {text: "field"}), el("th", {text: "type"}), el("th", {text: ""}), el("th", {text: "description"})
+        ])].concat(rows));
+    }
+
+    function example(schema, depth) {
+        var s = resolve(schema);
+        if (depth > 6) {
+            return null;
+        }
+        if (s.enum) {
+            return s.enum[0];
+        }
+        switch (s.type) {
+            case "object":
+                var res = {};
+                Object.keys(s.properties || {}).sort().forEach(function (k) {
+                    res[k] = example(s.properties[k], depth + 1);
+                });
+                return res;
+            case "array":
+                return [example(s.items || {}, depth + 1)];
+            case "integer":
+            case "number":
+                return 0;
+            case "boolean":
+                return false;
+            case "string":
+                return "";
+        }
+        return null;
+    }
+
+    function jsonSchema(body) {
+        return body && body.content && body.content["application/json"] ? body.content["application/json"].schema : null;
+    }
+
+    function tryIt(path, reqSchema) {
+        var input = el("textarea", {spellcheck: "false"});
+        input.value = reqSchema ? JSON.stringify(example(reqSchema, 0), null, 2) : "";
+        var output = el("pre", {text: ""});
+        var send = el("button", {type: "button", text: "Send"});
+        send.addEventListener("click", function () {
+            output.textContent = "...";
+            fetch(new URL(path.replace(/^\//, ""), root).href, {
+                method: "POST",
+                headers: {"Content-Type": "application/json"},
+                body: input.value
+            }).then(function (res) {
+                return res.text().then(function (text) {
+                    try {
+                        text = JSON.stringify(JSON.parse(text), null, 2);
+                    } catch (e) {
+                    }
+                    output.textContent = res.status + "\n" + text;
+                });
+            }).catch(function (e) {
+                output.textContent = String(e);
+            });
+        });
+        return el("div", {}, [el("h4", {text: "Try it"}), reqSchema ? input : null, send, output]);
+    }
+
+    function operation(path, op) {
+        var reqSchema = jsonSchema(op.requestBody);
+        var respSchema = jsonSchema((op.responses || {})["200"]);
+        var details = el("details", {class: "op", "data-key": (path + " " + op.operationId).toLowerCase()}, [
+            el("summary", {}, [
+                el("span", {class: "verb", text: "POST"}),
+                el("span", {class: "path", text: path}),
+                el("span", {class: "opid", text: op.operationId || ""})
+            ])
+        ]);
+        var body = el("div", {class: "body"}, [
+            op.description ? el("p", {text: op.description}) : null,
+            el("h4", {text: "Request"}),
+            reqSchema ? fieldTable(reqSchema) : el("p", {text: "no params"}),
+            el("h4", {text: "Response"}),
+            respSchema ? fieldTable(respSchema) : null
+        ]);
+        details.appendChild(body);
+        details.addEventListener("toggle", function () {
+            if (details.open && !details.dataset.tried) {
+                details.dataset.tried = "1";
+                body.appendChild(tryIt(path, reqSchema));
+            }
+        });
+        return details;
+    }
+
+    function render() {
+        document.getElementById("title").textContent = spec.info.title + " " + spec.info.version;
+        var list = document.getElementById("operations");
+        Object.keys(spec.paths).sort().forEach(function (path) {
+            var op = spec.paths[path].post;
+            if (op) {
+                list.appendChild(operation(path, op));
+            }
+        });
+        document.getElementById("filter").addEventListener("input", function (e) {
+            var q = e.target.value.toLowerCase();
+            Array.prototype.forEach.call(list.children, function (d) {
+                d.style.display = d.dataset.key.indexOf(q) >= 0 ? "" : "none";
+            });
+        });
+    }
+
+    fetch(new URL("openapi.json", root).href).then(function (res) {
+        return res.json();
+    }).then(function (res) {
+        spec = res;
+        render();
+    }).catch(function (e) {
+        document.getElementById("operations").textContent = "openapi.json: " + e;
+    });
+})();
@@ -1,163 +1,202 @@
 package handle
 
 import (
+	"bytes"
+	"das-account-indexer/config"
 	"das-account-indexer/http_server/code"
 	"das-account-indexer/prometheus"
+	"encoding/json"
 	"fmt"
 	"github.com/dotbitHQ/das-lib/http_api"
 	"github.com/gin-gonic/gin"
 	"github.com/scorpiotzh/toolib"
 	"net/http"
+	"sync"
 	"time"
 )
 
-func (h *HttpHandle) Query(ctx *gin.Context) {
+const defaultJsonRpcBatchLimit = 20
+
+// QueryIndexer serves single calls as well as JSON-RPC 2.0 batch arrays, in strict mode the responses
+// carry standard error objects instead of an err_no inside result
+func (h *HttpHandle) QueryIndexer(ctx *gin.Context) {
 	var (
-		req      http_api.JsonRequest
-		resp     http_api.JsonResponse
-		apiResp  http_api.ApiResp
 		clientIp = GetClientIp(ctx)
+		strict   = config.Cfg.JsonRpc.Strict || ctx.Query("strict") == "true"
 	)
-	resp.Result = &apiResp
-
-	start := time.Now()
-	defer func() {
-		prometheus.Tools.Metrics.Api().WithLabelValues(req.Method, "200", fmt.Sprint(apiResp.ErrNo), apiResp.ErrMsg).Observe(time.Since(start).Seconds())
-	}()
 
-	err := ctx.ShouldBindJSON(&req)
+	body, err := ctx.GetRawData()
 	if err != nil {
-		log.Error("ShouldBindJSON err:", err.Error())
-		apiResp.ApiRespErr(http_api.ApiCodeParamsInvalid, "params invalid")
-		ctx.JSON(http.StatusOK, resp)
+		log.Error("GetRawData err:", err.Error())
+	}
+	body = bytes.TrimSpace(body)
+	if len(body) > 0 && body[0] == '[' {
+		h.queryIndexerBatch(ctx, body, strict)
 		return
 	}
 
-	resp.ID, resp.JsonRpc = req.ID, req.JsonRpc
-	log.Info("Query:", req.Method, clientIp, toolib.JsonString(req))
-
-	switch req.Method {
-	case code.MethodServerInfo:
-		h.JsonRpcServerInfo(req.Params, &apiResp)
-	case code.MethodSearchAccount:
-		h.JsonRpcSearchAccount(req.Params, &apiResp)
-	case code.MethodAddressAccount:
-		h.JsonRpcAddressAccount(req.Params, &apiResp)
-	default:
-		log.Error("method not exist:", req.Method)
-		apiResp.ApiRespErr(http_api.ApiCodeMethodNotExist, fmt.Sprintf("method [%s] not exits", req.Method))
+	var req code.JsonRpcCall
+	if err := json.Unmarshal(body, &req); err != nil {
+		log.Error("json.Unmarshal err:", err.Error())
+		if strict && json.Valid(body) {
+			// json, just not a call object
+			ctx.JSON(http.StatusOK, code.NewJsonRpcError(nil, code.JsonRpcCodeInvalidRequest, "invalid request"))
+			return
+		}
+		ctx.JSON(http.StatusOK, jsonRpcParseError(strict))
+		return
 	}
+	log.Info("QueryIndexer:", req.Method, clientIp, toolib.JsonString(req))
 
+	resp := h.queryIndexerCall(ctx, &req, strict)
+	if skipJsonRpcResponse(&req, strict) {
+		ctx.Status(http.StatusNoContent)
+		return
+	}
 	ctx.JSON(http.StatusOK, resp)
-	return
 }
 
-func (h *HttpHandle) QueryIndexer(ctx *gin.Context) {
-	var (
-		req      http_api.JsonRequest
-		resp     http_api.JsonResponse
-		apiResp  http_api.ApiResp
-		clientIp = GetClientIp(ctx)
-	)
-	resp.Result = &apiResp
-
-	start := time.Now()
-	defer func() {
-		prometheus.Tools.Metrics.Api().WithLabelValues(req.Method, "200", fmt.Sprint(apiResp.ErrNo), apiResp.ErrMsg).Observe(time.Since(start).Seconds())
-	}()
-
-	err := ctx.ShouldBindJSON(&req)
-	if err != nil {
-		log.Error("ShouldBindJSON err:", err.Error())
-		apiResp.ApiRespErr(http_api.ApiCodeParamsInvalid, "params invalid")
-		ctx.JSON(http.StatusOK, resp)
+func (h *HttpHandle) queryIndexerBatch(ctx *gin.Context, body []byte, strict bool) {
+	var reqs []json.RawMessage
+	if err := json.Unmarshal(body, &reqs); err != nil {
+		log.Error("json.Unmarshal err:", err.Error())
+		ctx.JSON(http.StatusOK, jsonRpcParseError(strict))
 		return
 	}
-
-	resp.ID, resp.JsonRpc = req.ID, req.JsonRpc
-	log.Info("QueryIndexer:", req.Method, clientIp, toolib.JsonString(req))
-
-	switch req.Method {
-	case code.MethodSearchAccount:
-		h.JsonRpcSearchAccount(req.Params, &apiResp)
-	case code.MethodAddressAccount:
-		h.JsonRpcAddressAccount(req.Params, &apiResp)
-	case code.MethodDidNumber:
-		h.JsonRpcDidNumber(req.Params, &apiResp)
-	case code.MethodServerInfo:
-		h.JsonRpcServerInfo(req.Params, &apiResp)
-	case code.MethodAccountInfo:
-		h.JsonRpcAccountInfo(req.Params, &apiResp)
-	case code.MethodAccountList:
-		h.JsonRpcAccountList(req.Params, &apiResp)
-	case code.MethodAccountRecords:
-		h.JsonRpcAccountRecords(req.Params, &apiResp)
-	case code.MethodAccountReverseAddress:
-		h.JsonRpcAccountReverseAddress(req.Params, &apiResp)
-	case code.MethodBatchAccountRecords:
-		h.JsonRpcBatchAccountRecords(req.Params, &apiResp)
-	case code.MethodAccountRecordsV2:
-		h.JsonRpcAccountRecordsV2(req.Params, &apiResp)
-	case code.MethodReverseRecord:
-		h.JsonRpcReverseRecord(req.Params, &apiResp)
-	case code.MethodBatchReverseRecord:
-		h.JsonRpcBatchReverseRecord(req.Params, &apiResp)
-	case code.MethodBatchRegisterInfo:
-		h.JsonRpcBatchRegisterInfo(req.Params, &apiResp)
-	case code.MethodSubAccountList:
-		h.JsonRpcSubAccountList(req.Params, &apiResp)
-	case code.MethodSubAccountVerify:
-		h.JsonRpcSubAccountVerify(req.Params, &apiResp)
-	case code.MethodDidCellList:
-		h.JsonRpcDidList(ctx, req.Params, &apiResp)
-	default:
-		log.Error("method not exist:", req.Method)
-		apiResp.ApiRespErr(http_api.ApiCodeMethodNotExist, fmt.Sprintf("method [%s] not exits", req.Method))
+	limit := config.Cfg.JsonRpc.BatchLimit
+	if limit <= 0 {
+		limit = defaultJsonRpcBatchLimit
+	}
+	if len(reqs) == 0 || len(reqs) > limit {
+		msg := fmt.Sprintf("batch size must be between 1 and %d", limit)
+		if strict {
+			ctx.JSON(http.StatusOK, code.NewJsonRpcError(nil, code.JsonRpcCodeInvalidRequest, msg))
+		} else {
+			ctx.JSON(http.StatusOK, http_api.JsonResponse{Result: http_api.ApiRespErr(http_api.ApiCodeParamsInvalid, msg)})
+		}
+		return
+	}
+	log.Info("queryIndexerBatch:", len(reqs), GetClientIp(ctx))
+
+	list := make([]interface{}, len(reqs))
+	var wg sync.WaitGroup
+	for i := range reqs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			defer func() {
+				if r := recover(); r != nil {
+					log.Error("queryIndexerBatch panic:", r, string(reqs[i]))
+					list[i] = code.NewJsonRpcError(nil, code.JsonRpcCodeInternalError, "internal error")
+				}
+			}()
+			var req code.JsonRpcCall
+			if err := json.Unmarshal(reqs[i], &req); err != nil {
+				if strict {
+					list[i] = code.NewJsonRpcError(nil, code.JsonRpcCodeInvalidRequest, "invalid request")
+				} else {
+					list[i] = http_api.JsonResponse{Result: http_api.ApiRespErr(http_api.ApiCodeParamsInvalid, "params invalid")}
+				}
+				return
+			}
+			resp := h.queryIndexerCall(ctx, &req, strict)
+			if !skipJsonRpcResponse(&req, strict) {
+				list[i] = resp
+			}
+		}(i)
 	}
+	wg.Wait()
 
+	resp := make([]interface{}, 0, len(list))
+	for _, v := range list {
+		if v != nil {
+			resp = append(resp, v)
+		}
+	}
+	if len(resp) == 0 {
+		ctx.Status(http.StatusNoContent)
+		return
+	}
 	ctx.JSON(http.StatusOK, resp)
-	return
 }
 
-func (h *HttpHandle) QueryReverse(ctx *gin.Context) {
-	var (
-		req      http_api.JsonRequest
-		resp     http_api.JsonResponse
-		apiResp  http_api.ApiResp
-		clientIp = GetClientIp(ctx)
-	)
-	resp.Result = &apiResp
+// skipJsonRpcResponse tells whether a call is a notification to leave unanswered, single or in a batch.
+// Only strict mode has notifications, existing clients leave out the id and still expect their answer
+func skipJsonRpcResponse(req *code.JsonRpcCall, strict bool) bool {
+	return strict && req.IsNotification()
+}
 
+func (h *HttpHandle) queryIndexerCall(ctx *gin.Context, req *code.JsonRpcCall, strict bool) interface{} {
+	var apiResp http_api.ApiResp
 	start := time.Now()
 	defer func() {
 		prometheus.Tools.Metrics.Api().WithLabelValues(req.Method, "200", fmt.Sprint(apiResp.ErrNo), apiResp.ErrMsg).Observe(time.Since(start).Seconds())
 	}()
 
-	err := ctx.ShouldBindJSON(&req)
-	if err != nil {
-		log.Error("ShouldBindJSON err:", err.Error())
-		apiResp.ApiRespErr(http_api.ApiCodeParamsInvalid, "params invalid")
-		ctx.JSON(http.StatusOK, resp)
-		return
+	if strict && (req.JsonRpc != "2.0" || req.Method == "") {
+		apiResp.ErrMsg = "invalid request"
+		return code.NewJsonRpcError(req.ID, code.JsonRpcCodeInvalidRequest, apiResp.ErrMsg)
 	}
+	h.doQueryIndexer(ctx, req, &apiResp)
+	params := jsonRpcParam(req.Params)
+	if m, ok := GetMethod(req.Method); ok && m.noParams {
+		params = nil
+	}
+	signature := h.signApiResp(req.Method, params, &apiResp)
+	if strict {
+		return code.NewSignedJsonRpcResponse(req.ID, &apiResp, signature)
+	}
+	return http_api.JsonResponse{ID: req.ID, JsonRpc: req.JsonRpc, Result: &SignedApiResp{ApiResp: apiResp, Signature: signature}}
+}
 
-	resp.ID, resp.JsonRpc = req.ID, req.JsonRpc
-	log.Info("QueryReverse:", req.Method, clientIp, toolib.JsonString(req))
-
-	switch req.Method {
-	case code.MethodServerInfo:
-		h.JsonRpcServerInfo(req.Params, &apiResp)
-	case code.MethodAccountInfo:
-		h.JsonRpcAccountInfo(req.Params, &apiResp)
-	case code.MethodAccountList:
-		h.JsonRpcAccountList(req.Params, &apiResp)
-	case code.MethodReverseRecord:
-		h.JsonRpcReverseRecord(req.Params, &apiResp)
-	default:
+func (h *HttpHandle) doQueryIndexer(ctx *gin.Context, req *code.JsonRpcCall, apiResp *http_api.ApiResp) {
+	m, ok := GetMethod(req.Method)
+	if !ok {
 		log.Error("method not exist:", req.Method)
 		apiResp.ApiRespErr(http_api.ApiCodeMethodNotExist, fmt.Sprintf("method [%s] not exits", req.Method))
+		return
+	}
+	if m.Cache == CacheNone {
+		ctx.Set(CtxKeyNoCache, true)
 	}
+	h.JsonRpc(ctx.Request.Context(), m, req.Params, apiResp)
+}
 
-	ctx.JSON(http.StatusOK, resp)
-	return
+func jsonRpcParseError(strict bool) interface{} {
+	if strict {
+		return code.NewJsonRpcError(nil, code.JsonRpcCodeParseError, "parse error")
+	}
+	return http_api.JsonResponse{Result: http_api.ApiRespErr(http_api.ApiCodeParamsInvalid, "params invalid")}
+}
+
+// JsonRpcWeight is what a call to the indexer root weighs in the rate limits, the weights of its calls
+// summed up for a batch array
+func JsonRpcWeight(body []byte) int {
+	body = bytes.TrimSpace(body)
+	var reqs []json.RawMessage
+	if len(body) > 0 && body[0] == '[' {
+		if err := json.Unmarshal(body, &reqs); err != nil || len(reqs) == 0 {
+			return 1
+		}
+	} else {
+		reqs = []json.RawMessage{body}
+	}
+	weight := 0
+	for _, v := range reqs {
+		weight += jsonRpcCallWeight(v)
+	}
+	return weight
+}
+
+func jsonRpcCallWeight(call json.RawMessage) int {
+	var req code.JsonRpcCall
+	var params []json.RawMessage
+	if err := json.Unmarshal(call, &req); err != nil {
+		return 1
+	}
+	m, ok := GetMethod(req.Method)
+	if !ok || json.Unmarshal(req.Params, &params) != nil || len(params) != 1 {
+		return 1
+	}
+	return m.Weight(params[0])
 }
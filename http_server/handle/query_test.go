@@ -0,0 +1,39 @@
+package handle
+
+import (
+	"das-account-indexer/http_server/code"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// in strict mode a body that is not json is a parse error, json that is not a call an invalid request
+func TestQueryIndexerStrictErrors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.POST("/", (&HttpHandle{}).QueryIndexer)
+
+	for _, v := range []struct {
+		body string
+		code int
+	}{
+		{`{`, code.JsonRpcCodeParseError},
+		{`not json`, code.JsonRpcCodeParseError},
+		{`1`, code.JsonRpcCodeInvalidRequest},
+		{`"x"`, code.JsonRpcCodeInvalidRequest},
+		{`true`, code.JsonRpcCodeInvalidRequest},
+		{`[1`, code.JsonRpcCodeParseError},
+	} {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/?strict=true", strings.NewReader(v.body)))
+		var resp code.JsonRpcErrorResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatal(v.body, err, w.Body.String())
+		} else if resp.Error == nil || resp.Error.Code != v.code {
+			t.Fatal(v.body, w.Body.String())
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package handle
+
+import (
+	"context"
+	"das-account-indexer/tables"
+	"fmt"
+	"github.com/dotbitHQ/das-lib/common"
+	"github.com/dotbitHQ/das-lib/core"
+	"github.com/dotbitHQ/das-lib/http_api"
+	"strings"
+)
+
+type ReqRecordsByValue struct {
+	CoinType common.CoinType `json:"coin_type"`
+	Address  string          `json:"address"`
+	Key      string          `json:"key"` // any record key, e.g. profile.twitter, address.60
+	Value    string          `json:"value"`
+	Pagination
+}
+
+type RespRecordsByValue struct {
+	Total      int64                   `json:"total"`
+	List       []RecordsByValueAccount `json:"list"`
+	NextCursor string                  `json:"next_cursor"`
+}
+
+type RecordsByValueAccount struct {
+	AccountId   string       `json:"account_id"`
+	Account     string       `json:"account"`
+	DisplayName string       `json:"display_name"`
+	ExpiredAt   uint64       `json:"expired_at"`
+	IsOwner     bool         `json:"is_owner"`
+	IsManager   bool         `json:"is_manager"`
+	Records     []DataRecord `json:"records"`
+}
+
+func (h *HttpHandle) doRecordsByValue(ctx context.Context, req *ReqRecordsByValue, apiResp *http_api.ApiResp) error {
+	var resp RespRecordsByValue
+	resp.List = make([]RecordsByValueAccount, 0)
+
+	var recordType string
+	var keys, values []string
+	var addrHex *core.DasAddressHex
+	req.Address = strings.TrimSpace(req.Address)
+	req.Key = strings.TrimSpace(req.Key)
+	req.Value = strings.TrimSpace(req.Value)
+	if req.Address != "" {
+		if req.CoinType == "" {
+			apiResp.ApiRespErr(http_api.ApiCodeParamsInvalid, "coin_type invalid")
+			return nil
+		}
+		recordType = "address"
+		keys = recordAddressKeys(req.CoinType)
+		values = normalizeRecordAddress(req.CoinType, req.Address)
+		cta := core.ChainTypeAddress{
+			Type:    "blockchain",
+			KeyInfo: core.KeyInfo{CoinType: req.CoinType, Key: req.Address},
+		}
+		if res, err := cta.FormatChainTypeAddress(h.DasCore.NetType(), true); err == nil {
+			addrHex = res
+		}
+	} else if req.Key != "" && req.Value != "" {
+		index := strings.Index(req.Key, ".")
+		if index <= 0 || index == len(req.Key)-1 {
+			apiResp.ApiRespErr(http_api.ApiCodeParamsInvalid, "key invalid")
+			return nil
+		}
+		recordType = req.Key[:index]
+		keys = []string{req.Key[index+1:]}
+		if recordType == "address" {
+			if k := common.ConvertRecordsAddressCoinType(req.Key); k != req.Key {
+				keys = append(keys, strings.TrimPrefix(k, "address."))
+			} else if k = common.ConvertRecordsAddressKey(req.Key); k != req.Key {
+				keys = append(keys, strings.TrimPrefix(k, "address."))
+			}
+		}
+		values = []string{req.Value}
+	} else {
+		apiResp.ApiRespErr(http_api.ApiCodeParamsInvalid, "params invalid")
+		return nil
+	}
+	log.Info(ctx, "doRecordsByValue:", recordType, keys, values)
+
+	cursor, err := req.GetCursor()
+	if err != nil {
+		apiResp.ApiRespErr(http_api.ApiCodeParamsInvalid, "cursor invalid")
+		return nil
+	}
+	page, err := h.DbDao.FindAccountIdsByRecordValue(recordType, keys, values, cursor, req.GetLimit(), req.GetOffset())
+	if err != nil {
+		apiResp.ApiRespErr(http_api.ApiCodeDbError, "find records err")
+		return fmt.Errorf("FindAccountIdsByRecordValue err: %s", err.Error())
+	}
+	total, err := h.DbDao.FindTotalAccountIdsByRecordValue(recordType, keys, values)
+	if err != nil {
+		apiResp.ApiRespErr(http_api.ApiCodeDbError, "find records total err")
+		return fmt.Errorf("FindTotalAccountIdsByRecordValue err: %s", err.Error())
+	}
+	resp.Total = total
+	if len(page) == 0 {
+		apiResp.ApiRespOK(resp)
+		return nil
+	}
+	// the next page starts after the last row of this one, whether or not its account is still there to list
+	last := page[len(page)-1]
+	resp.NextCursor = req.NextCursor(len(page), last.Account, last.Id)
+
+	accountIds := make([]string, 0, len(page))
+	for _, v := range page {
+		accountIds = append(accountIds, v.AccountId)
+	}
+
+	accounts, err := h.DbDao.FindAccountInfoListByAccountIds(accountIds)
+	if err != nil {
+		apiResp.ApiRespErr(http_api.ApiCodeDbError, "find accounts err")
+		return fmt.Errorf("FindAccountInfoListByAccountIds err: %s", err.Error())
+	}
+	records, err := h.DbDao.FindRecordsByAccountIdsValue(accountIds, recordType, keys, values)
+	if err != nil {
+		apiResp.ApiRespErr(http_api.ApiCodeDbError, "find records err")
+		return fmt.Errorf("FindRecordsByAccountIdsValue err: %s", err.Error())
+	}
+	var mapAcc = make(map[string]tables.TableAccountInfo)
+	for i, v := range accounts {
+		mapAcc[v.AccountId] = accounts[i]
+	}
+	var mapRecords = make(map[string][]DataRecord)
+	for _, v := range records {
+		key := fmt.Sprintf("%s.%s", v.Type, v.Key)
+		mapRecords[v.AccountId] = append(mapRecords[v.AccountId], DataRecord{
+			Key:   common.ConvertRecordsAddressCoinType(key),
+			Label: v.Label,
+			Value: v.Value,
+			TTL:   v.Ttl,
+		})
+	}
+
+	for _, accountId := range accountIds {
+		acc, ok := mapAcc[accountId]
+		if !ok {
+			continue
+		}
+		item := RecordsByValueAccount{
+			AccountId:   acc.AccountId,
+			Account:     acc.Account,
+			DisplayName: FormatDisplayName(acc.Account),
+			ExpiredAt:   acc.ExpiredAt,
+			Records:     mapRecords[accountId],
+		}
+		if addrHex != nil && acc.Status != tables.AccountStatusOnUpgrade {
+			item.IsOwner = acc.OwnerChainType == addrHex.ChainType && strings.EqualFold(acc.Owner, addrHex.AddressHex)
+			item.IsManager = acc.ManagerChainType == addrHex.ChainType && strings.EqualFold(acc.Manager, addrHex.AddressHex)
+		}
+		resp.List = append(resp.List, item)
+	}
+	apiResp.ApiRespOK(resp)
+	return nil
+}
+
+// recordAddressKeys returns both spellings an address record of coinType may be stored under, e.g. 60 and eth
+func recordAddressKeys(coinType common.CoinType) []string {
+	keys := []string{string(coinType)}
+	if k, ok := common.RecordsAddressCoinTypeMap["address."+string(coinType)]; ok {
+		keys = append(keys, strings.TrimPrefix(k, "address."))
+	}
+	return keys
+}
+
+// normalizeRecordAddress returns the forms an address may have been written in,
+// the column collation already makes the comparison case-insensitive
+func normalizeRecordAddress(coinType common.CoinType, addr string) []string {
+	values := []string{addr}
+	switch coinType {
+	case common.CoinTypeEth, common.CoinTypeBNB, common.CoinTypeBSC, common.CoinTypeMatic:
+		if !strings.HasPrefix(strings.ToLower(addr), "0x") {
+			values = append(values, "0x"+addr)
+		}
+	case common.CoinTypeTrx:
+		if strings.HasPrefix(addr, common.TronPreFix) {
+			if res, err := common.TronHexToBase58(addr); err == nil {
+				values = append(values, res)
+			}
+		} else if strings.HasPrefix(addr, common.TronBase58PreFix) {
+			if res, err := common.TronBase58ToHex(addr); err == nil {
+				values = append(values, res)
+			}
+		}
+	}
+	return values
+}
@@ -0,0 +1,37 @@
+package handle
+
+import (
+	"encoding/base64"
+	"testing"
+)
+
+func TestPaginationCursor(t *testing.T) {
+	p := Pagination{Size: 2}
+	if res := p.NextCursor(1, "a.bit", 1); res != "" {
+		t.Fatal("last page:", res)
+	}
+	p.Cursor = p.NextCursor(2, "b.bit", 7)
+	cursor, err := p.GetCursor()
+	if err != nil {
+		t.Fatal(err)
+	} else if cursor.Account != "b.bit" || cursor.Id != 7 {
+		t.Fatal("cursor:", cursor)
+	} else if p.GetOffset() != 0 {
+		t.Fatal("offset:", p.GetOffset())
+	}
+
+	for _, v := range []string{
+		"not base64!",
+		base64.RawURLEncoding.EncodeToString([]byte(`{"a":"b.bit","i":`)),
+		base64.RawURLEncoding.EncodeToString([]byte(`{"a":1,"i":7}`)),
+		base64.RawURLEncoding.EncodeToString([]byte(`{"a":"b.bit","i":-1}`)),
+		base64.RawURLEncoding.EncodeToString([]byte(`{}`)),
+		base64.RawURLEncoding.EncodeToString([]byte(`{"a":"","i":7}`)),
+		base64.RawURLEncoding.EncodeToString([]byte(`{"a":"b.bit","i":0}`)),
+	} {
+		p.Cursor = v
+		if cursor, err = p.GetCursor(); err == nil {
+			t.Fatal("tampered cursor accepted:", v, cursor)
+		}
+	}
+}
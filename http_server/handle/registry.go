@@ -0,0 +1,250 @@
+package handle
+
+import (
+	"bytes"
+	"context"
+	"das-account-indexer/http_server/code"
+	"encoding/json"
+	"fmt"
+	"github.com/dotbitHQ/das-lib/common"
+	"github.com/dotbitHQ/das-lib/http_api"
+	"github.com/gin-gonic/gin"
+	"github.com/scorpiotzh/toolib"
+	"io/ioutil"
+	"net/http"
+)
+
+type CachePolicy int
+
+const (
+	CacheNone CachePolicy = iota
+	CacheShort
+)
+
+// CtxKeyNoCache is set on the gin context by json-rpc calls of methods with CacheNone,
+// the cache middleware then leaves the response out of the cache
+const CtxKeyNoCache = "no_cache"
+
+// Method is the single declaration of an api, the REST routes, the json-rpc dispatch
+// and the monitor label are all derived from it
+type Method struct {
+	Name     code.JsonRpcMethod // json-rpc method
+	Label    string             // monitor label of the REST routes, Name unless set
+	Paths    []string           // REST paths below the indexer root, none for json-rpc only methods
+	Cache    CachePolicy
+	Resp     interface{} // zero value of the data returned on success
+	noParams bool
+	batch    string // json field holding the items of a batch call
+	legacy   func(string) interface{}
+	newReq   func() interface{}
+	do       func(h *HttpHandle, ctx context.Context, req interface{}, apiResp *http_api.ApiResp) error
+}
+
+func newMethod[T any](name code.JsonRpcMethod, cache CachePolicy, do func(h *HttpHandle, ctx context.Context, req *T, apiResp *http_api.ApiResp) error, resp interface{}, paths ...string) *Method {
+	return &Method{
+		Name:   name,
+		Label:  name,
+		Paths:  paths,
+		Cache:  cache,
+		Resp:   resp,
+		newReq: func() interface{} { return new(T) },
+		do: func(h *HttpHandle, ctx context.Context, req interface{}, apiResp *http_api.ApiResp) error {
+			return do(h, ctx, req.(*T), apiResp)
+		},
+	}
+}
+
+// withoutParams marks methods whose params are ignored, a REST call may then come without a body
+func withoutParams(m *Method) *Method {
+	m.noParams = true
+	return m
+}
+
+// monitorAs keeps the monitor label a route had before the registry, dashboards are built on it
+func monitorAs(label string, m *Method) *Method {
+	m.Label = label
+	return m
+}
+
+// withLegacyString lets old json-rpc clients send params as ["<value>"], f builds the request from the value
+func withLegacyString[T any](f func(string) *T, m *Method) *Method {
+	m.legacy = func(v string) interface{} { return f(v) }
+	return m
+}
+
+// batchOf marks batch methods, the items in field are what a call weighs in the rate limits
+func batchOf(field string, m *Method) *Method {
+	m.batch = field
+	return m
+}
+
+// Weight is the number of items of a batch call with params, 1 for other calls
+func (m *Method) Weight(params json.RawMessage) int {
+	if m.batch == "" {
+		return 1
+	}
+	var req map[string]json.RawMessage
+	var items []json.RawMessage
+	if err := json.Unmarshal(params, &req); err != nil {
+		return 1
+	} else if err = json.Unmarshal(req[m.batch], &items); err != nil || len(items) == 0 {
+		return 1
+	}
+	return len(items)
+}
+
+var Methods = []*Method{
+	newMethod(code.MethodVersion, CacheShort, (*HttpHandle).doVersion, RespVersion{}, "/v1/version"),
+	newMethod(code.MethodDidNumber, CacheShort, (*HttpHandle).doDidNumber, RespDidNumber{}, "/v1/did/number"),
+	withoutParams(newMethod(code.MethodServerInfo, CacheShort, (*HttpHandle).doServerInfo, RespServerInfo{}, "/v1/server/info")),
+	withoutParams(newMethod(code.MethodSigningKey, CacheShort, (*HttpHandle).doSigningKey, RespSigningKey{}, "/v1/signing/key")),
+
+	withLegacyString(func(v string) *ReqSearchAccount { return &ReqSearchAccount{Account: v} },
+		newMethod(code.MethodSearchAccount, CacheShort, (*HttpHandle).doSearchAccount, RespSearchAccount{})),
+	withLegacyString(func(v string) *ReqAddressAccount { return &ReqAddressAccount{Address: v} },
+		newMethod(code.MethodAddressAccount, CacheShort, (*HttpHandle).doAddressAccount, []RespSearchAccount{})),
+
+	newMethod(code.MethodAccountInfo, CacheShort, (*HttpHandle).doAccountInfo, RespAccountInfo{}, "/v1/account/info"),
+	newMethod(code.MethodAccountList, CacheShort, (*HttpHandle).doAccountList, RespAccountList{}, "/v1/account/list"),
+	newMethod(code.MethodExpiringAccountList, CacheShort, (*HttpHandle).doExpiringAccountList, RespExpiringAccountList{}, "/v1/account/expiring"),
+	batchOf("batch_key_info", newMethod(code.MethodBatchAccountList, CacheShort, (*HttpHandle).doBatchAccountList, RespBatchAccountList{}, "/v1/batch/account/list")),
+	newMethod(code.MethodAccountRecords, CacheShort, recordsMethod(common.ConvertRecordsAddressCoinType), RespAccountRecords{}, "/v1/account/records"),
+	monitorAs("records_list", newMethod(code.MethodRecordList, CacheNone, recordsMethod(common.ConvertRecordsAddressCoinType), RespAccountRecords{}, "/v1/record/list")),
+	newMethod(code.MethodAccountRecordsV2, CacheShort, recordsMethod(common.ConvertRecordsAddressKey), RespAccountRecords{}, "/v2/account/records"),
+	batchOf("accounts", newMethod(code.MethodBatchAccountRecords, CacheShort, (*HttpHandle).doBatchAccountRecords, RespBatchAccountRecords{}, "/v1/batch/account/records")),
+	newMethod(code.MethodAccountReverseAddress, CacheShort, (*HttpHandle).doAccountReverseAddress, RespAccountReverseAddress{}, "/v1/account/reverse/address"),
+	newMethod(code.MethodRecordsByValue, CacheShort, (*HttpHandle).doRecordsByValue, RespRecordsByValue{}, "/v1/records/search"),
+	newMethod(code.MethodAccountNameSearch, CacheShort, (*HttpHandle).doAccountNameSearch, RespAccountNameSearch{}, "/v1/account/name/search"),
+	newMethod(code.MethodResolve, CacheShort, (*HttpHandle).doResolve, RespResolve{}, "/v1/resolve"),
+	newMethod(code.MethodAccountProfile, CacheShort, (*HttpHandle).doAccountProfile, AccountProfile{}, "/v1/account/profile"),
+	batchOf("accounts", newMethod(code.MethodBatchAccountProfile, CacheShort, (*HttpHandle).doBatchAccountProfile, RespBatchAccountProfile{}, "/v1/batch/account/profile")),
+	newMethod(code.MethodReverseRecord, CacheShort, (*HttpHandle).doReverseRecord, RespReverseRecord{}),
+	batchOf("batch_key_info", newMethod(code.MethodBatchReverseRecord, CacheShort, (*HttpHandle).doBatchReverseRecord, RespBatchReverseRecord{})),
+	monitorAs(code.MethodReverseRecord, newMethod(code.MethodReverseRecordV2, CacheShort, (*HttpHandle).doReverseRecordV2, RespReverseRecordV2{}, "/v1/reverse/record")),
+	monitorAs(code.MethodBatchReverseRecord, batchOf("batch_key_info", newMethod(code.MethodBatchReverseRecordV2, CacheShort, (*HttpHandle).doBatchReverseRecordV2, RespBatchReverseRecordV2{}, "/v1/batch/reverse/record"))),
+	batchOf("batch_account", newMethod(code.MethodBatchRegisterInfo, CacheShort, (*HttpHandle).doBatchRegisterInfo, RespBatchRegisterInfo{}, "/v1/batch/register/info")),
+
+	newMethod(code.MethodSubAccountList, CacheShort, (*HttpHandle).doSubAccountList, RespSubAccountList{}, "/v1/sub/account/list"),
+	newMethod(code.MethodSubAccountVerify, CacheShort, (*HttpHandle).doSubAccountVerify, RespSubAccountVerify{}, "/v1/sub/account/verify"),
+	newMethod(code.MethodSubAccountStats, CacheShort, (*HttpHandle).doSubAccountStats, RespSubAccountStats{}, "/v1/sub/account/stats"),
+	newMethod(code.MethodSubAccountTree, CacheShort, (*HttpHandle).doSubAccountTree, RespSubAccountTree{}, "/v1/sub/account/tree"),
+	monitorAs("did_list", newMethod(code.MethodDidCellList, CacheShort, (*HttpHandle).doDidList, RespDidList{}, "/v1/did/list")),
+}
+
+var mapMethods = func() map[code.JsonRpcMethod]*Method {
+	res := make(map[code.JsonRpcMethod]*Method, len(Methods))
+	for _, m := range Methods {
+		if _, ok := res[m.Name]; ok {
+			panic(fmt.Sprintf("method [%s] registered twice", m.Name))
+		}
+		res[m.Name] = m
+	}
+	return res
+}()
+
+var mapPathMethods = func() map[string]*Method {
+	res := make(map[string]*Method)
+	for _, m := range Methods {
+		for _, path := range m.Paths {
+			res[path] = m
+		}
+	}
+	return res
+}()
+
+func GetMethod(name code.JsonRpcMethod) (*Method, bool) {
+	m, ok := mapMethods[name]
+	return m, ok
+}
+
+func GetMethodByPath(path string) (*Method, bool) {
+	m, ok := mapPathMethods[path]
+	return m, ok
+}
+
+func recordsMethod(convertRecordsFunc ConvertRecordsFunc) func(h *HttpHandle, ctx context.Context, req *ReqAccountRecords, apiResp *http_api.ApiResp) error {
+	return func(h *HttpHandle, ctx context.Context, req *ReqAccountRecords, apiResp *http_api.ApiResp) error {
+		return h.doAccountRecords(ctx, req, apiResp, convertRecordsFunc)
+	}
+}
+
+// Rest returns the gin handler serving m on its REST paths
+func (h *HttpHandle) Rest(m *Method) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var (
+			funcName = m.Name
+			req      = m.newReq()
+			apiResp  http_api.ApiResp
+			clientIp = GetClientIp(ctx)
+		)
+
+		var params json.RawMessage
+		if !m.noParams {
+			params, _ = ctx.GetRawData()
+			if err := validateParams(m, params); err != nil && len(params) > 0 {
+				log.Warn("validateParams:", err.Error(), funcName, clientIp, ctx.Request.Context())
+				apiResp.ApiRespErr(http_api.ApiCodeParamsInvalid, "params invalid: "+err.Error())
+				ctx.JSON(http.StatusOK, h.signedApiResp(m.Name, params, &apiResp))
+				return
+			}
+			ctx.Request.Body = ioutil.NopCloser(bytes.NewBuffer(params))
+			if err := ctx.ShouldBindJSON(req); err != nil {
+				log.Error("ShouldBindJSON err: ", err.Error(), funcName, clientIp, ctx.Request.Context())
+				apiResp.ApiRespErr(http_api.ApiCodeParamsInvalid, "params invalid")
+				ctx.JSON(http.StatusOK, h.signedApiResp(m.Name, params, &apiResp))
+				return
+			}
+		}
+		log.Info("ApiReq:", ctx.Request.Host, funcName, clientIp, toolib.JsonString(req), ctx.Request.Context())
+
+		if err := m.do(h, ctx.Request.Context(), req, &apiResp); err != nil {
+			log.Error("do err:", err.Error(), funcName, clientIp, ctx.Request.Context())
+		}
+
+		ctx.JSON(http.StatusOK, h.signedApiResp(m.Name, params, &apiResp))
+	}
+}
+
+// JsonRpc serves m as a json-rpc call, params is an array holding the one request
+func (h *HttpHandle) JsonRpc(ctx context.Context, m *Method, p json.RawMessage, apiResp *http_api.ApiResp) {
+	req := m.jsonRpcReq(p, apiResp)
+	if apiResp.ErrNo != http_api.ApiCodeSuccess {
+		return
+	}
+	if err := m.do(h, ctx, req, apiResp); err != nil {
+		log.Error("do err:", err.Error(), m.Name)
+	}
+}
+
+func (m *Method) jsonRpcReq(p json.RawMessage, apiResp *http_api.ApiResp) interface{} {
+	req := m.newReq()
+	if m.noParams {
+		return req
+	}
+	var params []json.RawMessage
+	if err := json.Unmarshal(p, &params); err != nil {
+		log.Error("json.Unmarshal err:", err.Error(), m.Name)
+		apiResp.ApiRespErr(http_api.ApiCodeParamsInvalid, "params invalid")
+		return nil
+	}
+	if len(params) != 1 {
+		log.Error("len(req) is :", len(params), m.Name)
+		apiResp.ApiRespErr(http_api.ApiCodeParamsInvalid, "params invalid")
+		return nil
+	}
+	var old string
+	if m.legacy != nil && json.Unmarshal(params[0], &old) == nil {
+		return m.legacy(old)
+	}
+	if err := validateParams(m, params[0]); err != nil {
+		log.Warn("validateParams:", err.Error(), m.Name)
+		apiResp.ApiRespErr(http_api.ApiCodeParamsInvalid, "params invalid: "+err.Error())
+		return nil
+	}
+	if err := json.Unmarshal(params[0], req); err != nil {
+		log.Error("json.Unmarshal err:", err.Error(), m.Name)
+		apiResp.ApiRespErr(http_api.ApiCodeParamsInvalid, "params invalid")
+		return nil
+	}
+	return req
+}
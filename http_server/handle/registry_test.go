@@ -0,0 +1,70 @@
+package handle
+
+import (
+	"das-account-indexer/http_server/code"
+	"encoding/json"
+	"github.com/dotbitHQ/das-lib/http_api"
+	"testing"
+)
+
+// das_searchAccount and das_getAddressAccount still take the params of old clients, ["<value>"]
+func TestJsonRpcLegacyString(t *testing.T) {
+	for _, v := range []struct {
+		method code.JsonRpcMethod
+		params string
+		want   interface{}
+	}{
+		{code.MethodSearchAccount, `["phone.bit"]`, ReqSearchAccount{Account: "phone.bit"}},
+		{code.MethodSearchAccount, `[{"account":"phone.bit"}]`, ReqSearchAccount{Account: "phone.bit"}},
+		{code.MethodAddressAccount, `["0xc9f53b1d85356b60453f867610888d89a0b667ad"]`, ReqAddressAccount{Address: "0xc9f53b1d85356b60453f867610888d89a0b667ad"}},
+		{code.MethodAddressAccount, `[{"address":"0xc9f53b1d85356b60453f867610888d89a0b667ad"}]`, ReqAddressAccount{Address: "0xc9f53b1d85356b60453f867610888d89a0b667ad"}},
+	} {
+		m, _ := GetMethod(v.method)
+		var apiResp http_api.ApiResp
+		req := m.jsonRpcReq(json.RawMessage(v.params), &apiResp)
+		if apiResp.ErrNo != http_api.ApiCodeSuccess {
+			t.Fatal(v.method, v.params, apiResp.ErrMsg)
+		}
+		var got interface{}
+		switch r := req.(type) {
+		case *ReqSearchAccount:
+			got = *r
+		case *ReqAddressAccount:
+			got = *r
+		}
+		if got != v.want {
+			t.Fatal(v.method, v.params, "req:", req)
+		}
+	}
+
+	// other methods take an object only
+	for _, v := range []struct {
+		method code.JsonRpcMethod
+		params string
+	}{
+		{code.MethodAccountInfo, `["phone.bit"]`},
+		{code.MethodSearchAccount, `["phone.bit","0x01"]`},
+		{code.MethodSearchAccount, `[1]`},
+	} {
+		m, _ := GetMethod(v.method)
+		var apiResp http_api.ApiResp
+		if m.jsonRpcReq(json.RawMessage(v.params), &apiResp); apiResp.ErrNo != http_api.ApiCodeParamsInvalid {
+			t.Fatal(v.method, v.params, "err_no:", apiResp.ErrNo)
+		}
+	}
+}
+
+// the REST routes keep the monitor labels they had before the registry
+func TestMethodLabel(t *testing.T) {
+	for path, label := range map[string]string{
+		"/v1/account/info":         code.MethodAccountInfo,
+		"/v1/did/list":             "did_list",
+		"/v1/record/list":          "records_list",
+		"/v1/reverse/record":       code.MethodReverseRecord,
+		"/v1/batch/reverse/record": code.MethodBatchReverseRecord,
+	} {
+		if m, ok := GetMethodByPath(path); !ok || m.Label != label {
+			t.Fatal(path, "label:", m)
+		}
+	}
+}
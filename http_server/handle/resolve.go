@@ -0,0 +1,182 @@
+package handle
+
+import (
+	"context"
+	"das-account-indexer/config"
+	"das-account-indexer/tables"
+	"fmt"
+	"github.com/dotbitHQ/das-lib/common"
+	"github.com/dotbitHQ/das-lib/core"
+	"github.com/dotbitHQ/das-lib/http_api"
+	"github.com/nervosnetwork/ckb-sdk-go/address"
+	"strings"
+	"time"
+)
+
+type ReqResolve struct {
+	Account   string            `json:"account"`
+	CoinType  common.CoinType   `json:"coin_type"`
+	CoinTypes []common.CoinType `json:"coin_types" binding:"max=50"`
+	Keys      []string          `json:"keys" binding:"max=50"` // text records, e.g. profile.twitter
+}
+
+type RespResolve struct {
+	Account   string            `json:"account"`
+	AccountId string            `json:"account_id"`
+	ExpiredAt uint64            `json:"expired_at"`
+	Addresses []ResolveAddress  `json:"addresses"`
+	Texts     []ResolveTextItem `json:"texts"`
+}
+
+type ResolveAddress struct {
+	CoinType common.CoinType `json:"coin_type"`
+	Address  string          `json:"address"` // empty when nothing resolves
+	Source   string          `json:"source"`  // record, owner
+}
+
+type ResolveTextItem struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+const (
+	ResolveSourceRecord = "record"
+	ResolveSourceOwner  = "owner"
+)
+
+func (h *HttpHandle) doResolve(ctx context.Context, req *ReqResolve, apiResp *http_api.ApiResp) error {
+	var resp RespResolve
+	resp.Addresses = make([]ResolveAddress, 0)
+	resp.Texts = make([]ResolveTextItem, 0)
+
+	req.Account = strings.TrimSpace(req.Account)
+	req.Account = FormatSharpToDot(req.Account)
+	if err := checkAccount(req.Account, apiResp); err != nil {
+		log.Error(ctx, "checkAccount err: ", err.Error())
+		return nil
+	}
+	var coinTypes []common.CoinType
+	var mapCoinType = make(map[common.CoinType]struct{})
+	for _, v := range append([]common.CoinType{req.CoinType}, req.CoinTypes...) {
+		v = common.CoinType(strings.TrimSpace(string(v)))
+		if _, ok := mapCoinType[v]; ok || v == "" {
+			continue
+		}
+		mapCoinType[v] = struct{}{}
+		coinTypes = append(coinTypes, v)
+	}
+	if len(coinTypes) == 0 && len(req.Keys) == 0 {
+		apiResp.ApiRespErr(http_api.ApiCodeParamsInvalid, "coin_type or keys required")
+		return nil
+	}
+
+	accountId := common.Bytes2Hex(common.GetAccountIdByAccount(req.Account))
+	accountInfo, err := h.DbDao.FindAccountInfoByAccountId(accountId)
+	if err != nil {
+		apiResp.ApiRespErr(http_api.ApiCodeDbError, "find account info err")
+		return fmt.Errorf("FindAccountInfoByAccountId err: %s", err.Error())
+	} else if accountInfo.Id == 0 {
+		apiResp.ApiRespErr(http_api.ApiCodeAccountNotExist, "account not exist")
+		return nil
+	} else if accountInfo.Status == tables.AccountStatusOnLock {
+		apiResp.ApiRespErr(http_api.ApiCodeAccountOnLock, "account cross-chain")
+		return nil
+	} else if accountInfo.ExpiredAt <= uint64(time.Now().Unix()) {
+		apiResp.ApiRespErr(http_api.ApiCodeAccountIsExpired, "account expired")
+		return nil
+	}
+	resp.Account = accountInfo.Account
+	resp.AccountId = accountInfo.AccountId
+	resp.ExpiredAt = accountInfo.ExpiredAt
+
+	list, err := h.DbDao.FindAccountRecordsByAccountId(accountId)
+	if err != nil {
+		apiResp.ApiRespErr(http_api.ApiCodeDbError, "find records info err")
+		return fmt.Errorf("FindAccountRecordsByAccountId err: %s", err.Error())
+	}
+
+	if len(coinTypes) > 0 {
+		ownerCoinType, ownerChainType, owner, err := h.resolveOwner(&accountInfo)
+		if err != nil {
+			apiResp.ApiRespErr(http_api.ApiCodeError500, "failed to get owner address")
+			return fmt.Errorf("resolveOwner err: %s", err.Error())
+		}
+		for _, coinType := range coinTypes {
+			item := ResolveAddress{CoinType: coinType}
+			if addr := resolveAddressRecord(list, coinType); addr != "" {
+				item.Address, item.Source = addr, ResolveSourceRecord
+			} else if owner != "" && (coinType == ownerCoinType || common.FormatCoinTypeToDasChainType(coinType) == ownerChainType) {
+				item.Address, item.Source = owner, ResolveSourceOwner
+			}
+			resp.Addresses = append(resp.Addresses, item)
+		}
+	}
+
+	for _, key := range req.Keys {
+		key = strings.TrimSpace(key)
+		item := ResolveTextItem{Key: key}
+		for _, v := range list {
+			if fmt.Sprintf("%s.%s", v.Type, v.Key) == key {
+				item.Value = v.Value
+				break
+			}
+		}
+		resp.Texts = append(resp.Texts, item)
+	}
+
+	apiResp.ApiRespOK(resp)
+	return nil
+}
+
+// resolveAddressRecord returns the first address record of coinType in its canonical form,
+// records may be keyed by coin type or by the legacy chain name
+func resolveAddressRecord(list []tables.TableRecordsInfo, coinType common.CoinType) string {
+	keys := recordAddressKeys(coinType)
+	for _, v := range list {
+		if v.Type != "address" || v.Value == "" {
+			continue
+		}
+		for _, k := range keys {
+			if v.Key != k {
+				continue
+			}
+			if addr, err := common.FormatAddressByCoinType(string(coinType), v.Value); err == nil && addr != "" {
+				return addr
+			}
+			return v.Value
+		}
+	}
+	return ""
+}
+
+// resolveOwner returns the owner address and the coin it belongs to, an upgraded account is owned by its did cell
+func (h *HttpHandle) resolveOwner(acc *tables.TableAccountInfo) (common.CoinType, common.ChainType, string, error) {
+	if acc.Status == tables.AccountStatusOnUpgrade {
+		didCell, err := h.DbDao.GetDidCellByAccountId(acc.AccountId)
+		if err != nil {
+			return "", -1, "", fmt.Errorf("GetDidCellByAccountId err: %s", err.Error())
+		} else if didCell.Id == 0 {
+			return "", -1, "", nil
+		}
+		mode := address.Mainnet
+		if config.Cfg.Server.Net != common.DasNetTypeMainNet {
+			mode = address.Testnet
+		}
+		addr, err := didCell.ToAnyLockAddr(mode)
+		if err != nil {
+			return "", -1, "", fmt.Errorf("ToAnyLockAddr err: %s", err.Error())
+		}
+		return common.CoinTypeCKB, common.ChainTypeAnyLock, addr, nil
+	}
+
+	ownerNormal, err := h.DasCore.Daf().HexToNormal(core.DasAddressHex{
+		DasAlgorithmId:    acc.OwnerAlgorithmId,
+		DasSubAlgorithmId: acc.OwnerSubAid,
+		AddressHex:        acc.Owner,
+		ChainType:         acc.OwnerChainType,
+	})
+	if err != nil {
+		return "", -1, "", fmt.Errorf("HexToNormal err: %s", err.Error())
+	}
+	return common.FormatDasChainTypeToCoinType(acc.OwnerChainType), acc.OwnerChainType, ownerNormal.AddressNormal, nil
+}
@@ -1,16 +1,13 @@
 package handle
 
 import (
+	"context"
 	"das-account-indexer/config"
 	"das-account-indexer/tables"
-	"encoding/json"
 	"fmt"
 	"github.com/dotbitHQ/das-lib/common"
 	"github.com/dotbitHQ/das-lib/core"
 	"github.com/dotbitHQ/das-lib/http_api"
-	"github.com/gin-gonic/gin"
-	"github.com/scorpiotzh/toolib"
-	"net/http"
 	"regexp"
 	"strings"
 )
@@ -25,49 +22,6 @@ type RespReverseRecord struct {
 	DisplayName  string `json:"display_name"`
 }
 
-func (h *HttpHandle) JsonRpcReverseRecord(p json.RawMessage, apiResp *http_api.ApiResp) {
-	var req []ReqReverseRecord
-	err := json.Unmarshal(p, &req)
-	if err != nil {
-		log.Error("json.Unmarshal err:", err.Error())
-		apiResp.ApiRespErr(http_api.ApiCodeParamsInvalid, "params invalid")
-		return
-	}
-	if len(req) != 1 {
-		log.Error("len(req) is :", len(req))
-		apiResp.ApiRespErr(http_api.ApiCodeParamsInvalid, "params invalid")
-		return
-	}
-
-	if err = h.doReverseRecord(&req[0], apiResp); err != nil {
-		log.Error("doReverseRecord err:", err.Error())
-	}
-}
-
-func (h *HttpHandle) ReverseRecord(ctx *gin.Context) {
-	var (
-		funcName = "ReverseRecord"
-		req      ReqReverseRecord
-		apiResp  http_api.ApiResp
-		err      error
-		clientIp = GetClientIp(ctx)
-	)
-
-	if err := ctx.ShouldBindJSON(&req); err != nil {
-		log.Error("ShouldBindJSON err: ", err.Error(), funcName)
-		apiResp.ApiRespErr(http_api.ApiCodeParamsInvalid, "params invalid")
-		ctx.JSON(http.StatusOK, apiResp)
-		return
-	}
-	log.Info("ApiReq:", ctx.Request.Host, funcName, clientIp, toolib.JsonString(req))
-
-	if err = h.doReverseRecord(&req, &apiResp); err != nil {
-		log.Error("doReverseRecord err:", err.Error(), funcName)
-	}
-
-	ctx.JSON(http.StatusOK, apiResp)
-}
-
 func checkReqKeyInfo(daf *core.DasAddressFormat, req *core.ChainTypeAddress, apiResp *http_api.ApiResp) *core.DasAddressHex {
 	if req.Type != "blockchain" {
 		apiResp.ApiRespErr(http_api.ApiCodeParamsInvalid, fmt.Sprintf("type [%s] is invalid", req.Type))
@@ -114,7 +68,8 @@ func checkReqKeyInfo(daf *core.DasAddressFormat, req *core.ChainTypeAddress, api
 	return &addrHex
 }
 
-func (h *HttpHandle) doReverseRecord(req *ReqReverseRecord, apiResp *http_api.ApiResp) error {
+// doReverseRecord is the reverse record of das_reverseRecord, kept for json-rpc clients, REST serves doReverseRecordV2
+func (h *HttpHandle) doReverseRecord(ctx context.Context, req *ReqReverseRecord, apiResp *http_api.ApiResp) error {
 	var resp RespReverseRecord
 	res := checkReqKeyInfo(h.DasCore.Daf(), &req.ChainTypeAddress, apiResp)
 	if apiResp.ErrNo != http_api.ApiCodeSuccess {
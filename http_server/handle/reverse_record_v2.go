@@ -3,69 +3,26 @@ package handle
 import (
 	"context"
 	"das-account-indexer/tables"
-	"encoding/json"
 	"fmt"
 	"github.com/dotbitHQ/das-lib/common"
 	"github.com/dotbitHQ/das-lib/core"
 	"github.com/dotbitHQ/das-lib/http_api"
-	"github.com/gin-gonic/gin"
 	"github.com/nervosnetwork/ckb-sdk-go/address"
 	"github.com/nervosnetwork/ckb-sdk-go/types"
-	"github.com/scorpiotzh/toolib"
-	"net/http"
 	"strings"
 )
 
 type ReqReverseRecordV2 struct {
 	core.ChainTypeAddress
+	WithProof bool   `json:"with_proof"`
+	AsOfBlock uint64 `json:"as_of_block"` // the reverse record at the end of this block, 0 for now
 }
 
 type RespReverseRecordV2 struct {
-	Account      string `json:"account"`
-	AccountAlias string `json:"account_alias"`
-	DisplayName  string `json:"display_name"`
-}
-
-func (h *HttpHandle) JsonRpcReverseRecordV2(p json.RawMessage, apiResp *http_api.ApiResp) {
-	var req []ReqReverseRecordV2
-	err := json.Unmarshal(p, &req)
-	if err != nil {
-		log.Error("json.Unmarshal err:", err.Error())
-		apiResp.ApiRespErr(http_api.ApiCodeParamsInvalid, "params invalid")
-		return
-	}
-	if len(req) != 1 {
-		log.Error("len(req) is :", len(req))
-		apiResp.ApiRespErr(http_api.ApiCodeParamsInvalid, "params invalid")
-		return
-	}
-
-	if err = h.doReverseRecordV2(h.Ctx, &req[0], apiResp); err != nil {
-		log.Error("doReverseRecordV2 err:", err.Error())
-	}
-}
-
-func (h *HttpHandle) ReverseRecordV2(ctx *gin.Context) {
-	var (
-		funcName = "ReverseRecordV2"
-		req      ReqReverseRecordV2
-		apiResp  http_api.ApiResp
-		err      error
-	)
-
-	if err := ctx.ShouldBindJSON(&req); err != nil {
-		log.Error("ShouldBindJSON err: ", err.Error(), funcName, ctx.Request.Context())
-		apiResp.ApiRespErr(http_api.ApiCodeParamsInvalid, "params invalid")
-		ctx.JSON(http.StatusOK, apiResp)
-		return
-	}
-	log.Info("ApiReq:", funcName, toolib.JsonString(req), ctx.Request.Context())
-
-	if err = h.doReverseRecordV2(ctx.Request.Context(), &req, &apiResp); err != nil {
-		log.Error("doReverseRecordV2 err:", err.Error(), funcName, ctx.Request.Context())
-	}
-
-	ctx.JSON(http.StatusOK, apiResp)
+	Account      string     `json:"account"`
+	AccountAlias string     `json:"account_alias"`
+	DisplayName  string     `json:"display_name"`
+	Proof        *CellProof `json:"proof,omitempty"` // of the reverse record, with with_proof
 }
 
 func (h *HttpHandle) doReverseRecordV2(ctx context.Context, req *ReqReverseRecordV2, apiResp *http_api.ApiResp) error {
@@ -74,6 +31,9 @@ func (h *HttpHandle) doReverseRecordV2(ctx context.Context, req *ReqReverseRecor
 	var addressHex string
 	var btcAddr string
 
+	if ok, err := h.checkAsOfBlock(req.AsOfBlock, apiResp); !ok {
+		return err
+	}
 	addrHex, err := req.FormatChainTypeAddress(h.DasCore.NetType(), false)
 	if err != nil {
 		log.Error(ctx, "FormatChainTypeAddress err:", req.KeyInfo.Key)
@@ -115,7 +75,7 @@ func (h *HttpHandle) doReverseRecordV2(ctx context.Context, req *ReqReverseRecor
 	log.Info(ctx, "doReverseRecordV2:", chainType, addressHex, req.KeyInfo.Key, btcAddr)
 
 	// reverse
-	reverse, err := h.DbDao.FindLatestReverseRecord(chainType, addressHex, btcAddr)
+	reverse, err := h.findLatestReverseRecordAsOf(chainType, addressHex, btcAddr, req.AsOfBlock)
 	if err != nil {
 		apiResp.ApiRespErr(http_api.ApiCodeDbError, "find reverse record err")
 		return fmt.Errorf("FindLatestReverseRecord err: %s", err.Error())
@@ -130,7 +90,7 @@ func (h *HttpHandle) doReverseRecordV2(ctx context.Context, req *ReqReverseRecor
 	// check account
 	var owner, manager string
 	accountId := common.Bytes2Hex(common.GetAccountIdByAccount(reverse.Account))
-	accountInfo, err := h.DbDao.FindAccountInfoByAccountId(accountId)
+	accountInfo, err := h.findAccountInfoAsOf(accountId, req.AsOfBlock)
 	if err != nil {
 		apiResp.ApiRespErr(http_api.ApiCodeDbError, "find reverse record account err")
 		return fmt.Errorf("FindAccountInfoByAccountId err: %s", err.Error())
@@ -142,7 +102,7 @@ func (h *HttpHandle) doReverseRecordV2(ctx context.Context, req *ReqReverseRecor
 		return fmt.Errorf("account on lock")
 	} else if accountInfo.Status == tables.AccountStatusOnUpgrade {
 		// did cell
-		didAnyLock, err := h.getAnyLockAddressHex(accountId)
+		didAnyLock, err := h.getAnyLockAddressHex(accountId, req.AsOfBlock)
 		if err != nil {
 			log.Warn(ctx, "getAnyLockAddressHex err: %s", err)
 		} else {
@@ -158,7 +118,7 @@ func (h *HttpHandle) doReverseRecordV2(ctx context.Context, req *ReqReverseRecor
 	if strings.EqualFold(addressHex, owner) || strings.EqualFold(addressHex, manager) {
 		resp.Account = accountInfo.Account
 	} else {
-		record, err := h.DbDao.FindRecordByAccountIdAddressValue(accountInfo.AccountId, req.KeyInfo.Key)
+		record, err := h.findRecordByAccountIdAddressValueAsOf(accountInfo.AccountId, req.KeyInfo.Key, req.AsOfBlock)
 		if err != nil {
 			apiResp.ApiRespErr(http_api.ApiCodeDbError, "find reverse record account record err")
 			return fmt.Errorf("FindRecordByAccountIdAddressValue err: %s", err.Error())
@@ -169,15 +129,21 @@ func (h *HttpHandle) doReverseRecordV2(ctx context.Context, req *ReqReverseRecor
 	if resp.Account != "" {
 		resp.AccountAlias = FormatDotToSharp(resp.Account)
 		resp.DisplayName = FormatDisplayName(resp.Account)
+		if req.WithProof {
+			if resp.Proof, err = h.getReverseProof(ctx, &reverse); err != nil {
+				apiResp.ApiRespErr(http_api.ApiCodeError500, "get proof err")
+				return fmt.Errorf("getReverseProof err: %s", err.Error())
+			}
+		}
 	}
 
 	apiResp.ApiRespOK(resp)
 	return nil
 }
 
-func (h *HttpHandle) getAnyLockAddressHex(accountId string) (*core.DasAddressHex, error) {
+func (h *HttpHandle) getAnyLockAddressHex(accountId string, asOfBlock uint64) (*core.DasAddressHex, error) {
 	// did cell
-	didCell, err := h.DbDao.GetDidCellByAccountId(accountId)
+	didCell, err := h.getDidCellAsOf(accountId, asOfBlock)
 	if err != nil {
 		return nil, fmt.Errorf("GetDidCellInfoByAccountId err: %s", err.Error())
 	} else if didCell.Id == 0 {
@@ -1,16 +1,13 @@
 package handle
 
 import (
+	"context"
 	"das-account-indexer/tables"
-	"encoding/json"
 	"fmt"
 	"github.com/dotbitHQ/das-lib/common"
 	"github.com/dotbitHQ/das-lib/core"
 	"github.com/dotbitHQ/das-lib/http_api"
-	"github.com/gin-gonic/gin"
 	"github.com/nervosnetwork/ckb-sdk-go/types"
-	"github.com/scorpiotzh/toolib"
-	"net/http"
 	"strings"
 )
 
@@ -42,56 +39,7 @@ type AccountData struct {
 	DisplayName         string               `json:"display_name"`
 }
 
-func (h *HttpHandle) JsonRpcSearchAccount(p json.RawMessage, apiResp *http_api.ApiResp) {
-	var req []ReqSearchAccount
-	err := json.Unmarshal(p, &req)
-	if err != nil {
-		log.Warn("json.Unmarshal err:", err.Error())
-		var reqOld []string
-		if err = json.Unmarshal(p, &reqOld); err != nil {
-			log.Error("json.Unmarshal old req err:", err.Error())
-			apiResp.ApiRespErr(http_api.ApiCodeParamsInvalid, "params invalid")
-			return
-		} else if len(reqOld) == 1 {
-			req[0] = ReqSearchAccount{Account: reqOld[0]}
-		}
-	}
-	if len(req) != 1 {
-		log.Error("len(req) is:", len(req))
-		apiResp.ApiRespErr(http_api.ApiCodeParamsInvalid, "params invalid")
-		return
-	}
-
-	if err = h.doSearchAccount(&req[0], apiResp); err != nil {
-		log.Error("doSearchAccount err:", err.Error())
-	}
-}
-
-func (h *HttpHandle) SearchAccount(ctx *gin.Context) {
-	var (
-		funcName = "SearchAccount"
-		req      ReqSearchAccount
-		apiResp  http_api.ApiResp
-		err      error
-		clientIp = GetClientIp(ctx)
-	)
-
-	if err := ctx.ShouldBindJSON(&req); err != nil {
-		log.Error("ShouldBindJSON err: ", err.Error(), funcName)
-		apiResp.ApiRespErr(http_api.ApiCodeParamsInvalid, "params invalid")
-		ctx.JSON(http.StatusOK, apiResp)
-		return
-	}
-	log.Info("ApiReq:", ctx.Request.Host, funcName, clientIp, toolib.JsonString(req))
-
-	if err = h.doSearchAccount(&req, &apiResp); err != nil {
-		log.Error("doSearchAccount err:", err.Error(), funcName)
-	}
-
-	ctx.JSON(http.StatusOK, apiResp)
-}
-
-func (h *HttpHandle) doSearchAccount(req *ReqSearchAccount, apiResp *http_api.ApiResp) error {
+func (h *HttpHandle) doSearchAccount(ctx context.Context, req *ReqSearchAccount, apiResp *http_api.ApiResp) error {
 	var resp RespSearchAccount
 
 	req.Account = strings.TrimSpace(req.Account)
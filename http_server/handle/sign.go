@@ -0,0 +1,96 @@
+package handle
+
+import (
+	"context"
+	"das-account-indexer/sign"
+	"encoding/json"
+	"fmt"
+	"github.com/dotbitHQ/das-lib/http_api"
+	"sync"
+	"time"
+)
+
+// responses are signed with the block the indexer has reached, read again once it is older than this
+const signBlockTtl = time.Second * 3
+
+// SignedApiResp is the api envelope, with the signature of the response when signing is on
+type SignedApiResp struct {
+	http_api.ApiResp
+	Signature *sign.Signature `json:"signature,omitempty"`
+}
+
+type signBlock struct {
+	lock   sync.Mutex
+	number uint64
+	hash   string
+	readAt time.Time
+}
+
+func (h *HttpHandle) getSignBlock() (uint64, string, error) {
+	h.signBlock.lock.Lock()
+	defer h.signBlock.lock.Unlock()
+	if time.Since(h.signBlock.readAt) < signBlockTtl {
+		return h.signBlock.number, h.signBlock.hash, nil
+	}
+	block, err := h.DbDao.FindCurrentBlockInfo()
+	if err != nil {
+		return 0, "", fmt.Errorf("FindCurrentBlockInfo err: %s", err.Error())
+	}
+	h.signBlock.number, h.signBlock.hash, h.signBlock.readAt = block.BlockNumber, block.BlockHash, time.Now()
+	return block.BlockNumber, block.BlockHash, nil
+}
+
+// signApiResp signs the response to method called with params, nil when signing is off or fails
+func (h *HttpHandle) signApiResp(method string, params json.RawMessage, apiResp *http_api.ApiResp) *sign.Signature {
+	if h.Signer == nil {
+		return nil
+	}
+	data, err := json.Marshal(apiResp.Data)
+	if err != nil {
+		log.Error("json.Marshal err:", err.Error(), method)
+		return nil
+	}
+	blockNumber, blockHash, err := h.getSignBlock()
+	if err != nil {
+		log.Error("getSignBlock err:", err.Error(), method)
+		return nil
+	}
+	sig, err := h.Signer.Sign(method, params, apiResp.ErrNo, apiResp.ErrMsg, data, blockNumber, blockHash)
+	if err != nil {
+		log.Error("Sign err:", err.Error(), method)
+		return nil
+	}
+	return sig
+}
+
+func (h *HttpHandle) signedApiResp(method string, params json.RawMessage, apiResp *http_api.ApiResp) SignedApiResp {
+	return SignedApiResp{ApiResp: *apiResp, Signature: h.signApiResp(method, params, apiResp)}
+}
+
+// jsonRpcParam is the request object of a json-rpc call, nil when params is not an array of one
+func jsonRpcParam(p json.RawMessage) json.RawMessage {
+	var params []json.RawMessage
+	if err := json.Unmarshal(p, &params); err != nil || len(params) != 1 {
+		return nil
+	}
+	return params[0]
+}
+
+type ReqSigningKey struct {
+}
+
+type RespSigningKey struct {
+	Enable    bool   `json:"enable"`
+	Alg       string `json:"alg"`
+	PublicKey string `json:"public_key"`
+	KeyId     string `json:"key_id"`
+}
+
+func (h *HttpHandle) doSigningKey(ctx context.Context, req *ReqSigningKey, apiResp *http_api.ApiResp) error {
+	var resp RespSigningKey
+	if h.Signer != nil {
+		resp = RespSigningKey{Enable: true, Alg: h.Signer.Alg(), PublicKey: h.Signer.PublicKey(), KeyId: h.Signer.KeyId()}
+	}
+	apiResp.ApiRespOK(resp)
+	return nil
+}
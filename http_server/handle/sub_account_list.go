@@ -3,14 +3,10 @@ package handle
 import (
 	"context"
 	"das-account-indexer/tables"
-	"encoding/json"
 	"fmt"
 	"github.com/dotbitHQ/das-lib/common"
 	"github.com/dotbitHQ/das-lib/core"
 	"github.com/dotbitHQ/das-lib/http_api"
-	"github.com/gin-gonic/gin"
-	"github.com/scorpiotzh/toolib"
-	"net/http"
 )
 
 type ReqSubAccountList struct {
@@ -24,6 +20,7 @@ type RespSubAccountList struct {
 	EnableSubAccount tables.EnableSubAccount `json:"enable_sub_account"`
 	SubAccountTotal  int64                   `json:"sub_account_total"`
 	SubAccountList   []SubAccountInfo        `json:"sub_account_list"`
+	NextCursor       string                  `json:"next_cursor"`
 }
 
 type SubAccountInfo struct {
@@ -40,48 +37,6 @@ type SubAccountInfo struct {
 	DisplayName        string                   `json:"display_name"`
 }
 
-func (h *HttpHandle) JsonRpcSubAccountList(p json.RawMessage, apiResp *http_api.ApiResp) {
-	var req []ReqSubAccountList
-	err := json.Unmarshal(p, &req)
-	if err != nil {
-		log.Error("json.Unmarshal err:", err.Error())
-		apiResp.ApiRespErr(http_api.ApiCodeParamsInvalid, "params invalid")
-		return
-	}
-	if len(req) != 1 {
-		log.Error("len(req) is :", len(req))
-		apiResp.ApiRespErr(http_api.ApiCodeParamsInvalid, "params invalid")
-		return
-	}
-
-	if err = h.doSubAccountList(h.Ctx, &req[0], apiResp); err != nil {
-		log.Error("doSubAccountList err:", err.Error())
-	}
-}
-
-func (h *HttpHandle) SubAccountList(ctx *gin.Context) {
-	var (
-		funcName = "SubAccountList"
-		req      ReqSubAccountList
-		apiResp  http_api.ApiResp
-		err      error
-	)
-
-	if err := ctx.ShouldBindJSON(&req); err != nil {
-		log.Error("ShouldBindJSON err: ", err.Error(), funcName, ctx.Request.Context())
-		apiResp.ApiRespErr(http_api.ApiCodeParamsInvalid, "params invalid")
-		ctx.JSON(http.StatusOK, apiResp)
-		return
-	}
-	log.Info("ApiReq:", funcName, toolib.JsonString(req))
-
-	if err = h.doSubAccountList(ctx.Request.Context(), &req, &apiResp); err != nil {
-		log.Error("doSubAccountList err:", err.Error(), funcName, ctx.Request.Context())
-	}
-
-	ctx.JSON(http.StatusOK, apiResp)
-}
-
 func (h *HttpHandle) doSubAccountList(ctx context.Context, req *ReqSubAccountList, apiResp *http_api.ApiResp) error {
 	var resp RespSubAccountList
 	resp.SubAccountList = make([]SubAccountInfo, 0)
@@ -103,7 +58,12 @@ func (h *HttpHandle) doSubAccountList(ctx context.Context, req *ReqSubAccountLis
 	resp.EnableSubAccount = accountInfo.EnableSubAccount
 
 	if accountInfo.EnableSubAccount == tables.AccountEnableStatusOn {
-		list, err := h.DbDao.GetSubAccountListByParentAccountId(accountId, req.GetLimit(), req.GetOffset())
+		cursor, err := req.GetCursor()
+		if err != nil {
+			apiResp.ApiRespErr(http_api.ApiCodeParamsInvalid, "cursor invalid")
+			return nil
+		}
+		list, err := h.DbDao.GetSubAccountListByParentAccountId(accountId, cursor, req.GetLimit(), req.GetOffset())
 		if err != nil {
 			apiResp.ApiRespErr(http_api.ApiCodeDbError, "find sub-account list err")
 			return fmt.Errorf("GetSubAccountListByParentAccountId err: %s", err.Error())
@@ -147,6 +107,9 @@ func (h *HttpHandle) doSubAccountList(ctx context.Context, req *ReqSubAccountLis
 				DisplayName:        FormatDisplayName(v.Account),
 			})
 		}
+		if l := len(list); l > 0 {
+			resp.NextCursor = req.NextCursor(l, list[l-1].Account, list[l-1].Id)
+		}
 	}
 	count, err := h.DbDao.GetSubAccountListCountByParentAccountId(accountId)
 	if err != nil {
@@ -0,0 +1,234 @@
+package handle
+
+import (
+	"context"
+	"das-account-indexer/tables"
+	"fmt"
+	"github.com/dotbitHQ/das-lib/common"
+	"github.com/dotbitHQ/das-lib/http_api"
+	"math"
+	"strings"
+	"time"
+)
+
+// sub-account stats and tree of a parent, read from the aggregates the parser keeps with each sub-account change
+
+const (
+	secondsPerDay       = 86400
+	subAccountMintDays  = 30 // default days of mint history
+	subAccountTopOwners = 20
+	subAccountTreeDepth = 2 // default levels under the account
+	subAccountTreeNodes = 1000
+)
+
+var subAccountExpiringDays = []uint64{30, 60, 90}
+
+type ReqSubAccountStats struct {
+	Account string `json:"account" binding:"required"`
+	Days    int    `json:"days"` // days of mint history, up to 365
+}
+
+type RespSubAccountStats struct {
+	Account         string               `json:"account"`
+	AccountId       string               `json:"account_id"`
+	SubAccountTotal int64                `json:"sub_account_total"`
+	OwnerTotal      int64                `json:"owner_total"`
+	Expired         int64                `json:"expired"`  // expired before today, UTC
+	Expiring        []SubAccountExpiring `json:"expiring"` // expiring from today on, by whole days
+	TopOwners       []SubAccountOwner    `json:"top_owners"`
+	Mints           []SubAccountMint     `json:"mints"` // a day for each of the last days, today last
+}
+
+type SubAccountExpiring struct {
+	Days  uint64 `json:"days"`
+	Count int64  `json:"count"`
+}
+
+type SubAccountOwner struct {
+	ChainType common.ChainType `json:"chain_type"`
+	Address   string           `json:"address"`
+	Count     int64            `json:"count"`
+}
+
+type SubAccountMint struct {
+	Date  string `json:"date"`
+	Count int64  `json:"count"`
+}
+
+type ReqSubAccountTree struct {
+	Account string `json:"account" binding:"required"`
+	Depth   int    `json:"depth"` // levels under the account, up to 5
+}
+
+type RespSubAccountTree struct {
+	Tree      SubAccountNode `json:"tree"`
+	NodeTotal int            `json:"node_total"`
+	Truncated bool           `json:"truncated"` // more than 1000 nodes, the rest are left out
+}
+
+type SubAccountNode struct {
+	Account         string            `json:"account"`
+	AccountId       string            `json:"account_id"`
+	ExpiredAt       uint64            `json:"expired_at"`
+	SubAccountTotal int64             `json:"sub_account_total"`
+	Children        []*SubAccountNode `json:"children"`
+}
+
+func (h *HttpHandle) subAccountStatsParent(ctx context.Context, account string, apiResp *http_api.ApiResp) (*tables.TableAccountInfo, error) {
+	account = FormatSharpToDot(strings.TrimSpace(account))
+	if err := checkAccount(account, apiResp); err != nil {
+		log.Error(ctx, "checkAccount err: ", err.Error())
+		return nil, nil
+	}
+	accountId := common.Bytes2Hex(common.GetAccountIdByAccount(account))
+	accountInfo, err := h.DbDao.FindAccountInfoByAccountId(accountId)
+	if err != nil {
+		apiResp.ApiRespErr(http_api.ApiCodeDbError, "find account info err")
+		return nil, fmt.Errorf("FindAccountInfoByAccountId err: %s", err.Error())
+	} else if accountInfo.Id == 0 {
+		apiResp.ApiRespErr(http_api.ApiCodeAccountNotExist, "account not exist")
+		return nil, nil
+	}
+	return &accountInfo, nil
+}
+
+func (h *HttpHandle) doSubAccountStats(ctx context.Context, req *ReqSubAccountStats, apiResp *http_api.ApiResp) error {
+	var resp RespSubAccountStats
+	resp.Expiring = make([]SubAccountExpiring, 0, len(subAccountExpiringDays))
+	resp.TopOwners = make([]SubAccountOwner, 0)
+
+	days := req.Days
+	if days == 0 {
+		days = subAccountMintDays
+	} else if days < 0 || days > 365 {
+		apiResp.ApiRespErr(http_api.ApiCodeParamsInvalid, "days should be 1 to 365")
+		return nil
+	}
+	accountInfo, err := h.subAccountStatsParent(ctx, req.Account, apiResp)
+	if accountInfo == nil {
+		return err
+	}
+	resp.Account = accountInfo.Account
+	resp.AccountId = accountInfo.AccountId
+
+	totals, err := h.DbDao.GetSubAccountStatsTotals([]string{accountInfo.AccountId})
+	if err != nil {
+		apiResp.ApiRespErr(http_api.ApiCodeDbError, "find sub-account stats err")
+		return fmt.Errorf("GetSubAccountStatsTotals err: %s", err.Error())
+	}
+	resp.SubAccountTotal = totals[accountInfo.AccountId].Total
+	resp.OwnerTotal = totals[accountInfo.AccountId].Owners
+
+	// the last count is of all not expired yet
+	today := uint64(time.Now().Unix()) / secondsPerDay
+	var untilDays []uint64
+	for _, v := range subAccountExpiringDays {
+		untilDays = append(untilDays, today+v)
+	}
+	expiring, err := h.DbDao.GetSubAccountStatsExpiring(accountInfo.AccountId, today, append(untilDays, math.MaxInt64))
+	if err != nil {
+		apiResp.ApiRespErr(http_api.ApiCodeDbError, "find sub-account stats err")
+		return fmt.Errorf("GetSubAccountStatsExpiring err: %s", err.Error())
+	}
+	for i, v := range subAccountExpiringDays {
+		resp.Expiring = append(resp.Expiring, SubAccountExpiring{Days: v, Count: expiring[i]})
+	}
+	resp.Expired = resp.SubAccountTotal - expiring[len(untilDays)]
+
+	owners, err := h.DbDao.GetSubAccountStatsTopOwners(accountInfo.AccountId, subAccountTopOwners)
+	if err != nil {
+		apiResp.ApiRespErr(http_api.ApiCodeDbError, "find sub-account stats err")
+		return fmt.Errorf("GetSubAccountStatsTopOwners err: %s", err.Error())
+	}
+	for _, v := range owners {
+		resp.TopOwners = append(resp.TopOwners, SubAccountOwner{ChainType: v.OwnerChainType, Address: v.Owner, Count: v.Count})
+	}
+
+	fromDay := today - uint64(days) + 1
+	mints, err := h.DbDao.GetSubAccountStatsDaily(accountInfo.AccountId, tables.SubAccountStatsKindMint, fromDay, today)
+	if err != nil {
+		apiResp.ApiRespErr(http_api.ApiCodeDbError, "find sub-account stats err")
+		return fmt.Errorf("GetSubAccountStatsDaily err: %s", err.Error())
+	}
+	var mapMints = make(map[uint64]int64, len(mints))
+	for _, v := range mints {
+		mapMints[v.Day] = v.Count
+	}
+	resp.Mints = make([]SubAccountMint, 0, days)
+	for day := fromDay; day <= today; day++ {
+		date := time.Unix(int64(day*secondsPerDay), 0).UTC().Format("2006-01-02")
+		resp.Mints = append(resp.Mints, SubAccountMint{Date: date, Count: mapMints[day]})
+	}
+
+	apiResp.ApiRespOK(resp)
+	return nil
+}
+
+func (h *HttpHandle) doSubAccountTree(ctx context.Context, req *ReqSubAccountTree, apiResp *http_api.ApiResp) error {
+	var resp RespSubAccountTree
+
+	depth := req.Depth
+	if depth == 0 {
+		depth = subAccountTreeDepth
+	} else if depth < 0 || depth > 5 {
+		apiResp.ApiRespErr(http_api.ApiCodeParamsInvalid, "depth should be 1 to 5")
+		return nil
+	}
+	accountInfo, err := h.subAccountStatsParent(ctx, req.Account, apiResp)
+	if accountInfo == nil {
+		return err
+	}
+	resp.Tree = SubAccountNode{
+		Account:   accountInfo.Account,
+		AccountId: accountInfo.AccountId,
+		ExpiredAt: accountInfo.ExpiredAt,
+		Children:  make([]*SubAccountNode, 0),
+	}
+	resp.NodeTotal = 1
+
+	// a level at a time, only parents that have sub-accounts are looked into
+	level := []*SubAccountNode{&resp.Tree}
+	for d := 0; len(level) > 0; d++ {
+		var parentIds []string
+		var mapNodes = make(map[string]*SubAccountNode, len(level))
+		for _, v := range level {
+			parentIds = append(parentIds, v.AccountId)
+			mapNodes[v.AccountId] = v
+		}
+		totals, err := h.DbDao.GetSubAccountStatsTotals(parentIds)
+		if err != nil {
+			apiResp.ApiRespErr(http_api.ApiCodeDbError, "find sub-account stats err")
+			return fmt.Errorf("GetSubAccountStatsTotals err: %s", err.Error())
+		}
+		parentIds = parentIds[:0]
+		for k, v := range totals {
+			mapNodes[k].SubAccountTotal = v.Total
+			parentIds = append(parentIds, k)
+		}
+		if d == depth || len(parentIds) == 0 || resp.Truncated {
+			break
+		}
+
+		remain := subAccountTreeNodes - resp.NodeTotal
+		list, err := h.DbDao.FindSubAccountsByParentIds(parentIds, remain+1)
+		if err != nil {
+			apiResp.ApiRespErr(http_api.ApiCodeDbError, "find sub-account list err")
+			return fmt.Errorf("FindSubAccountsByParentIds err: %s", err.Error())
+		}
+		if len(list) > remain {
+			list = list[:remain]
+			resp.Truncated = true
+		}
+		level = make([]*SubAccountNode, 0, len(list))
+		for _, v := range list {
+			node := SubAccountNode{Account: v.Account, AccountId: v.AccountId, ExpiredAt: v.ExpiredAt, Children: make([]*SubAccountNode, 0)}
+			parent := mapNodes[v.ParentAccountId]
+			parent.Children = append(parent.Children, &node)
+			level = append(level, &node)
+		}
+		resp.NodeTotal += len(list)
+	}
+
+	apiResp.ApiRespOK(resp)
+	return nil
+}
@@ -2,13 +2,9 @@ package handle
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"github.com/dotbitHQ/das-lib/common"
 	"github.com/dotbitHQ/das-lib/http_api"
-	"github.com/gin-gonic/gin"
-	"github.com/scorpiotzh/toolib"
-	"net/http"
 )
 
 type ReqSubAccountVerify struct {
@@ -22,47 +18,6 @@ type RespSubAccountVerify struct {
 	IsSubdid bool `json:"is_subdid"`
 }
 
-func (h *HttpHandle) JsonRpcSubAccountVerify(p json.RawMessage, apiResp *http_api.ApiResp) {
-	var req []ReqSubAccountVerify
-	err := json.Unmarshal(p, &req)
-	if err != nil {
-		log.Error("json.Unmarshal err:", err.Error())
-		apiResp.ApiRespErr(http_api.ApiCodeParamsInvalid, "params invalid")
-		return
-	}
-	if len(req) != 1 {
-		log.Error("len(req) is:", len(req))
-		apiResp.ApiRespErr(http_api.ApiCodeParamsInvalid, "params invalid")
-		return
-	}
-	if err = h.doSubAccountVerify(h.Ctx, &req[0], apiResp); err != nil {
-		log.Error("doSubAccountVerify err:", err.Error())
-	}
-}
-
-func (h *HttpHandle) SubAccountVerify(ctx *gin.Context) {
-	var (
-		funcName = "SubAccountList"
-		req      ReqSubAccountVerify
-		apiResp  http_api.ApiResp
-		err      error
-	)
-
-	if err := ctx.ShouldBindJSON(&req); err != nil {
-		log.Error("ShouldBindJSON err: ", err.Error(), funcName, ctx.Request.Context())
-		apiResp.ApiRespErr(http_api.ApiCodeParamsInvalid, "params invalid")
-		ctx.JSON(http.StatusOK, apiResp)
-		return
-	}
-	log.Info("ApiReq:", funcName, toolib.JsonString(req))
-
-	if err = h.doSubAccountVerify(ctx.Request.Context(), &req, &apiResp); err != nil {
-		log.Error("doSubAccountList err:", err.Error(), funcName, ctx.Request.Context())
-	}
-
-	ctx.JSON(http.StatusOK, apiResp)
-}
-
 func (h *HttpHandle) doSubAccountVerify(ctx context.Context, req *ReqSubAccountVerify, apiResp *http_api.ApiResp) error {
 	var resp RespSubAccountVerify
 	accountId := common.Bytes2Hex(common.GetAccountIdByAccount(req.Account))
@@ -1,13 +1,12 @@
 package handle
 
 import (
-	"encoding/json"
+	"context"
 	"github.com/dotbitHQ/das-lib/http_api"
-	"github.com/gin-gonic/gin"
-	"github.com/scorpiotzh/toolib"
-	"net/http"
 )
 
+const apiVersion = "v2.0.1"
+
 type ReqVersion struct {
 }
 
@@ -15,51 +14,9 @@ type RespVersion struct {
 	Version string `json:"version"`
 }
 
-func (h *HttpHandle) JsonRpcVersion(p json.RawMessage, apiResp *http_api.ApiResp) {
-	var req []ReqVersion
-	err := json.Unmarshal(p, &req)
-	if err != nil {
-		log.Error("json.Unmarshal err:", err.Error())
-		apiResp.ApiRespErr(http_api.ApiCodeParamsInvalid, "params invalid")
-		return
-	}
-	if len(req) != 1 {
-		log.Error("len(req) is :", len(req))
-		apiResp.ApiRespErr(http_api.ApiCodeParamsInvalid, "params invalid")
-		return
-	}
-
-	if err = h.doVersion(&req[0], apiResp); err != nil {
-		log.Error("doVersion err:", err.Error())
-	}
-}
-
-func (h *HttpHandle) Version(ctx *gin.Context) {
-	var (
-		funcName = "Version"
-		req      ReqVersion
-		apiResp  http_api.ApiResp
-		err      error
-	)
-
-	if err := ctx.ShouldBindJSON(&req); err != nil {
-		log.Error("ShouldBindJSON err: ", err.Error(), funcName)
-		apiResp.ApiRespErr(http_api.ApiCodeParamsInvalid, "params invalid")
-		ctx.JSON(http.StatusOK, apiResp)
-		return
-	}
-	log.Info("ApiReq:", funcName, toolib.JsonString(req))
-
-	if err = h.doVersion(&req, &apiResp); err != nil {
-		log.Error("doVersion err:", err.Error(), funcName)
-	}
-
-	ctx.JSON(http.StatusOK, apiResp)
-}
-
-func (h *HttpHandle) doVersion(req *ReqVersion, apiResp *http_api.ApiResp) error {
+func (h *HttpHandle) doVersion(ctx context.Context, req *ReqVersion, apiResp *http_api.ApiResp) error {
 	var resp RespVersion
-	resp.Version = "v2.0.1"
+	resp.Version = apiVersion
 	apiResp.ApiRespOK(resp)
 	return nil
 }
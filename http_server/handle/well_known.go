@@ -0,0 +1,201 @@
+package handle
+
+import (
+	"das-account-indexer/config"
+	"das-account-indexer/tables"
+	"fmt"
+	"github.com/btcsuite/btcd/btcutil/bech32"
+	"github.com/dotbitHQ/das-lib/common"
+	"github.com/gin-gonic/gin"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// NIP-05 and WebFinger for the names of a host, the host is mapped to an account, "_" is the account itself
+// and any other name is one of its sub-accounts, e.g. bob@alice.bit.cc is bob.alice.bit
+
+const wellKnownRootName = "_"
+
+// wellKnownName is one or more dot-separated labels, so "", ".", "a..b" and leading or trailing dots are rejected
+var wellKnownName = regexp.MustCompile(`^[a-z0-9\-_]+(\.[a-z0-9\-_]+)*$`)
+
+type RespNostr struct {
+	Names  map[string]string   `json:"names"`
+	Relays map[string][]string `json:"relays,omitempty"`
+}
+
+type RespWebFinger struct {
+	Subject string          `json:"subject"`
+	Aliases []string        `json:"aliases,omitempty"`
+	Links   []WebFingerLink `json:"links"`
+}
+
+type WebFingerLink struct {
+	Rel  string `json:"rel"`
+	Type string `json:"type,omitempty"`
+	Href string `json:"href"`
+}
+
+func (h *HttpHandle) NostrJson(ctx *gin.Context) {
+	var (
+		funcName = "NostrJson"
+		clientIp = GetClientIp(ctx)
+		name     = strings.ToLower(ctx.Query("name"))
+		resp     = RespNostr{Names: make(map[string]string)}
+	)
+	log.Info("ApiReq:", funcName, clientIp, ctx.Request.Host, name, ctx.Request.Context())
+	// required by NIP-05 for web clients
+	ctx.Header("Access-Control-Allow-Origin", "*")
+
+	account, ok := wellKnownAccount(ctx.Request.Host, name)
+	if !ok {
+		ctx.JSON(http.StatusNotFound, resp)
+		return
+	}
+	list, err := h.wellKnownRecords(account)
+	if err != nil {
+		log.Error("wellKnownRecords err:", err.Error(), funcName, clientIp, ctx.Request.Context())
+		ctx.JSON(http.StatusInternalServerError, resp)
+		return
+	}
+	pubKey := nostrPubKey(list)
+	if pubKey == "" {
+		ctx.JSON(http.StatusNotFound, resp)
+		return
+	}
+	resp.Names[name] = pubKey
+	if relays := recordValues(list, "profile", "nostr_relays"); len(relays) > 0 {
+		resp.Relays = map[string][]string{pubKey: relays}
+	}
+	ctx.JSON(http.StatusOK, resp)
+}
+
+func (h *HttpHandle) WebFinger(ctx *gin.Context) {
+	var (
+		funcName = "WebFinger"
+		clientIp = GetClientIp(ctx)
+		resource = ctx.Query("resource")
+	)
+	log.Info("ApiReq:", funcName, clientIp, resource, ctx.Request.Context())
+	ctx.Header("Access-Control-Allow-Origin", "*")
+
+	user, host, ok := strings.Cut(strings.TrimPrefix(resource, "acct:"), "@")
+	if !strings.HasPrefix(resource, "acct:") || !ok {
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "resource should be acct:<name>@<host>"})
+		return
+	}
+	account, ok := wellKnownAccount(host, strings.ToLower(user))
+	if !ok {
+		ctx.JSON(http.StatusNotFound, gin.H{"message": "resource not found"})
+		return
+	}
+	list, err := h.wellKnownRecords(account)
+	if err != nil {
+		log.Error("wellKnownRecords err:", err.Error(), funcName, clientIp, ctx.Request.Context())
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "failed to get records"})
+		return
+	}
+
+	resp := RespWebFinger{Subject: resource, Links: make([]WebFingerLink, 0)}
+	for _, v := range recordValues(list, "profile", "mastodon") {
+		if profile, actor := mastodonUrls(v); profile != "" {
+			resp.Aliases = append(resp.Aliases, profile, actor)
+			resp.Links = append(resp.Links,
+				WebFingerLink{Rel: "http://webfinger.net/rel/profile-page", Type: "text/html", Href: profile},
+				WebFingerLink{Rel: "self", Type: "application/activity+json", Href: actor},
+			)
+			break
+		}
+	}
+	for _, v := range recordValues(list, "profile", "website") {
+		resp.Links = append(resp.Links, WebFingerLink{Rel: "http://webfinger.net/rel/profile-page", Type: "text/html", Href: v})
+	}
+	if len(resp.Links) == 0 {
+		ctx.JSON(http.StatusNotFound, gin.H{"message": "no profile record"})
+		return
+	}
+	ctx.Render(http.StatusOK, didJson{contentType: "application/jrd+json", data: resp})
+}
+
+// wellKnownAccount maps name@host to an account, hosts are set in well_known.hosts
+// or are <label>.<well_known.domain> as with the gateway
+func wellKnownAccount(host, name string) (string, bool) {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	account, ok := config.Cfg.WellKnown.Hosts[host]
+	if !ok {
+		if account, ok = hostAccount(host, config.Cfg.WellKnown.Domain); !ok {
+			return "", false
+		}
+	}
+	if name == wellKnownRootName || name == strings.TrimSuffix(account, common.DasAccountSuffix) {
+		return account, true
+	}
+	if !wellKnownName.MatchString(name) {
+		return "", false
+	}
+	return fmt.Sprintf("%s.%s", name, account), true
+}
+
+// wellKnownRecords returns the records of an account that resolves, nil when it does not
+func (h *HttpHandle) wellKnownRecords(account string) ([]tables.TableRecordsInfo, error) {
+	accountId := common.Bytes2Hex(common.GetAccountIdByAccount(account))
+	accountInfo, err := h.DbDao.FindAccountInfoByAccountId(accountId)
+	if err != nil {
+		return nil, fmt.Errorf("FindAccountInfoByAccountId err: %s", err.Error())
+	} else if accountInfo.Id == 0 || accountInfo.Status == tables.AccountStatusOnLock || accountInfo.ExpiredAt <= uint64(time.Now().Unix()) {
+		return nil, nil
+	}
+	list, err := h.DbDao.FindAccountRecordsByAccountId(accountId)
+	if err != nil {
+		return nil, fmt.Errorf("FindAccountRecordsByAccountId err: %s", err.Error())
+	}
+	return list, nil
+}
+
+func recordValues(list []tables.TableRecordsInfo, recordType, key string) []string {
+	var res []string
+	for _, v := range list {
+		if value := strings.TrimSpace(v.Value); v.Type == recordType && v.Key == key && value != "" {
+			res = append(res, value)
+		}
+	}
+	return res
+}
+
+// nostrPubKey returns the hex public key of the nostr record, which may be kept as an npub
+func nostrPubKey(list []tables.TableRecordsInfo) string {
+	for _, recordType := range []string{"profile", "address"} {
+		for _, v := range recordValues(list, recordType, "nostr") {
+			v = strings.ToLower(v)
+			if hrp, data, err := bech32.DecodeToBase256(v); err == nil && hrp == "npub" && len(data) == 32 {
+				return common.Bytes2Hex(data)[2:]
+			}
+			if v = strings.TrimPrefix(v, "0x"); len(v) == 64 && len(common.Hex2Bytes(v)) == 32 {
+				return v
+			}
+		}
+	}
+	return ""
+}
+
+// mastodonUrls returns the profile page and the actor of a handle such as @alice@mastodon.social
+// or https://mastodon.social/@alice
+func mastodonUrls(handle string) (string, string) {
+	var user, instance string
+	if u, err := url.Parse(handle); err == nil && u.Scheme == "https" && strings.HasPrefix(u.Path, "/@") {
+		user, instance = strings.TrimPrefix(strings.Trim(u.Path, "/"), "@"), u.Host
+	} else {
+		user, instance, _ = strings.Cut(strings.TrimPrefix(handle, "@"), "@")
+	}
+	if user == "" || instance == "" || strings.ContainsAny(user+instance, "/@ ") {
+		return "", ""
+	}
+	return fmt.Sprintf("https://%s/@%s", instance, user), fmt.Sprintf("https://%s/users/%s", instance, user)
+}
@@ -15,16 +15,22 @@ var (
 type HttpServer struct {
 	Ctx            context.Context
 	AddressIndexer string
+	AddressGateway string
 	H              *handle.HttpHandle
 
 	engineIndexer *gin.Engine
 	srvIndexer    *http.Server
+	engineGateway *gin.Engine
+	srvGateway    *http.Server
 }
 
 func (h *HttpServer) Run() {
 	if h.AddressIndexer != "" {
 		h.engineIndexer = gin.New()
 	}
+	if h.AddressGateway != "" {
+		h.engineGateway = gin.New()
+	}
 
 	h.initRouter()
 
@@ -39,7 +45,17 @@ func (h *HttpServer) Run() {
 			}
 		}()
 	}
-
+	if h.AddressGateway != "" {
+		h.srvGateway = &http.Server{
+			Addr:    h.AddressGateway,
+			Handler: h.engineGateway,
+		}
+		go func() {
+			if err := h.srvGateway.ListenAndServe(); err != nil {
+				log.Error("http_server gateway run err:", err)
+			}
+		}()
+	}
 }
 
 func (h *HttpServer) Shutdown() {
@@ -49,4 +65,9 @@ func (h *HttpServer) Shutdown() {
 			log.Error("http server Shutdown err:", err.Error())
 		}
 	}
+	if h.srvGateway != nil {
+		if err := h.srvGateway.Shutdown(h.Ctx); err != nil {
+			log.Error("http server gateway Shutdown err:", err.Error())
+		}
+	}
 }
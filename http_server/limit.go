@@ -0,0 +1,301 @@
+package http_server
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"das-account-indexer/cache"
+	"das-account-indexer/config"
+	"das-account-indexer/dao"
+	"das-account-indexer/http_server/handle"
+	"das-account-indexer/prometheus"
+	"encoding/hex"
+	"fmt"
+	"github.com/dotbitHQ/das-lib/http_api"
+	"github.com/gin-gonic/gin"
+	"io/ioutil"
+	"math"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// api keys and rate limits of the indexer api. A call takes tokens from the bucket of its key,
+// or of its ip when it comes without one, as many as the items of a batch call, and counts the same
+// against the daily quota. Quotas are counted in redis while it is up so that instances share them,
+// buckets are kept by each instance.
+
+const (
+	headerApiKey         = "X-Api-Key"
+	apiKeyAnonymous      = "anonymous"
+	apiKeyReloadInterval = time.Minute
+)
+
+func HashApiKey(key string) string {
+	h := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(h[:])
+}
+
+type apiKeyLimit struct {
+	name  string
+	limit config.ApiLimit
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+	full   time.Time // refilled by then, the bucket is no different from a new one
+}
+
+func limitBurst(limit config.ApiLimit) float64 {
+	if limit.Burst > 0 {
+		return float64(limit.Burst)
+	}
+	return math.Max(math.Ceil(limit.Rate), 1)
+}
+
+// take refills the bucket and takes n tokens, a call weighing more than the burst
+// goes through on a full bucket and leaves it in debt
+func (b *tokenBucket) take(limit config.ApiLimit, n int, now time.Time) (bool, time.Duration) {
+	burst := limitBurst(limit)
+	b.tokens = math.Min(burst, b.tokens+now.Sub(b.last).Seconds()*limit.Rate)
+	b.last = now
+	if need := math.Min(float64(n), burst); b.tokens < need {
+		return false, time.Duration((need - b.tokens) / limit.Rate * float64(time.Second))
+	}
+	b.tokens -= float64(n)
+	b.full = now.Add(time.Duration((burst - b.tokens) / limit.Rate * float64(time.Second)))
+	return true, 0
+}
+
+type apiLimiter struct {
+	dbDao *dao.DbDao
+	cache *cache.TieredCache
+
+	lock    sync.Mutex
+	keys    map[string]apiKeyLimit // by hex sha256 of the key
+	proxies []*net.IPNet
+	buckets map[string]*tokenBucket
+	counts  map[string]int64 // quota counts while redis is not there
+}
+
+func newApiLimiter(dbDao *dao.DbDao, tieredCache *cache.TieredCache) *apiLimiter {
+	return &apiLimiter{
+		dbDao:   dbDao,
+		cache:   tieredCache,
+		keys:    make(map[string]apiKeyLimit),
+		buckets: make(map[string]*tokenBucket),
+		counts:  make(map[string]int64),
+	}
+}
+
+// run loads the keys of the config and of t_api_key now and again every minute,
+// so that both the config file and the apikey command take effect without a restart
+func (l *apiLimiter) run(ctx context.Context) {
+	l.reload()
+	go func() {
+		ticker := time.NewTicker(apiKeyReloadInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				l.evict(time.Now())
+				l.reload()
+			}
+		}
+	}()
+}
+
+func (l *apiLimiter) reload() {
+	if !config.Cfg.ApiKey.Enable {
+		return
+	}
+	var proxies []*net.IPNet
+	for _, v := range config.Cfg.ApiKey.Proxies {
+		if !strings.Contains(v, "/") {
+			if ip := net.ParseIP(v); ip != nil && ip.To4() != nil {
+				v += "/32"
+			} else {
+				v += "/128"
+			}
+		}
+		if _, ipNet, err := net.ParseCIDR(v); err != nil {
+			log.Error("api_key.proxies invalid:", v)
+		} else {
+			proxies = append(proxies, ipNet)
+		}
+	}
+	l.lock.Lock()
+	l.proxies = proxies
+	l.lock.Unlock()
+
+	keys := make(map[string]apiKeyLimit)
+	for _, v := range config.Cfg.ApiKey.Keys {
+		if v.Key != "" {
+			keys[HashApiKey(v.Key)] = apiKeyLimit{name: v.Name, limit: v.ApiLimit}
+		}
+	}
+	list, err := l.dbDao.FindEnabledApiKeys()
+	if err != nil {
+		log.Error("FindEnabledApiKeys err:", err.Error())
+		return
+	}
+	for _, v := range list {
+		keys[v.KeyHash] = apiKeyLimit{name: v.Name, limit: config.ApiLimit{Rate: v.Rate, Burst: v.Burst, DailyQuota: v.DailyQuota}}
+	}
+	for k, v := range keys {
+		if v.name == "" {
+			v.name = k[:8]
+			keys[k] = v
+		}
+	}
+
+	l.lock.Lock()
+	l.keys = keys
+	l.lock.Unlock()
+}
+
+// evict drops the buckets that have refilled and the quota counts of past days,
+// so memory follows the subjects calling now rather than all that ever called
+func (l *apiLimiter) evict(now time.Time) {
+	today := now.UTC().Format("20060102") + ":"
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	for k, v := range l.buckets {
+		if !now.Before(v.full) {
+			delete(l.buckets, k)
+		}
+	}
+	for k := range l.counts {
+		if !strings.HasPrefix(k, today) {
+			delete(l.counts, k)
+		}
+	}
+}
+
+func (l *apiLimiter) middleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		cfg := config.Cfg.ApiKey
+		if !cfg.Enable {
+			return
+		}
+		subject, name, limit := "ip:"+l.clientIp(ctx), apiKeyAnonymous, cfg.Ip
+		if key := ctx.GetHeader(headerApiKey); key != "" {
+			l.lock.Lock()
+			v, ok := l.keys[HashApiKey(key)]
+			l.lock.Unlock()
+			if !ok {
+				l.reject(ctx, http.StatusUnauthorized, http_api.ApiCodePermissionDenied, "api key invalid", "unknown", "invalid", 1)
+				return
+			}
+			subject, name, limit = "key:"+v.name, v.name, v.limit
+		} else if cfg.Required {
+			l.reject(ctx, http.StatusUnauthorized, http_api.ApiCodePermissionDenied, "api key required", apiKeyAnonymous, "missing", 1)
+			return
+		}
+		weight := requestWeight(ctx)
+		now := time.Now()
+
+		if limit.Rate > 0 {
+			l.lock.Lock()
+			b, ok := l.buckets[subject]
+			if !ok {
+				b = &tokenBucket{tokens: limitBurst(limit), last: now}
+				l.buckets[subject] = b
+			}
+			ok, wait := b.take(limit, weight, now)
+			remaining := int64(math.Max(math.Floor(b.tokens), 0))
+			l.lock.Unlock()
+			ctx.Header("X-RateLimit-Limit", fmt.Sprint(int64(limitBurst(limit))))
+			ctx.Header("X-RateLimit-Remaining", fmt.Sprint(remaining))
+			if !ok {
+				ctx.Header("Retry-After", fmt.Sprint(int64(math.Ceil(wait.Seconds()))))
+				l.reject(ctx, http.StatusTooManyRequests, http_api.ApiCodeOperationFrequent, "rate limit exceeded", name, "rate_limited", weight)
+				return
+			}
+		}
+
+		if limit.DailyQuota > 0 {
+			used := l.count(subject, weight, now)
+			reset := now.UTC().Truncate(time.Hour * 24).Add(time.Hour * 24)
+			ctx.Header("X-Quota-Limit", fmt.Sprint(limit.DailyQuota))
+			remaining := limit.DailyQuota - used
+			if remaining < 0 {
+				remaining = 0
+			}
+			ctx.Header("X-Quota-Remaining", fmt.Sprint(remaining))
+			ctx.Header("X-Quota-Reset", fmt.Sprint(reset.Unix()))
+			if used > limit.DailyQuota {
+				ctx.Header("Retry-After", fmt.Sprint(int64(math.Ceil(reset.Sub(now).Seconds()))))
+				l.reject(ctx, http.StatusTooManyRequests, http_api.ApiCodeOperationFrequent, "daily quota exceeded", name, "quota_exceeded", weight)
+				return
+			}
+		}
+		prometheus.Tools.Metrics.ApiKey().WithLabelValues(name, "ok").Add(float64(weight))
+	}
+}
+
+func (l *apiLimiter) reject(ctx *gin.Context, status int, errNo http_api.ApiCode, errMsg, name, result string, weight int) {
+	prometheus.Tools.Metrics.ApiKey().WithLabelValues(name, result).Add(float64(weight))
+	ctx.AbortWithStatusJSON(status, http_api.ApiRespErr(errNo, errMsg))
+}
+
+// count adds weight to the calls of subject today and gives the total
+func (l *apiLimiter) count(subject string, weight int, now time.Time) int64 {
+	key := now.UTC().Format("20060102") + ":" + subject
+	if l.cache != nil {
+		if n, ok := l.cache.IncrBy("quota:"+key, int64(weight), time.Hour*25); ok {
+			return n
+		}
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.counts[key] += int64(weight)
+	return l.counts[key]
+}
+
+// requestWeight is the number of items of a batch call, read from the REST body or the json-rpc calls
+func requestWeight(ctx *gin.Context) int {
+	if ctx.Request.Method != http.MethodPost {
+		return 1
+	}
+	path := ctx.FullPath()
+	m, ok := handle.GetMethodByPath(path)
+	if !ok && path != "/" {
+		return 1
+	}
+	body, _ := ctx.GetRawData()
+	ctx.Request.Body = ioutil.NopCloser(bytes.NewBuffer(body))
+	if path == "/graphql" {
+		return handle.GraphqlWeight(body)
+	} else if !ok {
+		return handle.JsonRpcWeight(body)
+	}
+	return m.Weight(body)
+}
+
+// clientIp is the address set by a trusted proxy in front of the indexer, the peer otherwise,
+// anyone else could send X-Real-IP to get a new bucket on each call
+func (l *apiLimiter) clientIp(ctx *gin.Context) string {
+	host, _, err := net.SplitHostPort(ctx.Request.RemoteAddr)
+	if err != nil {
+		host = ctx.Request.RemoteAddr
+	}
+	realIp := ctx.GetHeader("X-Real-IP")
+	if realIp == "" || net.ParseIP(realIp) == nil {
+		return host
+	}
+	peer := net.ParseIP(host)
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	for _, v := range l.proxies {
+		if peer != nil && v.Contains(peer) {
+			return realIp
+		}
+	}
+	return host
+}
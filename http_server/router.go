@@ -2,51 +2,56 @@ package http_server
 
 import (
 	"das-account-indexer/http_server/code"
+	"das-account-indexer/http_server/handle"
 	"encoding/json"
 	"github.com/dotbitHQ/das-lib/http_api"
 	"github.com/gin-gonic/gin"
-	"github.com/scorpiotzh/toolib"
 	"net/http"
 	"time"
 )
 
 func (h *HttpServer) initRouter() {
 	shortDataTime, lockTime, shortExpireTime := time.Minute, time.Second*30, time.Second*5
-	cacheHandle := toolib.MiddlewareCacheByRedis(h.H.Red, false, shortDataTime, lockTime, shortExpireTime, respHandle)
+	cacheHandle := middlewareCache(h.H.Cache, shortDataTime, lockTime, shortExpireTime, respHandle)
 
 	if h.AddressIndexer != "" {
 		// indexer api
-		h.engineIndexer.Use(toolib.MiddlewareCors())
+		h.engineIndexer.Use(middlewareCors())
 		h.engineIndexer.Use(http_api.ReqIdMiddleware())
+		limiter := newApiLimiter(h.H.DbDao, h.H.Cache)
+		limiter.run(h.Ctx)
+		h.engineIndexer.Use(limiter.middleware())
 		h.engineIndexer.POST("", cacheHandle, h.H.QueryIndexer)
-		v1Indexer := h.engineIndexer.Group("v1")
-		{
-			//v1Indexer.POST("/search/account", code.DoMonitorLog(code.MethodSearchAccount), cacheHandle, h.H.SearchAccount)
-			//v1Indexer.POST("/address/account", code.DoMonitorLog(code.MethodAddressAccount), cacheHandle, h.H.AddressAccount)
-			v1Indexer.POST("/did/number", code.DoMonitorLog(code.MethodDidNumber), cacheHandle, h.H.DidNumber)
-			v1Indexer.POST("/server/info", code.DoMonitorLog(code.MethodServerInfo), cacheHandle, h.H.ServerInfo)
-			v1Indexer.POST("/account/info", code.DoMonitorLog(code.MethodAccountInfo), cacheHandle, h.H.AccountInfo)
-			v1Indexer.POST("/account/list", code.DoMonitorLog(code.MethodAccountList), cacheHandle, h.H.AccountList)
-			v1Indexer.POST("/account/records", code.DoMonitorLog(code.MethodAccountRecords), cacheHandle, h.H.AccountRecords)
-			v1Indexer.POST("/account/reverse/address", code.DoMonitorLog(code.MethodAccountReverseAddress), cacheHandle, h.H.AccountReverseAddress)
-			v1Indexer.POST("/reverse/record", code.DoMonitorLog(code.MethodReverseRecord), cacheHandle, h.H.ReverseRecordV2)
-			//v1Indexer.POST("/v2/reverse/record", code.DoMonitorLog(code.MethodReverseRecord), cacheHandle, h.H.ReverseRecordV2)
-			v1Indexer.POST("/sub/account/list", code.DoMonitorLog(code.MethodSubAccountList), cacheHandle, h.H.SubAccountList)
-			v1Indexer.POST("/sub/account/verify", code.DoMonitorLog(code.MethodSubAccountVerify), cacheHandle, h.H.SubAccountVerify)
-
-			v1Indexer.POST("/batch/account/records", code.DoMonitorLog(code.MethodBatchAccountRecords), cacheHandle, h.H.BatchAccountRecords)
-			v1Indexer.POST("/batch/reverse/record", code.DoMonitorLog(code.MethodBatchReverseRecord), cacheHandle, h.H.BatchReverseRecordV2)
-			//v1Indexer.POST("/v2/batch/reverse/record", code.DoMonitorLog(code.MethodBatchReverseRecord), cacheHandle, h.H.BatchReverseRecordV2)
-			v1Indexer.POST("/batch/register/info", code.DoMonitorLog(code.MethodBatchRegisterInfo), cacheHandle, h.H.BatchRegisterInfo)
-			v1Indexer.POST("/did/list", code.DoMonitorLog("did_list"), cacheHandle, h.H.DidList)  //
-			v1Indexer.POST("/record/list", code.DoMonitorLog("records_list"), h.H.AccountRecords) //
-		}
-		v2Indexer := h.engineIndexer.Group("v2")
-		{
-			v2Indexer.POST("/account/records", code.DoMonitorLog(code.MethodAccountRecordsV2), cacheHandle, h.H.AccountRecordsV2)
+		h.engineIndexer.POST("/graphql", code.DoMonitorLog("graphql"), cacheHandle, h.H.Graphql)
+		h.engineIndexer.GET("/openapi.json", h.H.OpenApi)
+		h.engineIndexer.GET("/docs", h.H.OpenApiViewer)
+		h.engineIndexer.GET("/docs/:file", h.H.OpenApiViewer)
+		h.engineIndexer.GET("/ccip/:sender/:data", code.DoMonitorLog("ccip"), h.H.CcipRead)
+		h.engineIndexer.POST("/ccip", code.DoMonitorLog("ccip"), h.H.CcipRead)
+		h.engineIndexer.GET("/1.0/identifiers/:did", code.DoMonitorStatus("did_resolve"), h.H.DidResolve)
+		h.engineIndexer.GET("/v1/account/card.svg", code.DoMonitorStatus("account_card"), h.H.AccountCard)
+		h.engineIndexer.GET("/v1/account/card.png", code.DoMonitorStatus("account_card"), h.H.AccountCard)
+		h.engineIndexer.GET("/v1/export", code.DoMonitorStatus("export"), h.H.Export)
+		h.engineIndexer.GET("/v1/changefeed", code.DoMonitorStatus("changefeed"), h.H.Changefeed)
+		h.engineIndexer.GET("/.well-known/nostr.json", code.DoMonitorStatus("nostr"), h.H.NostrJson)
+		h.engineIndexer.GET("/.well-known/webfinger", code.DoMonitorStatus("webfinger"), h.H.WebFinger)
+		for _, m := range handle.Methods {
+			handlers := []gin.HandlerFunc{code.DoMonitorLog(m.Label)}
+			if m.Cache != handle.CacheNone {
+				handlers = append(handlers, cacheHandle)
+			}
+			handlers = append(handlers, h.H.Rest(m))
+			for _, path := range m.Paths {
+				h.engineIndexer.POST(path, handlers...)
+			}
 		}
 	}
-
+	if h.AddressGateway != "" {
+		// web3 gateway, paths other than the well-known ones are served from the dweb records
+		h.engineGateway.GET("/.well-known/nostr.json", code.DoMonitorStatus("nostr"), h.H.NostrJson)
+		h.engineGateway.GET("/.well-known/webfinger", code.DoMonitorStatus("webfinger"), h.H.WebFinger)
+		h.engineGateway.NoRoute(code.DoMonitorStatus("gateway"), h.H.Gateway)
+	}
 }
 
 func respHandle(c *gin.Context, res string, err error) {
@@ -54,8 +59,7 @@ func respHandle(c *gin.Context, res string, err error) {
 		log.Error("respHandle err:", err.Error())
 		c.AbortWithStatusJSON(http.StatusOK, http_api.ApiRespErr(http_api.ApiCodeError500, err.Error()))
 	} else if res != "" {
-		var respMap map[string]interface{}
-		_ = json.Unmarshal([]byte(res), &respMap)
-		c.AbortWithStatusJSON(http.StatusOK, respMap)
+		// kept raw, batch responses are arrays
+		c.AbortWithStatusJSON(http.StatusOK, json.RawMessage(res))
 	}
 }
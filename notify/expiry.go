@@ -0,0 +1,249 @@
+package notify
+
+import (
+	"context"
+	"das-account-indexer/dao"
+	"das-account-indexer/tables"
+	"fmt"
+	"github.com/dotbitHQ/das-lib/common"
+	"github.com/scorpiotzh/mylog"
+	"sort"
+	"sync"
+	"time"
+)
+
+// expiry notices of accounts and DID cells, one at each stage before expiry and one once in the grace period.
+// A notice is claimed in t_expiry_notice before it is sent, so it goes out at most once per channel:
+// a failed send is tried again on later scans, one left pending by a stop is not.
+// A renewal changes expired_at and starts the stages over.
+
+var log = mylog.NewLogger("notify", mylog.LevelDebug)
+
+const (
+	ExpiryStageGrace    = "grace"
+	ExpirySourceAccount = tables.AccountSourceAccount
+	ExpirySourceDid     = tables.AccountSourceDid
+	expiryGraceDays     = 90
+	expiryMaxAttempts   = 3
+	expiryBatchSize     = 500
+	expirySecondsInDay  = 86400
+)
+
+type ExpiryNotice struct {
+	Stage          string           `json:"stage"`  // t-30, t-7, t-1 or grace
+	Source         string           `json:"source"` // account or did_cell
+	AccountId      string           `json:"account_id"`
+	Account        string           `json:"account"`
+	ExpiredAt      uint64           `json:"expired_at"`
+	OwnerChainType common.ChainType `json:"owner_chain_type"` // 99 with the lock args as owner for a DID cell
+	Owner          string           `json:"owner"`
+	Manager        string           `json:"manager"`
+	Email          string           `json:"-"`
+}
+
+// Text gives the subject and body of the notice for a person to read
+func (n *ExpiryNotice) Text() (string, string) {
+	expiredAt := time.Unix(int64(n.ExpiredAt), 0).UTC()
+	if n.Stage == ExpiryStageGrace {
+		recycleAt := expiredAt.AddDate(0, 0, expiryGraceDays)
+		return fmt.Sprintf("%s has expired", n.Account),
+			fmt.Sprintf("%s expired at %s UTC and is in its grace period until %s UTC, renew it before then or it will be recycled.",
+				n.Account, expiredAt.Format("2006-01-02 15:04"), recycleAt.Format("2006-01-02 15:04"))
+	}
+	days := (int64(n.ExpiredAt) - time.Now().Unix() + expirySecondsInDay - 1) / expirySecondsInDay
+	return fmt.Sprintf("%s expires in %d days", n.Account, days),
+		fmt.Sprintf("%s expires at %s UTC, renew it to keep it.", n.Account, expiredAt.Format("2006-01-02 15:04"))
+}
+
+type ExpiryScheduler struct {
+	Ctx      context.Context
+	Wg       *sync.WaitGroup
+	DbDao    *dao.DbDao
+	Channels []ExpiryChannel
+	Stages   []uint64 // days before expiry
+	Interval time.Duration
+}
+
+func (s *ExpiryScheduler) Run() {
+	var stages []uint64
+	for _, v := range s.Stages {
+		if v > 0 {
+			stages = append(stages, v)
+		}
+	}
+	sort.Slice(stages, func(i, j int) bool { return stages[i] > stages[j] })
+	s.Stages = stages
+
+	s.Wg.Add(1)
+	go func() {
+		defer s.Wg.Done()
+		ticker := time.NewTicker(s.Interval)
+		defer ticker.Stop()
+		for {
+			s.scan()
+			select {
+			case <-s.Ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// scan sends each stage to accounts expiring between it and the next stage,
+// one that was missed is skipped once the next stage is reached
+func (s *ExpiryScheduler) scan() {
+	now := uint64(time.Now().Unix())
+	for i, days := range s.Stages {
+		var next uint64
+		if i+1 < len(s.Stages) {
+			next = s.Stages[i+1]
+		}
+		if next == days {
+			continue
+		}
+		s.scanStage(fmt.Sprintf("t-%d", days), now+next*expirySecondsInDay, now+days*expirySecondsInDay)
+	}
+	s.scanStage(ExpiryStageGrace, now-expiryGraceDays*expirySecondsInDay, now)
+}
+
+func (s *ExpiryScheduler) scanStage(stage string, from, to uint64) {
+	var lastId uint64
+	for s.Ctx.Err() == nil {
+		list, err := s.DbDao.FindAccountsExpiringBetween(from, to, lastId, expiryBatchSize)
+		if err != nil {
+			log.Error("FindAccountsExpiringBetween err:", err.Error(), stage, lastId)
+			return
+		} else if len(list) == 0 {
+			break
+		}
+		lastId = list[len(list)-1].Id
+		notices := make([]ExpiryNotice, 0, len(list))
+		for _, v := range list {
+			notices = append(notices, ExpiryNotice{
+				Stage:          stage,
+				Source:         ExpirySourceAccount,
+				AccountId:      v.AccountId,
+				Account:        v.Account,
+				ExpiredAt:      v.ExpiredAt,
+				OwnerChainType: v.OwnerChainType,
+				Owner:          v.Owner,
+				Manager:        v.Manager,
+			})
+		}
+		s.send(stage, notices)
+		if len(list) < expiryBatchSize {
+			break
+		}
+	}
+
+	lastId = 0
+	for s.Ctx.Err() == nil {
+		list, err := s.DbDao.FindDidCellsExpiringBetween(from, to, lastId, expiryBatchSize)
+		if err != nil {
+			log.Error("FindDidCellsExpiringBetween err:", err.Error(), stage, lastId)
+			return
+		} else if len(list) == 0 {
+			return
+		}
+		lastId = list[len(list)-1].Id
+		notices := make([]ExpiryNotice, 0, len(list))
+		for _, v := range list {
+			notices = append(notices, ExpiryNotice{
+				Stage:          stage,
+				Source:         ExpirySourceDid,
+				AccountId:      v.AccountId,
+				Account:        v.Account,
+				ExpiredAt:      v.ExpiredAt,
+				OwnerChainType: common.ChainTypeAnyLock,
+				Owner:          v.Args,
+			})
+		}
+		s.send(stage, notices)
+		if len(list) < expiryBatchSize {
+			return
+		}
+	}
+}
+
+type expiryNoticeKey struct {
+	accountId string
+	expiredAt uint64
+	channel   string
+}
+
+func (s *ExpiryScheduler) send(stage string, notices []ExpiryNotice) {
+	var accountIds []string
+	for _, v := range notices {
+		accountIds = append(accountIds, v.AccountId)
+	}
+	sent, err := s.DbDao.FindExpiryNotices(accountIds, stage)
+	if err != nil {
+		log.Error("FindExpiryNotices err:", err.Error(), stage)
+		return
+	}
+	var mapSent = make(map[expiryNoticeKey]tables.TableExpiryNotice, len(sent))
+	for _, v := range sent {
+		mapSent[expiryNoticeKey{v.AccountId, v.ExpiredAt, v.Channel}] = v
+	}
+	emails, err := s.DbDao.FindRecordsByAccountIdsKey(accountIds, "profile", "email")
+	if err != nil {
+		log.Error("FindRecordsByAccountIdsKey err:", err.Error(), stage)
+		return
+	}
+	var mapEmail = make(map[string]string, len(emails))
+	for _, v := range emails {
+		if _, ok := mapEmail[v.AccountId]; !ok {
+			mapEmail[v.AccountId] = v.Value
+		}
+	}
+
+	for i := range notices {
+		n := &notices[i]
+		n.Email = mapEmail[n.AccountId]
+		for _, ch := range s.Channels {
+			if s.Ctx.Err() != nil {
+				return
+			}
+			recipient := ch.Recipient(n)
+			if recipient == "" {
+				continue
+			}
+			row, ok := mapSent[expiryNoticeKey{n.AccountId, n.ExpiredAt, ch.Name()}]
+			if ok && row.Status != tables.ExpiryNoticeStatusFailed {
+				continue
+			}
+			claimed := false
+			if ok {
+				claimed, err = s.DbDao.RetryExpiryNotice(row.Id, expiryMaxAttempts)
+			} else {
+				row = tables.TableExpiryNotice{
+					AccountId: n.AccountId,
+					ExpiredAt: n.ExpiredAt,
+					Stage:     stage,
+					Channel:   ch.Name(),
+					Account:   n.Account,
+					Recipient: recipient,
+				}
+				claimed, err = s.DbDao.ClaimExpiryNotice(&row)
+			}
+			if err != nil {
+				log.Error("claim expiry notice err:", err.Error(), n.Account, stage, ch.Name())
+				continue
+			} else if !claimed {
+				continue
+			}
+
+			status, errMsg := tables.ExpiryNoticeStatusSent, ""
+			ctx, cancel := context.WithTimeout(s.Ctx, time.Second*30)
+			if err = ch.Send(ctx, recipient, n); err != nil {
+				log.Warn("Send err:", err.Error(), n.Account, stage, ch.Name())
+				status, errMsg = tables.ExpiryNoticeStatusFailed, err.Error()
+			}
+			cancel()
+			if err = s.DbDao.UpdateExpiryNoticeStatus(row.Id, status, errMsg); err != nil {
+				log.Error("UpdateExpiryNoticeStatus err:", err.Error(), row.Id, status)
+			}
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/mail"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// ExpiryChannel delivers expiry notices, Recipient gives where a notice goes, empty when the channel has nowhere to send it
+type ExpiryChannel interface {
+	Name() string
+	Recipient(n *ExpiryNotice) string
+	Send(ctx context.Context, recipient string, n *ExpiryNotice) error
+}
+
+type WebhookChannel struct {
+	Url    string
+	Secret string
+	Client *http.Client
+}
+
+func (c *WebhookChannel) Name() string {
+	return "webhook"
+}
+
+func (c *WebhookChannel) Recipient(n *ExpiryNotice) string {
+	return c.Url
+}
+
+// Send posts the notice as json, with the hex hmac-sha256 of the body in X-Signature when there is a secret
+func (c *WebhookChannel) Send(ctx context.Context, recipient string, n *ExpiryNotice) error {
+	body, err := json.Marshal(n)
+	if err != nil {
+		return fmt.Errorf("json.Marshal err: %s", err.Error())
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, recipient, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("http.NewRequest err: %s", err.Error())
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.Secret != "" {
+		mac := hmac.New(sha256.New, []byte(c.Secret))
+		mac.Write(body)
+		req.Header.Set("X-Signature", hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	client := c.Client
+	if client == nil {
+		client = &http.Client{Timeout: time.Second * 10}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("client.Do err: %s", err.Error())
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook status: %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// SmtpChannel mails the email profile record of the account through a relay that needs no auth
+type SmtpChannel struct {
+	Addr string
+	From string
+}
+
+func (c *SmtpChannel) Name() string {
+	return "smtp"
+}
+
+func (c *SmtpChannel) Recipient(n *ExpiryNotice) string {
+	// the record is set by the owner, anything that is not a single plain address is left out
+	addr, err := mail.ParseAddress(n.Email)
+	if err != nil || strings.ContainsAny(addr.Address, "\r\n") {
+		return ""
+	}
+	return addr.Address
+}
+
+func (c *SmtpChannel) Send(ctx context.Context, recipient string, n *ExpiryNotice) error {
+	subject, text := n.Text()
+	var msg strings.Builder
+	msg.WriteString("From: " + c.From + "\r\n")
+	msg.WriteString("To: " + recipient + "\r\n")
+	msg.WriteString("Subject: " + mime.QEncoding.Encode("utf-8", subject) + "\r\n")
+	msg.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	msg.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+	msg.WriteString(text + "\r\n")
+	if err := c.sendMail(ctx, recipient, []byte(msg.String())); err != nil {
+		return fmt.Errorf("sendMail err: %s", err.Error())
+	}
+	return nil
+}
+
+// sendMail is smtp.SendMail without auth, bounded by ctx: a relay that stops answering would hold the scheduler
+func (c *SmtpChannel) sendMail(ctx context.Context, recipient string, msg []byte) error {
+	host, _, err := net.SplitHostPort(c.Addr)
+	if err != nil {
+		return fmt.Errorf("net.SplitHostPort err: %s", err.Error())
+	}
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", c.Addr)
+	if err != nil {
+		return fmt.Errorf("DialContext err: %s", err.Error())
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = conn.Close()
+		case <-done:
+		}
+	}()
+
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		return fmt.Errorf("smtp.NewClient err: %s", err.Error())
+	}
+	defer client.Close()
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err = client.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return fmt.Errorf("StartTLS err: %s", err.Error())
+		}
+	}
+	if err = client.Mail(c.From); err != nil {
+		return fmt.Errorf("Mail err: %s", err.Error())
+	} else if err = client.Rcpt(recipient); err != nil {
+		return fmt.Errorf("Rcpt err: %s", err.Error())
+	}
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("Data err: %s", err.Error())
+	} else if _, err = w.Write(msg); err != nil {
+		return fmt.Errorf("Write err: %s", err.Error())
+	} else if err = w.Close(); err != nil {
+		return fmt.Errorf("Close err: %s", err.Error())
+	}
+	return client.Quit()
+}
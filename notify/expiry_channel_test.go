@@ -0,0 +1,94 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testSmtpServer answers one session on a local port and gives the message it got,
+// with hang it accepts and never says anything
+func testSmtpServer(t *testing.T, hang bool) (string, chan string) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = l.Close() })
+	res := make(chan string, 1)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		if hang {
+			_, _ = bufio.NewReader(conn).ReadString('\n')
+			return
+		}
+		r := bufio.NewReader(conn)
+		write := func(s string) { _, _ = conn.Write([]byte(s + "\r\n")) }
+		write("220 localhost ESMTP")
+		var data strings.Builder
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			switch cmd := strings.ToUpper(strings.TrimSpace(line)); {
+			case strings.HasPrefix(cmd, "EHLO"):
+				write("250 localhost")
+			case strings.HasPrefix(cmd, "MAIL"), strings.HasPrefix(cmd, "RCPT"):
+				write("250 ok")
+			case cmd == "DATA":
+				write("354 go ahead")
+				for {
+					line, err = r.ReadString('\n')
+					if err != nil {
+						return
+					} else if line == ".\r\n" {
+						break
+					}
+					data.WriteString(line)
+				}
+				write("250 queued")
+			case cmd == "QUIT":
+				write("221 bye")
+				res <- data.String()
+				return
+			default:
+				write("502 not implemented")
+			}
+		}
+	}()
+	return l.Addr().String(), res
+}
+
+func TestSmtpChannel(t *testing.T) {
+	n := &ExpiryNotice{Stage: "t-7", Account: "phone.bit", ExpiredAt: uint64(time.Now().Unix()) + 7*expirySecondsInDay}
+
+	addr, res := testSmtpServer(t, false)
+	c := &SmtpChannel{Addr: addr, From: "notice@did.id"}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	if err := c.Send(ctx, "owner@example.com", n); err != nil {
+		t.Fatal(err)
+	}
+	if msg := <-res; !strings.Contains(msg, "To: owner@example.com\r\n") || !strings.Contains(msg, "phone.bit expires at") {
+		t.Fatal("message:", msg)
+	}
+
+	// a relay that never answers gives up with the context
+	addr, _ = testSmtpServer(t, true)
+	c = &SmtpChannel{Addr: addr, From: "notice@did.id"}
+	ctx, cancel = context.WithTimeout(context.Background(), time.Millisecond*300)
+	defer cancel()
+	start := time.Now()
+	if err := c.Send(ctx, "owner@example.com", n); err == nil {
+		t.Fatal("sent to a hung relay")
+	} else if time.Since(start) > time.Second*3 {
+		t.Fatal("hung for", time.Since(start))
+	}
+}
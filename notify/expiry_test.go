@@ -0,0 +1,109 @@
+package notify
+
+import (
+	"context"
+	"das-account-indexer/config"
+	"das-account-indexer/dao"
+	"das-account-indexer/tables"
+	"github.com/dotbitHQ/das-lib/common"
+	"gorm.io/gorm"
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+type testChannel struct {
+	sent []ExpiryNotice
+}
+
+func (c *testChannel) Name() string {
+	return "test"
+}
+
+func (c *testChannel) Recipient(n *ExpiryNotice) string {
+	return n.Owner
+}
+
+func (c *testChannel) Send(ctx context.Context, recipient string, n *ExpiryNotice) error {
+	c.sent = append(c.sent, *n)
+	return nil
+}
+
+// an account upgraded to a DID cell is noticed once, to the owner of the DID cell.
+// It runs against the mysql of DAS_TEST_MYSQL_ADDR, a database of its own
+func TestExpiryUpgradedAccount(t *testing.T) {
+	addr := os.Getenv("DAS_TEST_MYSQL_ADDR")
+	if addr == "" {
+		t.Skip("DAS_TEST_MYSQL_ADDR not set")
+	}
+	dbDao, err := dao.NewGormDB(config.DbMysql{
+		Addr:        addr,
+		User:        os.Getenv("DAS_TEST_MYSQL_USER"),
+		Password:    os.Getenv("DAS_TEST_MYSQL_PASSWORD"),
+		DbName:      os.Getenv("DAS_TEST_MYSQL_DB_NAME"),
+		MaxOpenConn: 5,
+		MaxIdleConn: 2,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const (
+		accountId = "0x00000000000000000000000000000000test4545"
+		account   = "test4545.bit"
+		owner     = "0xc9f53b1d85356b60453f867610888d89a0b667ad"
+		args      = "0x0000000000000000000000000000000000004545"
+	)
+	expiredAt := uint64(time.Now().Unix()) + 3*expirySecondsInDay
+	cleanUp := func() {
+		_ = dbDao.Transaction(func(tx *gorm.DB) error {
+			tx.Where("account_id=?", accountId).Delete(&tables.TableAccountInfo{})
+			tx.Where("account_id=?", accountId).Delete(&tables.TableDidCellInfo{})
+			tx.Where("account_id=?", accountId).Delete(&tables.TableExpiryNotice{})
+			return nil
+		})
+	}
+	cleanUp()
+	defer cleanUp()
+
+	acc := tables.TableAccountInfo{
+		Outpoint:       "0x0000000000000000000000000000000000000000000000000000000000004545-0",
+		AccountId:      accountId,
+		Account:        account,
+		OwnerChainType: common.ChainTypeEth,
+		Owner:          owner,
+		Status:         tables.AccountStatusNormal,
+		ExpiredAt:      expiredAt,
+	}
+	if err = dbDao.UpdateAccountInfo(&acc, nil); err != nil {
+		t.Fatal(err)
+	}
+	acc.Status = tables.AccountStatusOnUpgrade
+	if err = dbDao.TransferAccountToDid(acc, tables.TableDidCellInfo{
+		Outpoint:  "0x0000000000000000000000000000000000000000000000000000000000004546-0",
+		AccountId: accountId,
+		Account:   account,
+		Args:      args,
+		ExpiredAt: expiredAt,
+	}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	ch := &testChannel{}
+	s := ExpiryScheduler{Ctx: context.Background(), Wg: &sync.WaitGroup{}, DbDao: dbDao, Channels: []ExpiryChannel{ch}}
+	now := uint64(time.Now().Unix())
+	s.scanStage("t-7", now, now+7*expirySecondsInDay)
+
+	var sent []ExpiryNotice
+	for _, v := range ch.sent {
+		if v.AccountId == accountId {
+			sent = append(sent, v)
+		}
+	}
+	if len(sent) != 1 {
+		t.Fatal("notices:", sent)
+	} else if sent[0].Source != ExpirySourceDid || sent[0].Owner != args {
+		t.Fatal("notice:", sent[0].Source, sent[0].Owner)
+	}
+}
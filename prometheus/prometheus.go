@@ -27,12 +27,13 @@ type Metric struct {
 	l         sync.Mutex
 	api       *prometheus.SummaryVec
 	errNotify *prometheus.CounterVec
+	apiKey    *prometheus.CounterVec
 }
 
 func (m *Metric) Api() *prometheus.SummaryVec {
+	m.l.Lock()
+	defer m.l.Unlock()
 	if m.api == nil {
-		m.l.Lock()
-		defer m.l.Unlock()
 		m.api = prometheus.NewSummaryVec(prometheus.SummaryOpts{
 			Name: "api",
 		}, []string{"method", "http_status", "err_no", "err_msg"})
@@ -42,9 +43,9 @@ func (m *Metric) Api() *prometheus.SummaryVec {
 }
 
 func (m *Metric) ErrNotify() *prometheus.CounterVec {
+	m.l.Lock()
+	defer m.l.Unlock()
 	if m.errNotify == nil {
-		m.l.Lock()
-		defer m.l.Unlock()
 		m.errNotify = prometheus.NewCounterVec(prometheus.CounterOpts{
 			Name: "notify",
 		}, []string{"title", "text"})
@@ -53,6 +54,19 @@ func (m *Metric) ErrNotify() *prometheus.CounterVec {
 	return m.errNotify
 }
 
+// ApiKey counts the weight of the calls of each api key, anonymous for those limited by ip
+func (m *Metric) ApiKey() *prometheus.CounterVec {
+	m.l.Lock()
+	defer m.l.Unlock()
+	if m.apiKey == nil {
+		m.apiKey = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "api_key",
+		}, []string{"key", "result"})
+		PromRegister.MustRegister(m.apiKey)
+	}
+	return m.apiKey
+}
+
 func Init() {
 	Tools = &Prometheus{}
 }
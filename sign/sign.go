@@ -0,0 +1,174 @@
+package sign
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/ethereum/go-ethereum/crypto"
+	"strings"
+	"time"
+)
+
+// Signed responses of the indexer api. What is signed is the sha256 of the canonical json of
+//
+//	{"block_hash":"","block_number":0,"data":null,"err_msg":"","err_no":0,"method":"","params":null,"signed_at":0}
+//
+// with data, err_no and err_msg from the response, method the json-rpc method name, params the request object
+// (the REST body or the one json-rpc param, null when there is none) and the rest from the signature.
+// Canonical json has its object keys sorted, no whitespace and no html escaping, numbers are kept as written.
+
+const (
+	AlgEd25519   = "ed25519"
+	AlgSecp256k1 = "secp256k1" // 65 bytes r|s|v, as go-ethereum signs
+)
+
+type Signature struct {
+	Alg         string `json:"alg"`
+	KeyId       string `json:"key_id"`
+	BlockNumber uint64 `json:"block_number"` // the block the indexer had reached when signing
+	BlockHash   string `json:"block_hash"`
+	SignedAt    int64  `json:"signed_at"`
+	Sig         string `json:"sig"`
+}
+
+type payload struct {
+	BlockHash   string          `json:"block_hash"`
+	BlockNumber uint64          `json:"block_number"`
+	Data        json.RawMessage `json:"data"`
+	ErrMsg      string          `json:"err_msg"`
+	ErrNo       int             `json:"err_no"`
+	Method      string          `json:"method"`
+	Params      json.RawMessage `json:"params"`
+	SignedAt    int64           `json:"signed_at"`
+}
+
+// Canonical re-encodes a json value in canonical form, empty input is null
+func Canonical(raw []byte) ([]byte, error) {
+	if len(bytes.TrimSpace(raw)) == 0 {
+		return []byte("null"), nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// Digest is the sha256 that sig signs, its Sig is left out
+func Digest(method string, params json.RawMessage, errNo int, errMsg string, data json.RawMessage, sig *Signature) ([]byte, error) {
+	var err error
+	p := payload{
+		BlockHash:   sig.BlockHash,
+		BlockNumber: sig.BlockNumber,
+		ErrMsg:      errMsg,
+		ErrNo:       errNo,
+		Method:      method,
+		SignedAt:    sig.SignedAt,
+	}
+	if p.Data, err = Canonical(data); err != nil {
+		return nil, fmt.Errorf("Canonical data err: %s", err.Error())
+	}
+	if p.Params, err = Canonical(params); err != nil {
+		return nil, fmt.Errorf("Canonical params err: %s", err.Error())
+	}
+	raw, err := json.Marshal(&p)
+	if err != nil {
+		return nil, err
+	}
+	if raw, err = Canonical(raw); err != nil {
+		return nil, err
+	}
+	digest := sha256.Sum256(raw)
+	return digest[:], nil
+}
+
+func keyId(publicKey []byte) string {
+	h := sha256.Sum256(publicKey)
+	return hex.EncodeToString(h[:8])
+}
+
+type Signer struct {
+	alg       string
+	ed        ed25519.PrivateKey
+	ecdsa     *ecdsa.PrivateKey
+	publicKey []byte
+}
+
+// NewSigner takes a hex private key, the 32 bytes seed or the 64 bytes key for ed25519
+func NewSigner(alg, privateKey string) (*Signer, error) {
+	s := Signer{alg: alg}
+	privateKey = strings.TrimPrefix(privateKey, "0x")
+	switch alg {
+	case AlgEd25519:
+		bys, err := hex.DecodeString(privateKey)
+		if err != nil {
+			return nil, fmt.Errorf("hex.DecodeString err: %s", err.Error())
+		}
+		switch len(bys) {
+		case ed25519.SeedSize:
+			s.ed = ed25519.NewKeyFromSeed(bys)
+		case ed25519.PrivateKeySize:
+			s.ed = bys
+		default:
+			return nil, fmt.Errorf("ed25519 private key length invalid: %d", len(bys))
+		}
+		s.publicKey = s.ed.Public().(ed25519.PublicKey)
+	case AlgSecp256k1:
+		key, err := crypto.HexToECDSA(privateKey)
+		if err != nil {
+			return nil, fmt.Errorf("crypto.HexToECDSA err: %s", err.Error())
+		}
+		s.ecdsa = key
+		s.publicKey = crypto.CompressPubkey(&key.PublicKey)
+	default:
+		return nil, fmt.Errorf("alg invalid: %s", alg)
+	}
+	return &s, nil
+}
+
+func (s *Signer) Alg() string {
+	return s.alg
+}
+
+// PublicKey is hex, 32 bytes for ed25519 and 33 compressed bytes for secp256k1
+func (s *Signer) PublicKey() string {
+	return hex.EncodeToString(s.publicKey)
+}
+
+func (s *Signer) KeyId() string {
+	return keyId(s.publicKey)
+}
+
+func (s *Signer) Sign(method string, params json.RawMessage, errNo int, errMsg string, data json.RawMessage, blockNumber uint64, blockHash string) (*Signature, error) {
+	sig := Signature{
+		Alg:         s.alg,
+		KeyId:       s.KeyId(),
+		BlockNumber: blockNumber,
+		BlockHash:   blockHash,
+		SignedAt:    time.Now().Unix(),
+	}
+	digest, err := Digest(method, params, errNo, errMsg, data, &sig)
+	if err != nil {
+		return nil, err
+	}
+	var bys []byte
+	if s.ed != nil {
+		bys = ed25519.Sign(s.ed, digest)
+	} else if bys, err = crypto.Sign(digest, s.ecdsa); err != nil {
+		return nil, fmt.Errorf("crypto.Sign err: %s", err.Error())
+	}
+	sig.Sig = hex.EncodeToString(bys)
+	return &sig, nil
+}
@@ -0,0 +1,150 @@
+package sign_test
+
+import (
+	"das-account-indexer/http_server/code"
+	"das-account-indexer/sign"
+	"encoding/json"
+	"github.com/dotbitHQ/das-lib/http_api"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+const (
+	testMethod = "das_accountInfo"
+	testParams = `{"account":"phone.bit","with_proof":false}`
+	testData   = `{"account":"phone.bit","expired_at":1753185972,"price":1.50,"records":[{"key":"twitter","value":"<phone>"}]}`
+)
+
+var testKeys = []struct {
+	alg        string
+	privateKey string
+}{
+	{sign.AlgEd25519, "0101010101010101010101010101010101010101010101010101010101010101"},
+	{sign.AlgSecp256k1, "4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318"},
+}
+
+type signedApiResp struct {
+	http_api.ApiResp
+	Signature *sign.Signature `json:"signature,omitempty"`
+}
+
+// responses gives the api response to testMethod in each of its envelopes: REST, json-rpc and strict json-rpc
+func responses(t *testing.T, signer *sign.Signer, apiResp http_api.ApiResp) map[string][]byte {
+	data, err := json.Marshal(apiResp.Data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, err := signer.Sign(testMethod, json.RawMessage(testParams), apiResp.ErrNo, apiResp.ErrMsg, data, 12044213, "0x3e0e3ff4b1e1bd7e9d3cdc9a8b4cf7b2a7e11a1f2d1c24a6e5a18de0d7e3b8f1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rest := signedApiResp{ApiResp: apiResp, Signature: sig}
+	res := make(map[string][]byte)
+	for name, v := range map[string]interface{}{
+		"rest":    rest,
+		"jsonrpc": map[string]interface{}{"jsonrpc": "2.0", "id": 1, "result": rest},
+		"strict":  code.NewSignedJsonRpcResponse(json.RawMessage(`1`), &apiResp, sig),
+	} {
+		if res[name], err = json.Marshal(v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return res
+}
+
+func TestSignVerify(t *testing.T) {
+	for _, k := range testKeys {
+		signer, err := sign.NewSigner(k.alg, k.privateKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, apiResp := range []http_api.ApiResp{
+			http_api.ApiRespOK(json.RawMessage(testData)),
+			http_api.ApiRespErr(http_api.ApiCodeAccountNotExist, "account not exist"),
+		} {
+			for name, resp := range responses(t, signer, apiResp) {
+				sig, err := sign.Verify(signer.PublicKey(), testMethod, json.RawMessage(testParams), resp)
+				if err != nil {
+					t.Fatal(k.alg, name, apiResp.ErrNo, err, string(resp))
+				} else if sig.KeyId != signer.KeyId() || sig.BlockNumber != 12044213 {
+					t.Fatal(k.alg, name, "signature:", sig.KeyId, sig.BlockNumber)
+				}
+				// params are canonical too, the client may send its keys in any order
+				if _, err = sign.Verify(signer.PublicKey(), testMethod, json.RawMessage(`{ "with_proof": false, "account": "phone.bit" }`), resp); err != nil {
+					t.Fatal(k.alg, name, "params key order:", err)
+				}
+				if _, err = sign.Verify(signer.PublicKey(), "das_reverseRecord", json.RawMessage(testParams), resp); err == nil {
+					t.Fatal(k.alg, name, "verified for another method")
+				}
+				if _, err = sign.Verify(signer.PublicKey(), testMethod, json.RawMessage(`{"account":"other.bit","with_proof":false}`), resp); err == nil {
+					t.Fatal(k.alg, name, "verified for other params")
+				}
+			}
+		}
+	}
+}
+
+func TestVerifyTampered(t *testing.T) {
+	for _, k := range testKeys {
+		signer, err := sign.NewSigner(k.alg, k.privateKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		other, err := sign.NewSigner(k.alg, strings.Repeat("02", 32))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for name, resp := range responses(t, signer, http_api.ApiRespOK(json.RawMessage(testData))) {
+			for _, v := range []struct {
+				desc  string
+				old   string
+				new   string
+				valid bool
+			}{
+				{"data keys reordered", `{"account":"phone.bit","expired_at":1753185972,`, `{"expired_at":1753185972,"account":"phone.bit",`, true},
+				{"whitespace added", `"price":1.50`, `"price" : 1.50`, true},
+				{"number rewritten", `"price":1.50`, `"price":1.5`, false},
+				{"number in exponent form", `1753185972`, `1.753185972e9`, false},
+				{"number changed", `1753185972`, `1753185973`, false},
+				{"string changed", `"twitter"`, `"github"`, false},
+				{"html unescaped", `\u003cphone\u003e`, `<phone>`, true},
+				{"block changed", `12044213`, `12044214`, false},
+			} {
+				tampered := strings.Replace(string(resp), v.old, v.new, 1)
+				if tampered == string(resp) {
+					t.Fatal(k.alg, name, v.desc, "not found:", v.old, string(resp))
+				}
+				_, err := sign.Verify(signer.PublicKey(), testMethod, json.RawMessage(testParams), []byte(tampered))
+				if v.valid && err != nil {
+					t.Fatal(k.alg, name, v.desc, err)
+				} else if !v.valid && err == nil {
+					t.Fatal(k.alg, name, v.desc, "verified")
+				}
+			}
+			if _, err = sign.Verify(other.PublicKey(), testMethod, json.RawMessage(testParams), resp); err == nil {
+				t.Fatal(k.alg, name, "verified with another key")
+			}
+		}
+
+		for name, resp := range responses(t, signer, http_api.ApiRespErr(http_api.ApiCodeAccountNotExist, "account not exist")) {
+			old := `"err_no":` + strconv.Itoa(http_api.ApiCodeAccountNotExist)
+			tampered := strings.Replace(string(resp), old, `"err_no":0`, 1)
+			if tampered == string(resp) {
+				t.Fatal(k.alg, name, "err_no not found:", string(resp))
+			} else if _, err = sign.Verify(signer.PublicKey(), testMethod, json.RawMessage(testParams), []byte(tampered)); err == nil {
+				t.Fatal(k.alg, name, "err_no changed, verified")
+			}
+			tampered = strings.Replace(string(resp), "account not exist", "not found", 1)
+			if tampered == string(resp) {
+				t.Fatal(k.alg, name, "err_msg not found:", string(resp))
+			} else if _, err = sign.Verify(signer.PublicKey(), testMethod, json.RawMessage(testParams), []byte(tampered)); err == nil {
+				t.Fatal(k.alg, name, "err_msg changed, verified")
+			}
+		}
+	}
+
+	if _, err := sign.Verify(testKeys[0].privateKey, testMethod, nil, []byte(`{"err_no":0,"err_msg":"","data":null}`)); err == nil {
+		t.Fatal("unsigned response verified")
+	}
+}
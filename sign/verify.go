@@ -0,0 +1,84 @@
+package sign
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/ethereum/go-ethereum/crypto"
+	"strings"
+)
+
+// VerifySignature checks sig against the hex public key published at /v1/signing/key
+func VerifySignature(publicKey string, method string, params json.RawMessage, errNo int, errMsg string, data json.RawMessage, sig *Signature) error {
+	pub, err := hex.DecodeString(strings.TrimPrefix(publicKey, "0x"))
+	if err != nil {
+		return fmt.Errorf("public key invalid: %s", err.Error())
+	}
+	if sig.KeyId != keyId(pub) {
+		return fmt.Errorf("key id mismatch: %s", sig.KeyId)
+	}
+	bys, err := hex.DecodeString(sig.Sig)
+	if err != nil {
+		return fmt.Errorf("sig invalid: %s", err.Error())
+	}
+	digest, err := Digest(method, params, errNo, errMsg, data, sig)
+	if err != nil {
+		return err
+	}
+	ok := false
+	switch sig.Alg {
+	case AlgEd25519:
+		ok = len(pub) == ed25519.PublicKeySize && ed25519.Verify(pub, digest, bys)
+	case AlgSecp256k1:
+		ok = len(bys) == 65 && crypto.VerifySignature(pub, digest, bys[:64])
+	default:
+		return fmt.Errorf("alg invalid: %s", sig.Alg)
+	}
+	if !ok {
+		return fmt.Errorf("signature invalid")
+	}
+	return nil
+}
+
+// Verify checks a response of the api against the method and request object it was asked with, and gives its signature.
+// The response is the REST body, a json-rpc response that is not strict or its result, or a strict json-rpc response,
+// which has the data as its result or the err_no and err_msg in its error object, and the signature next to them.
+func Verify(publicKey string, method string, params json.RawMessage, resp []byte) (*Signature, error) {
+	var envelope struct {
+		ErrNo     int             `json:"err_no"`
+		ErrMsg    string          `json:"err_msg"`
+		Data      json.RawMessage `json:"data"`
+		JsonRpc   string          `json:"jsonrpc"`
+		Result    json.RawMessage `json:"result"`
+		Error     *jsonRpcError   `json:"error"`
+		Signature *Signature      `json:"signature"`
+	}
+	if err := json.Unmarshal(resp, &envelope); err != nil {
+		return nil, fmt.Errorf("json.Unmarshal err: %s", err.Error())
+	}
+	if envelope.JsonRpc != "" {
+		if envelope.Signature == nil && envelope.Error == nil && len(envelope.Result) > 0 && envelope.Result[0] == '{' {
+			// not strict, the result is the api envelope
+			return Verify(publicKey, method, params, envelope.Result)
+		} else if envelope.Error != nil {
+			envelope.ErrNo, envelope.ErrMsg, envelope.Data = envelope.Error.Data.ErrNo, envelope.Error.Message, nil
+		} else {
+			envelope.ErrNo, envelope.ErrMsg, envelope.Data = 0, "", envelope.Result
+		}
+	}
+	if envelope.Signature == nil {
+		return nil, fmt.Errorf("response not signed")
+	}
+	if err := VerifySignature(publicKey, method, params, envelope.ErrNo, envelope.ErrMsg, envelope.Data, envelope.Signature); err != nil {
+		return nil, err
+	}
+	return envelope.Signature, nil
+}
+
+type jsonRpcError struct {
+	Message string `json:"message"`
+	Data    struct {
+		ErrNo int `json:"err_no"`
+	} `json:"data"`
+}
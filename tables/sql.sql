@@ -34,6 +34,8 @@ CREATE TABLE IF NOT EXISTS `t_account_info`
     `parent_account_id`       varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT '' COMMENT '',
     `next_account_id`         varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT '' COMMENT 'hash of next account',
     `account`                 varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT '' COMMENT '',
+    `account_length`          int(11) unsigned                                              NOT NULL DEFAULT '0' COMMENT 'chars of the first label',
+    `char_set`                bigint(20) unsigned                                           NOT NULL DEFAULT '0' COMMENT 'bitmap of AccountCharType',
     `owner_chain_type`        smallint(6)                                                   NOT NULL DEFAULT '0' COMMENT '',
     `owner`                   varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT '' COMMENT 'owner address',
     `owner_algorithm_id`      smallint(6)                                                   NOT NULL DEFAULT '0' COMMENT '',
@@ -51,10 +53,13 @@ CREATE TABLE IF NOT EXISTS `t_account_info`
     PRIMARY KEY (`id`) USING BTREE,
     UNIQUE KEY `uk_account_id` (`account_id`) USING BTREE,
     KEY `k_account` (`account`) USING BTREE,
+    KEY `k_account_length` (`account_length`) USING BTREE,
     KEY `k_next_account_id` (`next_account_id`) USING BTREE,
     KEY `k_oct_o` (`owner_chain_type`, `owner`) USING BTREE,
     KEY `k_mct_m` (`manager_chain_type`, `manager`) USING BTREE,
-    KEY `k_parent_account_id` (`parent_account_id`)
+    KEY `k_parent_account_id` (`parent_account_id`),
+    KEY `k_expired_at` (`expired_at`),
+    KEY `k_block_number` (`block_number`)
 ) ENGINE = InnoDB
   DEFAULT CHARSET = utf8mb4
   COLLATE = utf8mb4_0900_ai_ci COMMENT ='current account info';
@@ -84,6 +89,23 @@ CREATE TABLE IF NOT EXISTS `t_records_info`
   DEFAULT CHARSET = utf8mb4
   COLLATE = utf8mb4_0900_ai_ci COMMENT ='records info in DAS account setting';
 
+-- ----------------------------
+-- Table structure for t_backfill_info
+-- ----------------------------
+CREATE TABLE IF NOT EXISTS `t_backfill_info`
+(
+    `id`         bigint(20) unsigned                                          NOT NULL AUTO_INCREMENT COMMENT '',
+    `name`       varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT '' COMMENT '',
+    `last_id`    bigint(20) unsigned                                          NOT NULL DEFAULT '0' COMMENT 'rows up to it are done',
+    `done`       tinyint(1)                                                   NOT NULL DEFAULT '0' COMMENT '',
+    `created_at` timestamp                                                    NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '',
+    `updated_at` timestamp                                                    NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '',
+    PRIMARY KEY (id),
+    UNIQUE KEY uk_name (name)
+) ENGINE = InnoDB
+  DEFAULT CHARSET = utf8mb4
+  COLLATE = utf8mb4_0900_ai_ci COMMENT ='progress of backfills of existing rows';
+
 -- ----------------------------
 -- Table structure for t_reverse_info
 -- ----------------------------
@@ -103,13 +125,165 @@ CREATE TABLE IF NOT EXISTS `t_reverse_info`
     PRIMARY KEY (id),
     UNIQUE KEY uk_outpoint (outpoint),
     KEY k_address (chain_type, address),
-    KEY k_account (account)
+    KEY k_account (account),
+    KEY k_block_number (block_number)
 ) ENGINE = InnoDB
   DEFAULT CHARSET = utf8mb4
   COLLATE = utf8mb4_0900_ai_ci COMMENT ='reverse records info';
 
+-- ----------------------------
+-- Table structure for t_did_cell_info
+-- ----------------------------
+CREATE TABLE IF NOT EXISTS `t_did_cell_info`
+(
+    `id`             bigint(20) unsigned                                           NOT NULL AUTO_INCREMENT COMMENT '',
+    `block_number`   bigint(20) unsigned                                           NOT NULL DEFAULT '0' COMMENT '',
+    `outpoint`       varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT '' COMMENT '',
+    `account_id`     varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT '' COMMENT 'hash of account',
+    `account`        varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT '' COMMENT '',
+    `args`           varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT '' COMMENT '',
+    `lock_code_hash` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT '' COMMENT '',
+    `expired_at`     bigint(20) unsigned                                           NOT NULL DEFAULT '0' COMMENT '',
+    `created_at`     timestamp                                                     NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '',
+    `updated_at`     timestamp                                                     NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '',
+    PRIMARY KEY (id),
+    UNIQUE KEY uk_op (outpoint),
+    KEY account (account),
+    KEY k_account_id (account_id),
+    KEY k_expired_at (expired_at),
+    KEY k_block_number (block_number)
+) ENGINE = InnoDB
+  DEFAULT CHARSET = utf8mb4
+  COLLATE = utf8mb4_0900_ai_ci COMMENT ='did cells info';
+
+-- ----------------------------
+-- Table structure for t_deleted_info
+-- ----------------------------
+CREATE TABLE IF NOT EXISTS `t_deleted_info`
+(
+    `id`           bigint(20) unsigned                                           NOT NULL AUTO_INCREMENT COMMENT '',
+    `block_number` bigint(20) unsigned                                           NOT NULL DEFAULT '0' COMMENT '',
+    `entity`       varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT '' COMMENT 'account, records, did_cell, reverse',
+    `entity_key`   varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT '' COMMENT 'account_id or outpoint',
+    `created_at`   timestamp                                                     NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '',
+    PRIMARY KEY (id),
+    KEY k_block_number (block_number)
+) ENGINE = InnoDB
+  DEFAULT CHARSET = utf8mb4
+  COLLATE = utf8mb4_0900_ai_ci COMMENT ='rows deleted by the parser';
+
+-- ----------------------------
+-- Table structure for t_sub_account_stats_daily
+-- ----------------------------
+CREATE TABLE IF NOT EXISTS `t_sub_account_stats_daily`
+(
+    `id`                bigint(20) unsigned                                           NOT NULL AUTO_INCREMENT COMMENT '',
+    `parent_account_id` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT '' COMMENT '',
+    `kind`              varchar(32) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci  NOT NULL DEFAULT '' COMMENT 'expire, mint',
+    `day`               bigint(20) unsigned                                           NOT NULL DEFAULT '0' COMMENT 'unix time / 86400',
+    `count`             bigint(20)                                                    NOT NULL DEFAULT '0' COMMENT '',
+    `created_at`        timestamp                                                     NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '',
+    `updated_at`        timestamp                                                     NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '',
+    PRIMARY KEY (id),
+    UNIQUE KEY uk_parent_kind_day (parent_account_id, kind, day)
+) ENGINE = InnoDB
+  DEFAULT CHARSET = utf8mb4
+  COLLATE = utf8mb4_0900_ai_ci COMMENT ='sub-accounts of each parent by expiry and mint day';
+
+-- ----------------------------
+-- Table structure for t_sub_account_stats_owner
+-- ----------------------------
+CREATE TABLE IF NOT EXISTS `t_sub_account_stats_owner`
+(
+    `id`                bigint(20) unsigned                                           NOT NULL AUTO_INCREMENT COMMENT '',
+    `parent_account_id` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT '' COMMENT '',
+    `owner_chain_type`  smallint(6)                                                   NOT NULL DEFAULT '0' COMMENT '',
+    `owner`             varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT '' COMMENT '',
+    `count`             bigint(20)                                                    NOT NULL DEFAULT '0' COMMENT '',
+    `created_at`        timestamp                                                     NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '',
+    `updated_at`        timestamp                                                     NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '',
+    PRIMARY KEY (id),
+    UNIQUE KEY uk_parent_owner (parent_account_id, owner_chain_type, owner)
+) ENGINE = InnoDB
+  DEFAULT CHARSET = utf8mb4
+  COLLATE = utf8mb4_0900_ai_ci COMMENT ='sub-accounts of each parent by owner';
+
+-- ----------------------------
+-- Table structure for t_expiry_notice
+-- ----------------------------
+CREATE TABLE IF NOT EXISTS `t_expiry_notice`
+(
+    `id`         bigint(20) unsigned                                            NOT NULL AUTO_INCREMENT COMMENT '',
+    `account_id` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci  NOT NULL DEFAULT '' COMMENT '',
+    `expired_at` bigint(20) unsigned                                            NOT NULL DEFAULT '0' COMMENT 'a renewal starts the stages over',
+    `stage`      varchar(32) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci   NOT NULL DEFAULT '' COMMENT 't-30, t-7, t-1, grace',
+    `channel`    varchar(32) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci   NOT NULL DEFAULT '' COMMENT 'webhook, smtp',
+    `account`    varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci  NOT NULL DEFAULT '' COMMENT '',
+    `recipient`  varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci  NOT NULL DEFAULT '' COMMENT '',
+    `status`     smallint(6)                                                    NOT NULL DEFAULT '0' COMMENT '0-pending 1-sent 2-failed',
+    `attempts`   int(11)                                                        NOT NULL DEFAULT '0' COMMENT '',
+    `err_msg`    varchar(1024) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT '' COMMENT '',
+    `created_at` timestamp                                                      NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '',
+    `updated_at` timestamp                                                      NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '',
+    PRIMARY KEY (id),
+    UNIQUE KEY uk_notice (account_id, expired_at, stage, channel)
+) ENGINE = InnoDB
+  DEFAULT CHARSET = utf8mb4
+  COLLATE = utf8mb4_0900_ai_ci COMMENT ='delivery state of expiry notices';
+
+-- ----------------------------
+-- Table structure for t_api_key
+-- ----------------------------
+CREATE TABLE IF NOT EXISTS `t_api_key`
+(
+    `id`          bigint(20) unsigned                                          NOT NULL AUTO_INCREMENT COMMENT '',
+    `name`        varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT '' COMMENT 'label in the metrics',
+    `key_hash`    varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT '' COMMENT 'hex sha256 of the key',
+    `rate`        double                                                       NOT NULL DEFAULT '0' COMMENT 'calls per second, 0 is unlimited',
+    `burst`       int(11)                                                      NOT NULL DEFAULT '0' COMMENT '',
+    `daily_quota` bigint(20)                                                   NOT NULL DEFAULT '0' COMMENT 'calls per UTC day, 0 is unlimited',
+    `status`      smallint(6)                                                  NOT NULL DEFAULT '0' COMMENT '0-enabled 1-disabled',
+    `created_at`  timestamp                                                    NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '',
+    `updated_at`  timestamp                                                    NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '',
+    PRIMARY KEY (id),
+    UNIQUE KEY uk_name (name),
+    UNIQUE KEY uk_key_hash (key_hash)
+) ENGINE = InnoDB
+  DEFAULT CHARSET = utf8mb4
+  COLLATE = utf8mb4_0900_ai_ci COMMENT ='api keys and their limits';
+
+-- ----------------------------
+-- Table structure for t_state_history
+-- ----------------------------
+CREATE TABLE IF NOT EXISTS `t_state_history`
+(
+    `id`         bigint(20) unsigned                                           NOT NULL AUTO_INCREMENT COMMENT '',
+    `entity`     varchar(32) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci  NOT NULL DEFAULT '' COMMENT 'account, records, did_cell, reverse, start',
+    `entity_key` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT '' COMMENT 'account_id or outpoint',
+    `lookup_key` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT '' COMMENT 'account_id, or chain_type:address for reverse records',
+    `from_block` bigint(20) unsigned                                           NOT NULL DEFAULT '0' COMMENT '',
+    `to_block`   bigint(20) unsigned                                           NOT NULL DEFAULT '0' COMMENT '',
+    `data`       mediumtext CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci   NOT NULL COMMENT 'json of the row, or of the record list',
+    `created_at` timestamp                                                     NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '',
+    PRIMARY KEY (id),
+    KEY k_entity_key (entity, entity_key),
+    KEY k_lookup_key (entity, lookup_key, from_block),
+    KEY k_from_block (from_block),
+    KEY k_to_block (to_block)
+) ENGINE = InnoDB
+  DEFAULT CHARSET = utf8mb4
+  COLLATE = utf8mb4_0900_ai_ci COMMENT ='states of accounts, records, did cells and reverse records by block';
+
 -- # DROP TABLES
 -- # DROP TABLE IF EXISTS `t_block_info`;
 -- # DROP TABLE IF EXISTS `t_account_info`;
 -- # DROP TABLE IF EXISTS `t_records_info`;
--- # DROP TABLE IF EXISTS `t_reverse_info`;
\ No newline at end of file
+-- # DROP TABLE IF EXISTS `t_backfill_info`;
+-- # DROP TABLE IF EXISTS `t_reverse_info`;
+-- # DROP TABLE IF EXISTS `t_did_cell_info`;
+-- # DROP TABLE IF EXISTS `t_deleted_info`;
+-- # DROP TABLE IF EXISTS `t_sub_account_stats_daily`;
+-- # DROP TABLE IF EXISTS `t_sub_account_stats_owner`;
+-- # DROP TABLE IF EXISTS `t_expiry_notice`;
+-- # DROP TABLE IF EXISTS `t_api_key`;
+-- # DROP TABLE IF EXISTS `t_state_history`;
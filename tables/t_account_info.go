@@ -2,18 +2,21 @@ package tables
 
 import (
 	"github.com/dotbitHQ/das-lib/common"
+	"strings"
 	"time"
 )
 
 type TableAccountInfo struct {
 	Id                   uint64                   `json:"id" gorm:"column:id;primary_key;type:bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT ''"`
-	BlockNumber          uint64                   `json:"block_number" gorm:"column:block_number;type:bigint(20) NOT NULL DEFAULT '0' COMMENT ''"`
+	BlockNumber          uint64                   `json:"block_number" gorm:"column:block_number;index:k_block_number;type:bigint(20) NOT NULL DEFAULT '0' COMMENT ''"`
 	BlockTimestamp       uint64                   `json:"block_timestamp" gorm:"column:block_timestamp;type:varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT '' COMMENT 'Hash-Index'"`
 	Outpoint             string                   `json:"outpoint" gorm:"column:outpoint;type:varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT '' COMMENT 'Hash-Index'"`
 	AccountId            string                   `json:"account_id" gorm:"column:account_id;uniqueIndex:uk_account_id;type:varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT '' COMMENT 'hash of account'"`
 	ParentAccountId      string                   `json:"parent_account_id" gorm:"column:parent_account_id;index:k_parent_account_id;type:varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT '' COMMENT ''"`
 	NextAccountId        string                   `json:"next_account_id" gorm:"column:next_account_id;index:k_next_account_id;type:varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT '' COMMENT 'hash of next account'"`
 	Account              string                   `json:"account" gorm:"column:account;index:k_account;type:varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT '' COMMENT ''"`
+	AccountLength        uint32                   `json:"account_length" gorm:"column:account_length;index:k_account_length;type:int(11) unsigned NOT NULL DEFAULT '0' COMMENT 'chars of the first label'"`
+	CharSet              uint64                   `json:"char_set" gorm:"column:char_set;type:bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT 'bitmap of AccountCharType'"`
 	OwnerChainType       common.ChainType         `json:"owner_chain_type" gorm:"column:owner_chain_type;index:k_oct_o;type:smallint(6) NOT NULL DEFAULT '0' COMMENT ''"`
 	Owner                string                   `json:"owner" gorm:"column:owner;index:k_oct_o;type:varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT '' COMMENT 'owner address'"`
 	OwnerAlgorithmId     common.DasAlgorithmId    `json:"owner_algorithm_id" gorm:"column:owner_algorithm_id;type:smallint(6) NOT NULL DEFAULT '0' COMMENT ''"`
@@ -27,7 +30,7 @@ type TableAccountInfo struct {
 	RenewSubAccountPrice uint64                   `json:"renew_sub_account_price" gorm:"column:renew_sub_account_price;type:bigint(20) NOT NULL DEFAULT '0' COMMENT ''"`
 	Nonce                uint64                   `json:"nonce" gorm:"column:nonce;type:bigint(20) NOT NULL DEFAULT '0' COMMENT ''"`
 	RegisteredAt         uint64                   `json:"registered_at" gorm:"column:registered_at;type:bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT ''"`
-	ExpiredAt            uint64                   `json:"expired_at" gorm:"column:expired_at;type:bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT ''"`
+	ExpiredAt            uint64                   `json:"expired_at" gorm:"column:expired_at;index:k_expired_at;type:bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT ''"`
 	CreatedAt            time.Time                `json:"created_at" gorm:"column:created_at;type:timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT ''"`
 	UpdatedAt            time.Time                `json:"updated_at" gorm:"column:updated_at;type:timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT ''"`
 }
@@ -49,6 +52,12 @@ const (
 	TableNameAccountInfo = "t_account_info"
 )
 
+// where an account is kept, t_account_info or t_did_cell_info once upgraded to a DID cell
+const (
+	AccountSourceAccount = "account"
+	AccountSourceDid     = "did_cell"
+)
+
 func (t *TableAccountInfo) TableName() string {
 	return TableNameAccountInfo
 }
@@ -59,3 +68,51 @@ func (t *TableAccountInfo) IsExpired() bool {
 	}
 	return false
 }
+
+// InitNameMeta precomputes the name columns used by the account name search
+func (t *TableAccountInfo) InitNameMeta() {
+	t.AccountLength, t.CharSet = GetAccountNameMeta(t.Account)
+}
+
+// GetAccountNameMeta returns the length of the first label in chars and the bitmap of the char sets it uses,
+// the char set maps are loaded by InitDasConfigCell, chars out of them are counted as emoji
+func GetAccountNameMeta(account string) (length uint32, charSet uint64) {
+	if !strings.HasSuffix(account, common.DasAccountSuffix) {
+		return
+	}
+	chars, count, _ := common.GetDotBitAccountLength(account)
+	length = uint32(count)
+	for _, char := range chars {
+		charSet |= common.AccountCharTypeToUint64(getCharType(char))
+	}
+	return
+}
+
+var charTypeOrder = []common.AccountCharType{
+	common.AccountCharTypeDigit,
+	common.AccountCharTypeEn,
+	common.AccountCharTypeHanS,
+	common.AccountCharTypeHanT,
+	common.AccountCharTypeJa,
+	common.AccountCharTypeKo,
+	common.AccountCharTypeVi,
+	common.AccountCharTypeRu,
+	common.AccountCharTypeTh,
+	common.AccountCharTypeTr,
+}
+
+func getCharType(char string) common.AccountCharType {
+	if len(char) == 1 {
+		if char[0] >= '0' && char[0] <= '9' {
+			return common.AccountCharTypeDigit
+		} else if char[0] >= 'a' && char[0] <= 'z' {
+			return common.AccountCharTypeEn
+		}
+	}
+	for _, v := range charTypeOrder {
+		if _, ok := common.AccountCharTypeMap[v][char]; ok {
+			return v
+		}
+	}
+	return common.AccountCharTypeEmoji
+}
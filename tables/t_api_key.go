@@ -0,0 +1,31 @@
+package tables
+
+import "time"
+
+type ApiKeyStatus int
+
+const (
+	ApiKeyStatusEnabled  ApiKeyStatus = 0
+	ApiKeyStatusDisabled ApiKeyStatus = 1
+)
+
+// TableApiKey holds api keys added with the apikey command, only the sha256 of a key is kept
+type TableApiKey struct {
+	Id         uint64       `json:"id" gorm:"column:id;primary_key;type:bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT ''"`
+	Name       string       `json:"name" gorm:"column:name;uniqueIndex:uk_name;type:varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT '' COMMENT 'label in the metrics'"`
+	KeyHash    string       `json:"key_hash" gorm:"column:key_hash;uniqueIndex:uk_key_hash;type:varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT '' COMMENT 'hex sha256 of the key'"`
+	Rate       float64      `json:"rate" gorm:"column:rate;type:double NOT NULL DEFAULT '0' COMMENT 'calls per second, 0 is unlimited'"`
+	Burst      int          `json:"burst" gorm:"column:burst;type:int(11) NOT NULL DEFAULT '0' COMMENT ''"`
+	DailyQuota int64        `json:"daily_quota" gorm:"column:daily_quota;type:bigint(20) NOT NULL DEFAULT '0' COMMENT 'calls per UTC day, 0 is unlimited'"`
+	Status     ApiKeyStatus `json:"status" gorm:"column:status;type:smallint(6) NOT NULL DEFAULT '0' COMMENT '0-enabled 1-disabled'"`
+	CreatedAt  time.Time    `json:"created_at" gorm:"column:created_at;type:timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT ''"`
+	UpdatedAt  time.Time    `json:"updated_at" gorm:"column:updated_at;type:timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT ''"`
+}
+
+const (
+	TableNameApiKey = "t_api_key"
+)
+
+func (t *TableApiKey) TableName() string {
+	return TableNameApiKey
+}
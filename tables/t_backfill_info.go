@@ -0,0 +1,24 @@
+package tables
+
+import "time"
+
+// TableBackfillInfo is how far a backfill of the rows written before a column existed got,
+// it goes on from there on the next start and is not run again once done
+type TableBackfillInfo struct {
+	Id        uint64    `json:"id" gorm:"column:id;primary_key;type:bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT ''"`
+	Name      string    `json:"name" gorm:"column:name;uniqueIndex:uk_name;type:varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT '' COMMENT ''"`
+	LastId    uint64    `json:"last_id" gorm:"column:last_id;type:bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT 'rows up to it are done'"`
+	Done      bool      `json:"done" gorm:"column:done;type:tinyint(1) NOT NULL DEFAULT '0' COMMENT ''"`
+	CreatedAt time.Time `json:"created_at" gorm:"column:created_at;type:timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT ''"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"column:updated_at;type:timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT ''"`
+}
+
+const (
+	TableNameBackfillInfo = "t_backfill_info"
+
+	BackfillNameMeta = "account_name_meta"
+)
+
+func (t *TableBackfillInfo) TableName() string {
+	return TableNameBackfillInfo
+}
@@ -0,0 +1,26 @@
+package tables
+
+import "time"
+
+// TableDeletedInfo keeps a row for each account, record list, DID cell or reverse record the parser deletes,
+// the changefeed reads what still exists from the tables themselves
+type TableDeletedInfo struct {
+	Id          uint64    `json:"id" gorm:"column:id;primary_key;type:bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT ''"`
+	BlockNumber uint64    `json:"block_number" gorm:"column:block_number;index:k_block_number;type:bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT ''"`
+	Entity      string    `json:"entity" gorm:"column:entity;type:varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT '' COMMENT 'account, records, did_cell, reverse'"`
+	EntityKey   string    `json:"entity_key" gorm:"column:entity_key;type:varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT '' COMMENT 'account_id or outpoint'"`
+	CreatedAt   time.Time `json:"created_at" gorm:"column:created_at;type:timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT ''"`
+}
+
+const (
+	TableNameDeletedInfo = "t_deleted_info"
+
+	DeletedEntityAccount = "account"
+	DeletedEntityRecords = "records"
+	DeletedEntityDidCell = "did_cell"
+	DeletedEntityReverse = "reverse"
+)
+
+func (t *TableDeletedInfo) TableName() string {
+	return TableNameDeletedInfo
+}
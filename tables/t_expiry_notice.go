@@ -0,0 +1,35 @@
+package tables
+
+import "time"
+
+type ExpiryNoticeStatus int
+
+const (
+	ExpiryNoticeStatusPending ExpiryNoticeStatus = 0 // claimed, being sent, never sent again if the process stops here
+	ExpiryNoticeStatusSent    ExpiryNoticeStatus = 1
+	ExpiryNoticeStatusFailed  ExpiryNoticeStatus = 2 // retried on the next scans
+)
+
+// TableExpiryNotice is the delivery state of the expiry notices, one row per account expiry, stage and channel
+type TableExpiryNotice struct {
+	Id        uint64             `json:"id" gorm:"column:id;primary_key;type:bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT ''"`
+	AccountId string             `json:"account_id" gorm:"column:account_id;uniqueIndex:uk_notice;type:varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT '' COMMENT ''"`
+	ExpiredAt uint64             `json:"expired_at" gorm:"column:expired_at;uniqueIndex:uk_notice;type:bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT 'a renewal starts the stages over'"`
+	Stage     string             `json:"stage" gorm:"column:stage;uniqueIndex:uk_notice;type:varchar(32) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT '' COMMENT 't-30, t-7, t-1, grace'"`
+	Channel   string             `json:"channel" gorm:"column:channel;uniqueIndex:uk_notice;type:varchar(32) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT '' COMMENT 'webhook, smtp'"`
+	Account   string             `json:"account" gorm:"column:account;type:varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT '' COMMENT ''"`
+	Recipient string             `json:"recipient" gorm:"column:recipient;type:varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT '' COMMENT ''"`
+	Status    ExpiryNoticeStatus `json:"status" gorm:"column:status;type:smallint(6) NOT NULL DEFAULT '0' COMMENT '0-pending 1-sent 2-failed'"`
+	Attempts  int                `json:"attempts" gorm:"column:attempts;type:int(11) NOT NULL DEFAULT '0' COMMENT ''"`
+	ErrMsg    string             `json:"err_msg" gorm:"column:err_msg;type:varchar(1024) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT '' COMMENT ''"`
+	CreatedAt time.Time          `json:"created_at" gorm:"column:created_at;type:timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT ''"`
+	UpdatedAt time.Time          `json:"updated_at" gorm:"column:updated_at;type:timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT ''"`
+}
+
+const (
+	TableNameExpiryNotice = "t_expiry_notice"
+)
+
+func (t *TableExpiryNotice) TableName() string {
+	return TableNameExpiryNotice
+}
@@ -7,7 +7,7 @@ import (
 
 type TableReverseInfo struct {
 	Id             uint64                   `json:"id" gorm:"column:id;primary_key;type:bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT ''"`
-	BlockNumber    uint64                   `json:"block_number" gorm:"column:block_number;type:bigint(20) NOT NULL DEFAULT '0' COMMENT ''"`
+	BlockNumber    uint64                   `json:"block_number" gorm:"column:block_number;index:k_block_number;type:bigint(20) NOT NULL DEFAULT '0' COMMENT ''"`
 	BlockTimestamp uint64                   `json:"block_timestamp" gorm:"column:block_timestamp;type:bigint(20) NOT NULL DEFAULT '0' COMMENT ''"`
 	Outpoint       string                   `json:"outpoint" gorm:"column:outpoint;uniqueIndex:uk_outpoint;type:varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT '' COMMENT ''"`
 	AlgorithmId    common.DasAlgorithmId    `json:"algorithm_id" gorm:"column:algorithm_id;type:smallint(6) NOT NULL DEFAULT '0' COMMENT ''"`
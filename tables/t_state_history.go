@@ -0,0 +1,31 @@
+package tables
+
+import (
+	"math"
+	"time"
+)
+
+// TableStateHistory keeps each state of an account, record list, DID cell or reverse record for as_of_block queries,
+// a row holds the state of one key for blocks from_block to to_block, to_block left out
+type TableStateHistory struct {
+	Id        uint64    `json:"id" gorm:"column:id;primary_key;type:bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT ''"`
+	Entity    string    `json:"entity" gorm:"column:entity;index:k_entity_key,priority:1;index:k_lookup_key,priority:1;type:varchar(32) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT '' COMMENT 'account, records, did_cell, reverse, start'"`
+	EntityKey string    `json:"entity_key" gorm:"column:entity_key;index:k_entity_key,priority:2;type:varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT '' COMMENT 'account_id or outpoint'"`
+	LookupKey string    `json:"lookup_key" gorm:"column:lookup_key;index:k_lookup_key,priority:2;type:varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT '' COMMENT 'account_id, or chain_type:address for reverse records'"`
+	FromBlock uint64    `json:"from_block" gorm:"column:from_block;index:k_lookup_key,priority:3;index:k_from_block;type:bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT ''"`
+	ToBlock   uint64    `json:"to_block" gorm:"column:to_block;index:k_to_block;type:bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT ''"`
+	Data      string    `json:"data" gorm:"column:data;type:mediumtext CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL COMMENT 'json of the row, or of the record list'"`
+	CreatedAt time.Time `json:"created_at" gorm:"column:created_at;type:timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT ''"`
+}
+
+const (
+	TableNameStateHistory = "t_state_history"
+
+	// StateHistoryEntityStart marks a history built up to the end, its from_block is the first block kept
+	StateHistoryEntityStart = "start"
+	StateHistoryOpen        = uint64(math.MaxInt64) // to_block of states still current
+)
+
+func (t *TableStateHistory) TableName() string {
+	return TableNameStateHistory
+}
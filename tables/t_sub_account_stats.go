@@ -0,0 +1,51 @@
+package tables
+
+import (
+	"github.com/dotbitHQ/das-lib/common"
+	"time"
+)
+
+// sub-account aggregates of each parent, kept up to date by the parser along with t_account_info
+
+type SubAccountStatsKind string
+
+const (
+	SubAccountStatsKindExpire SubAccountStatsKind = "expire" // by the day of expired_at
+	SubAccountStatsKindMint   SubAccountStatsKind = "mint"   // by the day of registered_at, recycling takes it back
+)
+
+type TableSubAccountStatsDaily struct {
+	Id              uint64              `json:"id" gorm:"column:id;primary_key;type:bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT ''"`
+	ParentAccountId string              `json:"parent_account_id" gorm:"column:parent_account_id;uniqueIndex:uk_parent_kind_day;type:varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT '' COMMENT ''"`
+	Kind            SubAccountStatsKind `json:"kind" gorm:"column:kind;uniqueIndex:uk_parent_kind_day;type:varchar(32) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT '' COMMENT 'expire, mint'"`
+	Day             uint64              `json:"day" gorm:"column:day;uniqueIndex:uk_parent_kind_day;type:bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT 'unix time / 86400'"`
+	Count           int64               `json:"count" gorm:"column:count;type:bigint(20) NOT NULL DEFAULT '0' COMMENT ''"`
+	CreatedAt       time.Time           `json:"created_at" gorm:"column:created_at;type:timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT ''"`
+	UpdatedAt       time.Time           `json:"updated_at" gorm:"column:updated_at;type:timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT ''"`
+}
+
+const (
+	TableNameSubAccountStatsDaily = "t_sub_account_stats_daily"
+)
+
+func (t *TableSubAccountStatsDaily) TableName() string {
+	return TableNameSubAccountStatsDaily
+}
+
+type TableSubAccountStatsOwner struct {
+	Id              uint64           `json:"id" gorm:"column:id;primary_key;type:bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT ''"`
+	ParentAccountId string           `json:"parent_account_id" gorm:"column:parent_account_id;uniqueIndex:uk_parent_owner;type:varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT '' COMMENT ''"`
+	OwnerChainType  common.ChainType `json:"owner_chain_type" gorm:"column:owner_chain_type;uniqueIndex:uk_parent_owner;type:smallint(6) NOT NULL DEFAULT '0' COMMENT ''"`
+	Owner           string           `json:"owner" gorm:"column:owner;uniqueIndex:uk_parent_owner;type:varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT '' COMMENT ''"`
+	Count           int64            `json:"count" gorm:"column:count;type:bigint(20) NOT NULL DEFAULT '0' COMMENT ''"`
+	CreatedAt       time.Time        `json:"created_at" gorm:"column:created_at;type:timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT ''"`
+	UpdatedAt       time.Time        `json:"updated_at" gorm:"column:updated_at;type:timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT ''"`
+}
+
+const (
+	TableNameSubAccountStatsOwner = "t_sub_account_stats_owner"
+)
+
+func (t *TableSubAccountStatsOwner) TableName() string {
+	return TableNameSubAccountStatsOwner
+}